@@ -0,0 +1,76 @@
+// Command chaos
+//
+// English:
+//
+//	Helper of the chaos tests, e.g. removes the docker elements kept by Primordial.KeepOnFailure().
+//
+//	  chaos clean --session 20221120T131500-3f2a9c1d
+//	  chaos clean --session 20221120T131500-3f2a9c1d --dry-run
+//	  chaos clean --all
+//
+// Português:
+//
+//	Auxiliar dos testes de caos, ex. remove os elementos docker mantidos por Primordial.KeepOnFailure().
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/helmutkemper/chaos/internal/standalone"
+	"io"
+	"os"
+)
+
+const kUsage = `usage:
+  chaos clean --session <id> [--dry-run]
+  chaos clean --all [--dry-run]
+`
+
+// garbageCollectorSession removes the docker elements, replaced by the tests
+var garbageCollectorSession = standalone.GarbageCollectorSession
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run
+//
+// Runs the command and returns the exit code
+func run(args []string, stdout, stderr io.Writer) (code int) {
+	if len(args) == 0 || args[0] != "clean" {
+		_, _ = fmt.Fprint(stderr, kUsage)
+		return 2
+	}
+
+	var flags = flag.NewFlagSet("clean", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var session = flags.String("session", "", "id of the session, printed by KeepOnFailure()")
+	var all = flags.Bool("all", false, "all sessions of this project")
+	var dryRun = flags.Bool("dry-run", false, "only lists the docker elements, without removing them")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if (*session == "") == !*all || flags.NArg() != 0 {
+		_, _ = fmt.Fprint(stderr, kUsage)
+		return 2
+	}
+
+	var resources, err = garbageCollectorSession(*session, *dryRun)
+	for _, resource := range resources {
+		_, _ = fmt.Fprintf(stdout, "%v %v\n", resource.Kind, resource.Name)
+	}
+
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "chaos clean: %v\n", err)
+		return 1
+	}
+
+	if *dryRun {
+		_, _ = fmt.Fprintf(stdout, "%v docker elements would be removed\n", len(resources))
+	} else {
+		_, _ = fmt.Fprintf(stdout, "%v docker elements removed\n", len(resources))
+	}
+
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"github.com/helmutkemper/chaos/internal/builder"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var collector = garbageCollectorSession
+	defer func() { garbageCollectorSession = collector }()
+
+	var calls []string
+	garbageCollectorSession = func(session string, dryRun bool) ([]builder.Resource, error) {
+		calls = append(calls, session)
+		return []builder.Resource{{Kind: builder.KResourceContainer, Name: "delete_db_0"}}, nil
+	}
+
+	for _, args := range [][]string{nil, {"list"}, {"clean"}, {"clean", "--session", "a", "--all"}, {"clean", "--session"}} {
+		var stderr bytes.Buffer
+		if code := run(args, new(bytes.Buffer), &stderr); code != 2 || stderr.Len() == 0 {
+			t.Errorf("%v must fail with the usage: %v", args, code)
+		}
+	}
+
+	if len(calls) != 0 {
+		t.Fatalf("invalid arguments must not remove: %v", calls)
+	}
+
+	var stdout bytes.Buffer
+	if code := run([]string{"clean", "--session", "a", "--dry-run"}, &stdout, new(bytes.Buffer)); code != 0 {
+		t.Errorf("unexpected exit code: %v", code)
+	}
+
+	if !strings.Contains(stdout.String(), "container delete_db_0\n1 docker elements would be removed") || calls[0] != "a" {
+		t.Errorf("unexpected output: %v", stdout.String())
+	}
+
+	if code := run([]string{"clean", "--all"}, new(bytes.Buffer), new(bytes.Buffer)); code != 0 || calls[1] != "" {
+		t.Errorf("--all must clean all sessions: %v, %v", code, calls)
+	}
+}
@@ -0,0 +1,36 @@
+package factory
+
+import (
+	"github.com/helmutkemper/chaos/internal/manager"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"github.com/helmutkemper/chaos/internal/standalone"
+)
+
+// Units used by Memory(), MemorySwap(), Tmpfs() and BlkioDevice*Bps(). e.g. 512 * factory.KMegaByte
+const (
+	KKiloByte = 1024
+	KMegaByte = 1024 * KKiloByte
+	KGigaByte = 1024 * KMegaByte
+)
+
+// Status of the test in Result.Status, returned by Primordial.MonitorResult()
+const (
+	KStatusPass  = monitor.KStatusPass
+	KStatusFail  = monitor.KStatusFail
+	KStatusError = monitor.KStatusError
+)
+
+// Labels of ownership of all docker elements created by the tests, used by GarbageCollector()
+const (
+	KLabelProject = standalone.KLabelProject
+	KLabelSession = standalone.KLabelSession
+
+	// Marks a session kept by Primordial.KeepOnFailure(), only removed by chaos clean --session <id>
+	KLabelKeep = standalone.KLabelKeep
+
+	// Environment variable of the session id, returned by Primordial.GetSessionId()
+	KSessionEnv = standalone.KSessionEnv
+)
+
+// Environment variable which enables Primordial.KeepOnFailure(), e.g. CHAOS_KEEP_ON_FAILURE=true go test ./...
+const KKeepOnFailureEnv = manager.KKeepOnFailureEnv
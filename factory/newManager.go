@@ -103,7 +103,9 @@ func NewContainerFromImage(imageName string) (reference *manager.ContainerFromIm
 }
 
 func NewPrimordial() (reference *manager.Primordial) {
-	standalone.GarbageCollector()
+	// removes the docker elements of this project left by dead tests, e.g. killed before the cleanup, but not the
+	// elements of other test processes still running, e.g. other packages of go test ./...
+	_, _ = standalone.GarbageCollectorDead(standalone.KSessionMaxAge)
 
 	ref := new(manager.Manager)
 	ref.New()
@@ -0,0 +1,33 @@
+package factory
+
+import "github.com/helmutkemper/chaos/internal/builder"
+
+// NewRestartPolicyNo
+//
+// Restart policy used by RestartPolicy(). Do not automatically restart the container (the default)
+func NewRestartPolicyNo() (policy builder.RestartPolicy) {
+	return builder.NewRestartPolicyRestartPolicyNoRestart()
+}
+
+// NewRestartPolicyOnFailure
+//
+// Restart policy used by RestartPolicy(). Restart the container if it exits due to an error, which manifests as a
+// non-zero exit code
+func NewRestartPolicyOnFailure() (policy builder.RestartPolicy) {
+	return builder.NewRestartPolicyOnFailureRestart()
+}
+
+// NewRestartPolicyAlways
+//
+// Restart policy used by RestartPolicy(). Always restart the container if it stops, unless it is manually stopped
+func NewRestartPolicyAlways() (policy builder.RestartPolicy) {
+	return builder.NewKRestartPolicyAlwaysRestart()
+}
+
+// NewRestartPolicyUnlessStopped
+//
+// Restart policy used by RestartPolicy(). Similar to always, except that when the container is stopped, it is not
+// restarted even after Docker daemon restarts
+func NewRestartPolicyUnlessStopped() (policy builder.RestartPolicy) {
+	return builder.NewRestartPolicyRestartPolicyUnlessStopped()
+}
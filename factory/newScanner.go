@@ -0,0 +1,61 @@
+package factory
+
+import "github.com/helmutkemper/chaos/internal/manager"
+
+// Severity levels used by VulnerabilityThreshold()
+const (
+	KSeverityLow      = manager.KSeverityLow
+	KSeverityMedium   = manager.KSeverityMedium
+	KSeverityHigh     = manager.KSeverityHigh
+	KSeverityCritical = manager.KSeverityCritical
+)
+
+// SBOM formats used by Sbom()
+const (
+	KSbomSpdx      = manager.KSbomSpdx
+	KSbomCycloneDx = manager.KSbomCycloneDx
+)
+
+// NewScannerOsv
+//
+// osv-scanner image, on the local computer, used by VulnerabilityScannerEngine(). Scans the lockfiles of the project.
+//
+//	Input:
+//	  imageName: local image. e.g. ghcr.io/google/osv-scanner:v1.3.6
+//	  dbPath: folder with the local database, downloaded by `osv-scanner --experimental-local-db`, or empty string
+//	    to use the online database
+func NewScannerOsv(imageName, dbPath string) (scanner manager.ScannerOsv) {
+	return manager.ScannerOsv{Image: imageName, DbPath: dbPath}
+}
+
+// NewScannerGrype
+//
+// Grype image, on the local computer, used by VulnerabilityScannerEngine().
+//
+//	Input:
+//	  imageName: local image. e.g. anchore/grype:v0.65.0
+//	  dbPath: folder with the grype database, or empty string to update the database online
+func NewScannerGrype(imageName, dbPath string) (scanner manager.ScannerGrype) {
+	return manager.ScannerGrype{Image: imageName, DbPath: dbPath}
+}
+
+// NewScannerTrivy
+//
+// Trivy image, on the local computer, used by VulnerabilityScannerEngine() and Sbom().
+//
+//	Input:
+//	  imageName: local image. e.g. aquasec/trivy:0.44.0
+//	  dbPath: trivy cache folder, containing the db folder, or empty string to update the database online
+func NewScannerTrivy(imageName, dbPath string) (scanner manager.ScannerTrivy) {
+	return manager.ScannerTrivy{Image: imageName, DbPath: dbPath}
+}
+
+// NewSbomSyft
+//
+// Syft image, on the local computer, used by Sbom().
+//
+//	Input:
+//	  imageName: local image. e.g. anchore/syft:v0.85.0
+func NewSbomSyft(imageName string) (maker manager.SbomSyft) {
+	return manager.SbomSyft{Image: imageName}
+}
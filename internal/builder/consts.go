@@ -13,4 +13,5 @@ const (
 	kContainerPullStatusImageIsUpToDate          = "Status: Image is up to date for "
 	kContainerBuildImageStatusSuccessContainer   = "Success fully tagged"
 	kContainerBuildImageStatusSuccessImage       = "Successfully tagged"
+	kContainerBuildKitStatusImageId              = "moby.image.id"
 )
@@ -0,0 +1,45 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+// ContainerCommit (English): Creates an image from the current state of the container, like docker commit
+//
+//	id: string container id
+//	reference: name and tag of the image, e.g. forensics/delete_mongo.0:session
+//	labels: labels of the image, added to the labels of the container
+//
+//	Note: the labels defined by SetLabels() are not added, so the image is kept by the garbage collector
+//
+// ContainerCommit (Português): Cria uma imagem a partir do estado atual do container, como o docker commit
+//
+//	id: string id do container
+//	reference: nome e tag da imagem, ex. forensics/delete_mongo.0:session
+//	labels: rótulos da imagem, adicionados aos rótulos do container
+//
+//	Nota: os rótulos definidos por SetLabels() não são adicionados, para que a imagem seja mantida pelo coletor de lixo
+func (el *DockerSystem) ContainerCommit(
+	id string,
+	reference string,
+	labels map[string]string,
+) (
+	imageID string,
+	err error,
+) {
+
+	var response types.IDResponse
+	response, err = el.cli.ContainerCommit(el.ctx, id, types.ContainerCommitOptions{
+		Reference: reference,
+		Comment:   "chaos forensics",
+		Pause:     true,
+		Config:    &container.Config{Labels: labels},
+	})
+	if err != nil {
+		return
+	}
+
+	imageID = response.ID
+	return
+}
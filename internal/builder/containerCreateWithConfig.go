@@ -41,6 +41,8 @@ import (
 //	containerNetwork: container network configuration
 //	  Note: please, use NetworkCreate() for correct configuration of network
 //
+//	Note: the image platform can be defined by SetPlatform() and the resources (memory, cpu, etc.) by SetHostConfig()
+//
 // ContainerCreate (Português): Cria um container
 // listas na imagem
 //
@@ -78,6 +80,9 @@ import (
 //	      ImageListExposedVolumes(id) e ImageListExposedVolumesByName(name)
 //	containerNetwork: configuração de rede do container
 //	  Nota: Por favor, use NetworkCreate() para a forma correta da configuração de rede
+//
+//	Nota: a plataforma da imagem pode ser definida por SetPlatform() e os recursos (memória, cpu, etc.) por
+//	SetHostConfig()
 func (el *DockerSystem) ContainerCreateWithConfig(
 	configuration *container.Config,
 	containerName string,
@@ -97,20 +102,25 @@ func (el *DockerSystem) ContainerCreateWithConfig(
 		el.container = make(map[string]container.ContainerCreateCreatedBody)
 	}
 
+	// copy of the configuration defined by SetHostConfig(), so the arguments of this function don't change it
+	var hostConfig = el.hostConfig
+	hostConfig.PortBindings = portExposedList
+	hostConfig.RestartPolicy = container.RestartPolicy{
+		Name: restartPolicy.String(),
+	}
+	hostConfig.Mounts = mountVolumes
+
+	// copy of the configuration with the labels defined by SetLabels()
+	var config = *configuration
+	config.Labels = el.labelsMerge(configuration.Labels)
+
 	el.ContainerName = containerName
 	resp, err = el.cli.ContainerCreate(
 		el.ctx,
-		configuration,
-		&container.HostConfig{
-			PortBindings: portExposedList,
-			RestartPolicy: container.RestartPolicy{
-				Name: restartPolicy.String(),
-			},
-			Resources: container.Resources{}, //fixme: rever isto
-			Mounts:    mountVolumes,
-		},
+		&config,
+		&hostConfig,
 		containerNetwork,
-		nil,
+		el.platform,
 		containerName,
 	)
 	if err != nil {
@@ -0,0 +1,38 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types"
+)
+
+// ContainerExecDetached (English): Runs a command inside the container without waiting for its end
+//
+//	id: container ID
+//	commands: command and arguments. E.g.: []string{"/chaos-stress", "-cpu", "2"}
+//
+// ContainerExecDetached (Português): Executa um comando dentro do container sem esperar o seu fim
+//
+//	id: ID do container
+//	commands: comando e argumentos. Ex.: []string{"/chaos-stress", "-cpu", "2"}
+func (el *DockerSystem) ContainerExecDetached(
+	id string,
+	commands []string,
+) (
+	err error,
+) {
+
+	var idResponse types.IDResponse
+	idResponse, err = el.cli.ContainerExecCreate(
+		el.ctx,
+		id,
+		types.ExecConfig{
+			Cmd:    commands,
+			Detach: true,
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	err = el.cli.ContainerExecStart(el.ctx, idResponse.ID, types.ExecStartCheck{Detach: true})
+	return
+}
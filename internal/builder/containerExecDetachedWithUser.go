@@ -0,0 +1,44 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types"
+)
+
+// ContainerExecDetachedWithUser (English): Runs a command inside the container, as the user, without waiting for its
+// end
+//
+//	id: container ID
+//	user: user, in the format user[:group]. E.g.: "root"
+//	commands: command and arguments. E.g.: []string{"/chaos-stress", "-dns", "timeout"}
+//
+// ContainerExecDetachedWithUser (Português): Executa um comando dentro do container, como o usuário, sem esperar o
+// seu fim
+//
+//	id: ID do container
+//	user: usuário, no formato user[:group]. Ex.: "root"
+//	commands: comando e argumentos. Ex.: []string{"/chaos-stress", "-dns", "timeout"}
+func (el *DockerSystem) ContainerExecDetachedWithUser(
+	id string,
+	user string,
+	commands []string,
+) (
+	err error,
+) {
+
+	var idResponse types.IDResponse
+	idResponse, err = el.cli.ContainerExecCreate(
+		el.ctx,
+		id,
+		types.ExecConfig{
+			User:   user,
+			Cmd:    commands,
+			Detach: true,
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	err = el.cli.ContainerExecStart(el.ctx, idResponse.ID, types.ExecStartCheck{Detach: true})
+	return
+}
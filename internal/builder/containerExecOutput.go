@@ -0,0 +1,70 @@
+package builder
+
+import (
+	"bytes"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"time"
+)
+
+// ContainerExecOutput (English): Runs a command inside the container and returns the exit code and the separated
+// standard and error outputs, after the command ends
+//
+//	id: string container id
+//	command: command and arguments, e.g. []string{"mongosh", "--quiet", "--eval", "rs.status()"}
+//	timeout: maximum time of the command. The outputs until the timeout are returned with the error
+//
+// ContainerExecOutput (Português): Executa um comando dentro do container e retorna o código de saída e as saídas
+// padrão e de erro separadas, depois que o comando termina
+//
+//	id: string id do container
+//	command: comando e argumentos, ex. []string{"mongosh", "--quiet", "--eval", "rs.status()"}
+//	timeout: tempo máximo do comando. As saídas até o tempo máximo são retornadas com o erro
+func (el *DockerSystem) ContainerExecOutput(
+	id string,
+	command []string,
+	timeout time.Duration,
+) (
+	exitCode int,
+	stdOutput []byte,
+	stdError []byte,
+	err error,
+) {
+
+	var idResponse types.IDResponse
+	idResponse, err = el.cli.ContainerExecCreate(el.ctx, id, types.ExecConfig{
+		Cmd:          command,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return
+	}
+
+	var resp types.HijackedResponse
+	resp, err = el.cli.ContainerExecAttach(el.ctx, idResponse.ID, types.ExecStartCheck{})
+	if err != nil {
+		return
+	}
+	defer resp.Close()
+
+	if timeout > 0 {
+		_ = resp.Conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	var stdout, stderr bytes.Buffer
+	_, err = stdcopy.StdCopy(&stdout, &stderr, resp.Reader)
+	stdOutput, stdError = stdout.Bytes(), stderr.Bytes()
+	if err != nil {
+		return
+	}
+
+	// the exit code is known after the end of the outputs
+	var inspect types.ContainerExecInspect
+	if inspect, err = el.cli.ContainerExecInspect(el.ctx, idResponse.ID); err != nil {
+		return
+	}
+
+	exitCode = inspect.ExitCode
+	return
+}
@@ -0,0 +1,20 @@
+package builder
+
+// ContainerKill (English): Sends a signal to the main process of the container
+//
+//	id: string container id
+//	signal: name of the signal, e.g. SIGQUIT
+//
+// ContainerKill (Português): Envia um sinal para o processo principal do container
+//
+//	id: string id do container
+//	signal: nome do sinal, ex. SIGQUIT
+func (el *DockerSystem) ContainerKill(
+	id string,
+	signal string,
+) (
+	err error,
+) {
+
+	return el.cli.ContainerKill(el.ctx, id, signal)
+}
@@ -0,0 +1,41 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types"
+	"io"
+	"time"
+)
+
+// ContainerLogsFollow (English): Returns the std out of the container with timestamps, followed until the container
+// stops
+//
+//	id: string container id
+//	since: time of the first line, or zero for all lines
+//
+// ContainerLogsFollow (Português): Retorna a saída padrão do container com as marcações de tempo, acompanhada até o
+// container parar
+//
+//	id: string container id
+//	since: tempo da primeira linha, ou zero para todas as linhas
+func (el *DockerSystem) ContainerLogsFollow(
+	id string,
+	since time.Time,
+) (
+	reader io.ReadCloser,
+	err error,
+) {
+
+	var sinceText string
+	if !since.IsZero() {
+		sinceText = since.Format(time.RFC3339Nano)
+	}
+
+	return el.cli.ContainerLogs(el.ctx, id, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      sinceText,
+		Timestamps: true,
+		Follow:     true,
+		Details:    false,
+	})
+}
@@ -0,0 +1,41 @@
+package builder
+
+import (
+	"encoding/json"
+	"github.com/docker/docker/api/types"
+	"io/ioutil"
+)
+
+// ContainerStatisticsOneShotJSON (English): Returns the performance information of the
+// container in a timely manner, with the network interfaces
+//
+//	id: string container id
+//
+// ContainerStatisticsOneShotJSON (Português): Retorna as informações de desempenho do
+// container de forma pontual, com as interfaces de rede
+//
+//	id: string container id
+func (el *DockerSystem) ContainerStatisticsOneShotJSON(
+	id string,
+) (
+	statsRet types.StatsJSON,
+	err error,
+) {
+
+	var stats types.ContainerStats
+	var body []byte
+
+	stats, err = el.cli.ContainerStats(el.ctx, id, false)
+	if err != nil {
+		return
+	}
+	defer stats.Body.Close()
+
+	body, err = ioutil.ReadAll(stats.Body)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &statsRet)
+	return
+}
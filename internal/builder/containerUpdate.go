@@ -0,0 +1,36 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types/container"
+)
+
+// ContainerUpdate (English): Changes the resources of a running container, e.g. cpu quota, memory limit and block IO
+// weight
+//
+//	id: container ID
+//	resources: new resources. Zero values are not changed by docker
+//
+//	Note: docker only updates cpu, memory, pids and block IO weight. Block IO device limits are defined only on
+//	container creation
+//
+// ContainerUpdate (Português): Altera os recursos de um container em execução, ex. cota de cpu, limite de memória e
+// peso do block IO
+//
+//	id: ID do container
+//	resources: novos recursos. Valores zero não são alterados pelo docker
+//
+//	Nota: o docker só atualiza cpu, memória, pids e peso do block IO. Os limites de block IO por dispositivo são
+//	definidos apenas na criação do container
+func (el *DockerSystem) ContainerUpdate(
+	id string,
+	resources container.Resources,
+) (
+	warnings []string,
+	err error,
+) {
+
+	var resp container.ContainerUpdateOKBody
+	resp, err = el.cli.ContainerUpdate(el.ctx, id, container.UpdateConfig{Resources: resources})
+	warnings = resp.Warnings
+	return
+}
@@ -126,6 +126,8 @@ func (el *DockerSystem) ImageBuildFromFolder(
 		return
 	}
 
+	imageBuildOptions.Labels = el.labelsMerge(imageBuildOptions.Labels)
+
 	if len(imageBuildOptions.Tags) == 0 {
 		imageBuildOptions.Tags = tags
 	} else {
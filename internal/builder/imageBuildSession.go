@@ -0,0 +1,68 @@
+package builder
+
+import (
+	"context"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"github.com/moby/buildkit/session/sshforward/sshprovider"
+	"net"
+)
+
+// ImageBuildSession (English): Starts a BuildKit session to provide secrets and ssh agent forwarding to the image build
+//
+//	secrets: map of secret id and content, used by the Dockerfile in `RUN --mount=type=secret,id=<id>`
+//	sshAgentSocket: path of the ssh agent socket, e.g. $SSH_AUTH_SOCK, used by the Dockerfile in
+//	  `RUN --mount=type=ssh`. Empty string disables the ssh agent forwarding
+//
+//	  Note: set the returned sessionId in ImageBuildOptions.SessionID, together with
+//	  ImageBuildOptions.Version = types.BuilderBuildKit, and call closeSession() at the end of the build.
+//	  The secrets are never written in the build context, build args or image layers
+//
+// ImageBuildSession (Português): Inicia uma sessão BuildKit para fornecer segredos e o encaminhamento do agente ssh
+// para a construção da imagem
+//
+//	secrets: mapa de id e conteúdo do segredo, usado pelo Dockerfile em `RUN --mount=type=secret,id=<id>`
+//	sshAgentSocket: caminho do socket do agente ssh, ex. $SSH_AUTH_SOCK, usado pelo Dockerfile em
+//	  `RUN --mount=type=ssh`. String vazia desabilita o encaminhamento do agente ssh
+//
+//	  Nota: defina o sessionId retornado em ImageBuildOptions.SessionID, junto com
+//	  ImageBuildOptions.Version = types.BuilderBuildKit, e chame closeSession() ao final da construção.
+//	  Os segredos nunca são escritos no contexto de construção, build args ou camadas da imagem
+func (el *DockerSystem) ImageBuildSession(
+	secrets map[string][]byte,
+	sshAgentSocket string,
+) (
+	sessionId string,
+	closeSession func() error,
+	err error,
+) {
+
+	var buildSession *session.Session
+	buildSession, err = session.NewSession(el.ctx, "chaos", "")
+	if err != nil {
+		return
+	}
+
+	buildSession.Allow(secretsprovider.FromMap(secrets))
+
+	if sshAgentSocket != "" {
+		var sshAgent session.Attachable
+		sshAgent, err = sshprovider.NewSSHAgentProvider([]sshprovider.AgentConfig{{ID: "default", Paths: []string{sshAgentSocket}}})
+		if err != nil {
+			_ = buildSession.Close()
+			return
+		}
+
+		buildSession.Allow(sshAgent)
+	}
+
+	go func() {
+		_ = buildSession.Run(el.ctx, func(ctx context.Context, proto string, meta map[string][]string) (net.Conn, error) {
+			return el.cli.DialHijack(ctx, "/session", proto, meta)
+		})
+	}()
+
+	sessionId = buildSession.ID()
+	closeSession = buildSession.Close
+	return
+}
@@ -0,0 +1,16 @@
+package builder
+
+import "github.com/docker/docker/api/types/image"
+
+// ImageHistory (English): Returns the history of the image layers, the same content of `docker history`
+//
+// ImageHistory (Português): Retorna o histórico das camadas da imagem, o mesmo conteúdo de `docker history`
+func (el *DockerSystem) ImageHistory(id string) (history []image.HistoryResponseItem, err error) {
+
+	history, err = el.cli.ImageHistory(el.ctx, id)
+	if err != nil {
+		return
+	}
+
+	return
+}
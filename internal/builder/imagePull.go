@@ -15,12 +15,40 @@ func (el *DockerSystem) ImagePull(
 	err error,
 ) {
 
+	return el.ImagePullWithPlatform(name, "", channel)
+}
+
+// ImagePullWithPlatform (English): Downloads the image for a specific platform
+//
+//	name: image name. E.g.: mongo:6.0.6
+//	platform: platform in the format os[/arch[/variant]]. E.g.: linux/arm64. Use "" for the host platform
+//	channel: channel of pull data
+//
+//	Note: the image tag points to the last platform downloaded, use ImageTag() to keep more than one platform
+//
+// ImagePullWithPlatform (Português): Baixa a imagem para uma plataforma específica
+//
+//	name: nome da imagem. Ex.: mongo:6.0.6
+//	platform: plataforma no formato os[/arch[/variant]]. Ex.: linux/arm64. Use "" para a plataforma do hospedeiro
+//	channel: canal com os dados do download
+//
+//	Nota: a tag da imagem aponta para a última plataforma baixada, use ImageTag() para manter mais de uma plataforma
+func (el *DockerSystem) ImagePullWithPlatform(
+	name string,
+	platform string,
+	channel chan ContainerPullStatusSendToChannel,
+) (
+	imageId string,
+	imageName string,
+	err error,
+) {
+
 	var reader io.Reader
 
 	//esse valor é trocado no final do download
 	imageName = name
 
-	reader, err = el.cli.ImagePull(el.ctx, name, types.ImagePullOptions{})
+	reader, err = el.cli.ImagePull(el.ctx, name, types.ImagePullOptions{Platform: platform})
 	if err != nil {
 		return
 	}
@@ -0,0 +1,46 @@
+package builder
+
+import (
+	"io"
+	"os"
+)
+
+// ImageSave (English): Exports the image to a tar file, the same format of `docker save`
+//
+//	name: image name or id. E.g.: delete_app:latest
+//	filePath: path of the tar file to be created
+//
+// ImageSave (Português): Exporta a imagem para um arquivo tar, o mesmo formato de `docker save`
+//
+//	name: nome ou id da imagem. Ex.: delete_app:latest
+//	filePath: caminho do arquivo tar a ser criado
+func (el *DockerSystem) ImageSave(
+	name string,
+	filePath string,
+) (
+	err error,
+) {
+
+	var reader io.ReadCloser
+	reader, err = el.cli.ImageSave(el.ctx, []string{name})
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	var file *os.File
+	file, err = os.Create(filePath)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	_, err = io.Copy(file, reader)
+	return
+}
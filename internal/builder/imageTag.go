@@ -0,0 +1,20 @@
+package builder
+
+// ImageTag (English): Creates a new tag that refers to an existing image
+//
+//	source: name or id of the existing image. E.g.: mongo:6.0.6
+//	target: new tag. E.g.: mongo:6.0.6-linux-arm64
+//
+// ImageTag (Português): Cria uma nova tag que aponta para uma imagem existente
+//
+//	source: nome ou id da imagem existente. Ex.: mongo:6.0.6
+//	target: nova tag. Ex.: mongo:6.0.6-linux-arm64
+func (el *DockerSystem) ImageTag(
+	source string,
+	target string,
+) (
+	err error,
+) {
+
+	return el.cli.ImageTag(el.ctx, source, target)
+}
@@ -0,0 +1,157 @@
+package builder
+
+import (
+	"errors"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+)
+
+// NetworkCreateDualStack (English): Create a dual-stack network, with IPv4 and IPv6
+//
+//	name:     string       Ex.: containerNetwork
+//	drive:    NetworkDrive Ex.: KNetworkDriveBridge
+//	scope:    string       Ex.: local
+//	subnet:   string       Ex.: 10.0.0.0/16
+//	gateway:  string       Ex.: 10.0.0.1
+//	subnet6:  string       Ex.: fd00:c4a0::/64 (note: use "" for an IPv4 only network)
+//	gateway6: string       Ex.: fd00:c4a0::1
+//
+// NetworkCreateDualStack (Português): Cria uma rede dual-stack, com IPv4 e IPv6
+//
+//	name:     string       Ex.: containerNetwork
+//	drive:    NetworkDrive Ex.: KNetworkDriveBridge
+//	scope:    string       Ex.: local
+//	subnet:   string       Ex.: 10.0.0.0/16
+//	gateway:  string       Ex.: 10.0.0.1
+//	subnet6:  string       Ex.: fd00:c4a0::/64 (nota: use "" para uma rede apenas IPv4)
+//	gateway6: string       Ex.: fd00:c4a0::1
+func (el *DockerSystem) NetworkCreateDualStack(
+	name string,
+	drive NetworkDrive,
+	scope,
+	subnet,
+	gateway,
+	subnet6,
+	gateway6 string,
+) (
+	id string,
+	networkGenerator *NextNetworkAutoConfiguration,
+	err error,
+) {
+
+	//todo: se já tem uma rede, ajustar o ip automático para o próximo endereço
+	var resp types.NetworkCreateResponse
+	var insp types.NetworkResource
+
+	networkGenerator = &NextNetworkAutoConfiguration{}
+
+	if len(el.networkId) == 0 {
+		el.networkId = make(map[string]string)
+	}
+
+	if len(el.networkGenerator) == 0 {
+		el.networkGenerator = make(map[string]*NextNetworkAutoConfiguration)
+	}
+
+	id, _ = el.NetworkFindIdByName(name)
+	if id != "" {
+
+		insp, err = el.cli.NetworkInspect(
+			el.ctx,
+			id,
+			types.NetworkInspectOptions{
+				Scope:   scope,
+				Verbose: false,
+			},
+		)
+		if err != nil {
+			return
+		}
+		pass := NetworkIpamMatch(insp.IPAM.Config, subnet, gateway)
+		if subnet6 != "" && !NetworkIpamMatch(insp.IPAM.Config, subnet6, gateway6) {
+			pass = false
+		}
+
+		if pass == true {
+
+			var res types.NetworkResource
+			res, err = el.cli.NetworkInspect(el.ctx, name, types.NetworkInspectOptions{
+				Scope:   scope,
+				Verbose: false,
+			})
+
+			if err != nil {
+				return
+			}
+
+			// the addresses of the live endpoints are skipped by Lease()
+			networkGenerator.InitDualStack(
+				res.ID,
+				name,
+				gateway,
+				subnet,
+				gateway6,
+				subnet6,
+			)
+			networkGenerator = el.networkGeneratorShare(networkGenerator)
+
+			el.networkId[name] = res.ID
+			id = res.ID
+
+			el.networkGenerator[name] = networkGenerator
+
+			return
+		}
+
+		err = errors.New("there is a network with this name")
+		return
+	}
+
+	var ipamConfig = []network.IPAMConfig{
+		{
+			Subnet:  subnet,
+			Gateway: gateway,
+		},
+	}
+	if subnet6 != "" {
+		ipamConfig = append(ipamConfig, network.IPAMConfig{
+			Subnet:  subnet6,
+			Gateway: gateway6,
+		})
+	}
+
+	resp, err = el.cli.NetworkCreate(el.ctx, name, types.NetworkCreate{
+		//CheckDuplicate: false,
+		Driver:     drive.String(),
+		Scope:      scope,
+		EnableIPv6: subnet6 != "",
+		IPAM: &network.IPAM{
+			Driver: "default",
+			Config: ipamConfig,
+		},
+		Attachable: true,
+		Labels: el.labelsMerge(map[string]string{
+			"name": name,
+		}),
+	})
+	if err != nil {
+		return
+	}
+
+	networkGenerator.InitDualStack(
+		resp.ID,
+		name,
+		gateway,
+		subnet,
+		gateway6,
+		subnet6,
+	)
+	networkGenerator = el.networkGeneratorShare(networkGenerator)
+
+	el.networkId[name] = resp.ID
+	id = resp.ID
+
+	el.networkGenerator[name] = networkGenerator
+
+	return
+}
@@ -0,0 +1,49 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types"
+	"sync"
+)
+
+// networkGeneratorShared keeps one address generator per network, shared by all DockerSystem objects, where key is the
+// network ID
+var networkGeneratorShared = struct {
+	sync.Mutex
+	list map[string]*NextNetworkAutoConfiguration
+}{
+	list: make(map[string]*NextNetworkAutoConfiguration),
+}
+
+// networkGeneratorShare (English): Returns the generator of the network already in use, with the same configuration,
+// or registers the new generator, so two managers don't hand out the same address
+//
+//	generator: new generator of the network
+//
+// networkGeneratorShare (Português): Retorna o gerador da rede já em uso, com a mesma configuração, ou registra o
+// novo gerador, assim, dois gerenciadores não entregam o mesmo endereço
+//
+//	generator: novo gerador da rede
+func (el *DockerSystem) networkGeneratorShare(
+	generator *NextNetworkAutoConfiguration,
+) (
+	shared *NextNetworkAutoConfiguration,
+) {
+
+	networkGeneratorShared.Lock()
+	defer networkGeneratorShared.Unlock()
+
+	shared = networkGeneratorShared.list[generator.id]
+	if shared != nil &&
+		shared.gateway == generator.gateway && shared.subnet == generator.subnet &&
+		shared.gateway6 == generator.gateway6 && shared.subnet6 == generator.subnet6 {
+		return
+	}
+
+	var id = generator.id
+	generator.inspect = func() (types.NetworkResource, error) {
+		return el.cli.NetworkInspect(el.ctx, id, types.NetworkInspectOptions{})
+	}
+
+	networkGeneratorShared.list[id] = generator
+	return generator
+}
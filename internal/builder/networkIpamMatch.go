@@ -0,0 +1,33 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types/network"
+)
+
+// NetworkIpamMatch (English): Returns true when the IPAM configuration of the network has the subnet and the gateway
+//
+//	config: IPAM configuration of the network. Ex.: NetworkResource.IPAM.Config
+//	subnet: subnet. Ex.: 10.0.0.0/16
+//	gateway: gateway. Ex.: 10.0.0.1
+//
+// NetworkIpamMatch (Português): Retorna true quando a configuração IPAM da rede tem a subnet e o gateway
+//
+//	config: configuração IPAM da rede. Ex.: NetworkResource.IPAM.Config
+//	subnet: subnet. Ex.: 10.0.0.0/16
+//	gateway: gateway. Ex.: 10.0.0.1
+func NetworkIpamMatch(
+	config []network.IPAMConfig,
+	subnet,
+	gateway string,
+) (
+	match bool,
+) {
+
+	for _, v := range config {
+		if v.Subnet == subnet && v.Gateway == gateway {
+			return true
+		}
+	}
+
+	return false
+}
@@ -122,6 +122,13 @@ func (el *DockerSystem) processBuildAndPullReaders(
 				channelOut.SysStatus = KContainerPullStatusBuilding
 			}
 
+			// BuildKit does not print "Successfully tagged", the last message is {"id":"moby.image.id","aux":{"ID":"sha256:..."}}
+			if channelOut.ID == kContainerBuildKitStatusImageId {
+				channelOut.SysStatus = KContainerPullStatusComplete
+				channelOut.SuccessfullyBuildImage = true
+				successfully = true
+			}
+
 			//Successfully tagged delete_remote_server:latest
 			if strings.Contains(channelOut.Status, kContainerPullStatusDownloadedNewerImageText) {
 				imageName = strings.Replace(channelOut.Status, kContainerPullStatusDownloadedNewerImageText, "", 1)
@@ -4,11 +4,12 @@ import (
 	"github.com/docker/docker/api/types"
 	"log"
 	"runtime"
+	"strings"
 	"sync"
 )
 
 // RemoveAllByNameContains remove trash after test.
-// This function removes container, image and network by name, and unlinked volumes and
+// This function removes container, image, network and volume by name, and unlinked volumes and
 // imagens
 func (el DockerSystem) RemoveAllByNameContains(name string) (err error) {
 	var nameAndId []NameAndId
@@ -100,6 +101,17 @@ func (el DockerSystem) RemoveAllByNameContains(name string) (err error) {
 		}
 	}
 
+	var volumes []types.Volume
+	volumes, err = el.VolumeList()
+	if err != nil {
+		return
+	}
+	for _, data := range volumes {
+		if strings.Contains(data.Name, name) {
+			_ = el.VolumeRemove(data.Name)
+		}
+	}
+
 	err = el.VolumesUnreferencedRemove()
 	if err != nil {
 		return
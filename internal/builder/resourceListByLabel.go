@@ -0,0 +1,76 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	volumeTypes "github.com/docker/docker/api/types/volume"
+	"strings"
+)
+
+// ResourceListByLabel (English): Returns the containers, networks, volumes and images with all the labels, in the
+// order of removal
+//
+//	labels: labels in the format key=value, or key for any value
+//
+// ResourceListByLabel (Português): Retorna os containers, redes, volumes e imagens com todos os rótulos, na ordem de
+// remoção
+//
+//	labels: rótulos no formato chave=valor, ou chave para qualquer valor
+func (el *DockerSystem) ResourceListByLabel(labels ...string) (resources []Resource, err error) {
+	var filter = filters.NewArgs()
+	for _, label := range labels {
+		filter.Add("label", label)
+	}
+
+	resources = make([]Resource, 0)
+
+	var containers []types.Container
+	containers, err = el.cli.ContainerList(el.ctx, types.ContainerListOptions{All: true, Filters: filter})
+	if err != nil {
+		return
+	}
+
+	for _, container := range containers {
+		var name = container.ID
+		if len(container.Names) != 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		resources = append(resources, Resource{Kind: KResourceContainer, ID: container.ID, Name: name, Labels: container.Labels})
+	}
+
+	var networks []types.NetworkResource
+	networks, err = el.cli.NetworkList(el.ctx, types.NetworkListOptions{Filters: filter})
+	if err != nil {
+		return
+	}
+
+	for _, network := range networks {
+		resources = append(resources, Resource{Kind: KResourceNetwork, ID: network.ID, Name: network.Name, Labels: network.Labels})
+	}
+
+	var volumes volumeTypes.VolumeListOKBody
+	volumes, err = el.cli.VolumeList(el.ctx, filter)
+	if err != nil {
+		return
+	}
+
+	for _, volume := range volumes.Volumes {
+		resources = append(resources, Resource{Kind: KResourceVolume, ID: volume.Name, Name: volume.Name, Labels: volume.Labels})
+	}
+
+	var images []types.ImageSummary
+	images, err = el.cli.ImageList(el.ctx, types.ImageListOptions{Filters: filter})
+	if err != nil {
+		return
+	}
+
+	for _, image := range images {
+		var name = image.ID
+		if len(image.RepoTags) != 0 && image.RepoTags[0] != "<none>:<none>" {
+			name = image.RepoTags[0]
+		}
+		resources = append(resources, Resource{Kind: KResourceImage, ID: image.ID, Name: name, Labels: image.Labels})
+	}
+
+	return
+}
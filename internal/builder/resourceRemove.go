@@ -0,0 +1,21 @@
+package builder
+
+// ResourceRemove (English): Removes the docker element found by ResourceListByLabel(). Running containers are
+// stopped and their anonymous volumes are removed
+//
+// ResourceRemove (Português): Remove o elemento docker encontrado por ResourceListByLabel(). Containers em execução
+// são parados e os seus volumes anônimos são removidos
+func (el *DockerSystem) ResourceRemove(resource Resource) (err error) {
+	switch resource.Kind {
+	case KResourceContainer:
+		return el.ContainerRemove(resource.ID, true, false, true)
+	case KResourceNetwork:
+		return el.NetworkRemove(resource.ID)
+	case KResourceVolume:
+		return el.VolumeRemove(resource.ID)
+	case KResourceImage:
+		return el.ImageRemove(resource.ID, true, false)
+	}
+
+	return
+}
@@ -0,0 +1,20 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types/container"
+)
+
+// SetHostConfig (English): Defines the host configuration used by ContainerCreateWithConfig()
+//
+//	hostConfig: host configuration, e.g. Resources (memory, cpu, pids, blkio, ulimits) and Tmpfs
+//
+//	Note: PortBindings, RestartPolicy and Mounts are replaced by the arguments of ContainerCreateWithConfig()
+//
+// SetHostConfig (Português): Define a configuração do hospedeiro usada por ContainerCreateWithConfig()
+//
+//	hostConfig: configuração do hospedeiro, ex. Resources (memória, cpu, pids, blkio, ulimits) e Tmpfs
+//
+//	Nota: PortBindings, RestartPolicy e Mounts são substituídos pelos argumentos de ContainerCreateWithConfig()
+func (el *DockerSystem) SetHostConfig(hostConfig container.HostConfig) {
+	el.hostConfig = hostConfig
+}
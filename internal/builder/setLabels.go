@@ -0,0 +1,33 @@
+package builder
+
+// SetLabels (English): Defines the labels added to every container, network, volume and image created by this object
+//
+//	labels: labels of ownership, e.g. the session of the test. The labels of the arguments of each function are kept
+//
+// SetLabels (Português): Define os rótulos adicionados a todos os containers, redes, volumes e imagens criados por este
+// objeto
+//
+//	labels: rótulos de propriedade, ex. a sessão do teste. Os rótulos dos argumentos de cada função são mantidos
+func (el *DockerSystem) SetLabels(labels map[string]string) {
+	el.labels = labels
+}
+
+// labelsMerge
+//
+// Returns a new map with the labels of the argument and the labels defined by SetLabels()
+func (el *DockerSystem) labelsMerge(labels map[string]string) (merged map[string]string) {
+	if len(labels) == 0 && len(el.labels) == 0 {
+		return labels
+	}
+
+	merged = make(map[string]string, len(labels)+len(el.labels))
+	for key, value := range labels {
+		merged[key] = value
+	}
+
+	for key, value := range el.labels {
+		merged[key] = value
+	}
+
+	return
+}
@@ -0,0 +1,22 @@
+package builder
+
+import "testing"
+
+func TestDockerSystem_labelsMerge(t *testing.T) {
+	var dockerSys = DockerSystem{}
+	if merged := dockerSys.labelsMerge(nil); merged != nil {
+		t.Errorf("without labels, the map must be nil: %v", merged)
+	}
+
+	dockerSys.SetLabels(map[string]string{"chaos.session": "a"})
+
+	var labels = map[string]string{"name": "delete_db"}
+	var merged = dockerSys.labelsMerge(labels)
+	if len(merged) != 2 || merged["name"] != "delete_db" || merged["chaos.session"] != "a" {
+		t.Errorf("unexpected labels: %v", merged)
+	}
+
+	if len(labels) != 1 {
+		t.Errorf("the labels of the argument must not change: %v", labels)
+	}
+}
@@ -0,0 +1,35 @@
+package builder
+
+import (
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"strings"
+)
+
+// SetPlatform (English): Defines the image platform used by ContainerCreateWithConfig()
+//
+//	platform: platform in the format os[/arch[/variant]]. E.g.: linux/arm64/v8. Use "" for the host platform
+//
+//	Note: running an image from another architecture requires QEMU emulation (binfmt_misc) installed in docker
+//
+// SetPlatform (Português): Define a plataforma da imagem usada por ContainerCreateWithConfig()
+//
+//	platform: plataforma no formato os[/arch[/variant]]. Ex.: linux/arm64/v8. Use "" para a plataforma do hospedeiro
+//
+//	Nota: rodar uma imagem de outra arquitetura requer a emulação QEMU (binfmt_misc) instalada no docker
+func (el *DockerSystem) SetPlatform(platform string) {
+	if platform == "" {
+		el.platform = nil
+		return
+	}
+
+	var part = strings.Split(platform, "/")
+	el.platform = &specs.Platform{OS: part[0]}
+
+	if len(part) > 1 {
+		el.platform.Architecture = part[1]
+	}
+
+	if len(part) > 2 {
+		el.platform.Variant = part[2]
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"time"
 )
 
@@ -21,4 +22,7 @@ type DockerSystem struct {
 	networkGenerator map[string]*NextNetworkAutoConfiguration
 	healthcheck      *container.HealthConfig
 	Config           *container.Config
+	platform         *specs.Platform
+	hostConfig       container.HostConfig
+	labels           map[string]string
 }
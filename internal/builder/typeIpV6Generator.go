@@ -0,0 +1,120 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// IPv6Generator
+//
+// English:
+//
+//	Generates the IPv6 addresses of a subnet, in sequence, from the address after the gateway, with the same rules of
+//	IPv4Generator
+//
+// Português:
+//
+//	Gera os endereços IPv6 de uma subnet, em sequência, a partir do endereço depois do gateway, com as mesmas regras
+//	de IPv4Generator
+type IPv6Generator struct {
+	ip       netip.Addr
+	gateway  netip.Addr
+	subnet   netip.Prefix
+	reserved []netip.Addr
+}
+
+func (el *IPv6Generator) GetCurrentIPAsString() (ip string) {
+	return el.String()
+}
+
+func (el IPv6Generator) verify(
+	ip netip.Addr,
+) (
+	err error,
+) {
+
+	if !el.gateway.IsValid() {
+		err = errors.New("initialize IPv6Generator{} first")
+		return
+	}
+
+	for _, ipReserved := range el.reserved {
+		if ipReserved == ip {
+			err = errors.New("ip reserved")
+			return
+		}
+	}
+
+	if ip.Less(el.gateway.Next()) {
+		err = fmt.Errorf("min allowed ip is %v", el.gateway.Next())
+		return
+	}
+
+	if !el.subnet.Contains(ip) {
+		err = fmt.Errorf("the ip address %v is out of the subnet %v", ip, el.subnet)
+		return
+	}
+
+	return
+}
+
+func (el *IPv6Generator) IncCurrentIP() (
+	err error,
+) {
+
+	var ip = el.ip.Next()
+	if !ip.IsValid() {
+		err = errors.New("the ip address is greater than the theoretical maximum allowed")
+		return
+	}
+
+	err = el.verify(ip)
+	if err != nil {
+		return
+	}
+
+	el.ip = ip
+	return
+}
+
+func (el *IPv6Generator) InitWithString(
+	gateway string,
+	subnet string,
+) (
+	err error,
+) {
+	el.reserved = make([]netip.Addr, 0)
+
+	if el.gateway, err = netip.ParseAddr(gateway); err != nil || !el.gateway.Is6() {
+		err = errors.New("IP format must be 'hex:hex::hex'")
+		return
+	}
+
+	if el.subnet, err = netip.ParsePrefix(subnet); err != nil || !el.subnet.Addr().Is6() {
+		err = errors.New("IP format must be 'hex:hex::hex/int'")
+		return
+	}
+	el.subnet = el.subnet.Masked()
+
+	if !el.subnet.Contains(el.gateway) {
+		err = fmt.Errorf("the gateway %v is out of the subnet %v", el.gateway, el.subnet)
+		return
+	}
+
+	el.ip = el.gateway.Next()
+	if !el.ip.IsValid() || !el.subnet.Contains(el.ip) {
+		err = errors.New("the ip address is greater than the maximum theoretical ip allowed")
+		return
+	}
+
+	return
+}
+
+func (el IPv6Generator) String() (currentIP string) {
+	if !el.ip.IsValid() {
+		return ""
+	}
+
+	return el.ip.String()
+}
@@ -0,0 +1,64 @@
+package builder
+
+import (
+	"testing"
+)
+
+func TestIPv6Generator_IncCurrentIP(t *testing.T) {
+	var generator IPv6Generator
+	if err := generator.IncCurrentIP(); err == nil {
+		t.Fatalf("a generator without initialization must fail")
+	}
+
+	if err := generator.InitWithString("fd00:c4a0::1", "fd00:c4a0::/126"); err != nil {
+		t.Fatalf("InitWithString().error: %v", err)
+	}
+
+	for _, expected := range []string{"fd00:c4a0::2", "fd00:c4a0::3"} {
+		if generator.String() != expected {
+			t.Errorf("expected %v, got %v", expected, generator.String())
+		}
+
+		if err := generator.IncCurrentIP(); err != nil && expected != "fd00:c4a0::3" {
+			t.Fatalf("IncCurrentIP().error: %v", err)
+		}
+	}
+
+	// fd00:c4a0::4 is out of the subnet /126
+	if err := generator.IncCurrentIP(); err == nil {
+		t.Errorf("an address out of the subnet must fail")
+	}
+
+	if err := generator.InitWithString("10.0.0.1", "10.0.0.0/16"); err == nil {
+		t.Errorf("an IPv4 gateway must fail")
+	}
+
+	if err := generator.InitWithString("fd00:c4a1::1", "fd00:c4a0::/64"); err == nil {
+		t.Errorf("a gateway out of the subnet must fail")
+	}
+}
+
+func TestNextNetworkAutoConfiguration_GetNextDualStack(t *testing.T) {
+	var generator NextNetworkAutoConfiguration
+	generator.InitDualStack("id", "delete_network", "10.0.0.1", "10.0.0.0/16", "fd00:c4a0::1", "fd00:c4a0::/64")
+
+	ip, ipV6, config, err := generator.GetNextDualStack()
+	if err != nil {
+		t.Fatalf("GetNextDualStack().error: %v", err)
+	}
+
+	if ip != "10.0.0.2" || ipV6 != "fd00:c4a0::2" {
+		t.Errorf("unexpected addresses: %v, %v", ip, ipV6)
+	}
+
+	var endpoint = config.EndpointsConfig["delete_network"]
+	if endpoint.IPAMConfig.IPv4Address != ip || endpoint.IPAMConfig.IPv6Address != ipV6 || endpoint.IPv6Gateway != "fd00:c4a0::1" {
+		t.Errorf("unexpected endpoint: %+v", endpoint)
+	}
+
+	generator = NextNetworkAutoConfiguration{}
+	generator.Init("id", "delete_network", "10.0.0.1", "10.0.0.0/16")
+	if _, ipV6, _, _ = generator.GetNextDualStack(); ipV6 != "" {
+		t.Errorf("an IPv4 only network must not have IPv6 address: %v", ipV6)
+	}
+}
@@ -1,16 +1,41 @@
 package builder
 
 import (
+	"fmt"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/network"
+	"net/netip"
+	"strings"
+	"sync"
 )
 
 type NextNetworkAutoConfiguration struct {
-	ip      IPv4Generator
-	id      string
-	name    string
-	gateway string
-	subnet  string
-	err     error
+	ip       IPv4Generator
+	ip6      IPv6Generator
+	id       string
+	name     string
+	gateway  string
+	subnet   string
+	gateway6 string
+	subnet6  string
+	err      error
+
+	// Protects the generators and the leases, the network is shared by all managers
+	mutex sync.Mutex
+
+	// Addresses leased by Lease(), where key is the container name
+	leases map[string]networkLease
+
+	// Returns the live endpoints of the network. Nil when the network was not created by NetworkCreate()
+	inspect func() (types.NetworkResource, error)
+}
+
+// networkLease
+//
+// Addresses leased to one container
+type networkLease struct {
+	ipV4 string
+	ipV6 string
 }
 
 // init a network for new container
@@ -24,25 +49,349 @@ func (el *NextNetworkAutoConfiguration) Init(id, name, gateway, subnet string) {
 	el.err = el.ip.InitWithString(gateway, subnet)
 }
 
+// InitDualStack
+//
+// English:
+//
+//	Init a dual-stack network for new container, with IPv4 and IPv6 addresses
+//
+// Português:
+//
+//	Inicializa uma rede dual-stack para um novo container, com endereços IPv4 e IPv6
+func (el *NextNetworkAutoConfiguration) InitDualStack(id, name, gateway, subnet, gateway6, subnet6 string) {
+	el.Init(id, name, gateway, subnet)
+	if el.err != nil || subnet6 == "" {
+		return
+	}
+
+	el.gateway6 = gateway6
+	el.subnet6 = subnet6
+	el.err = el.ip6.InitWithString(gateway6, subnet6)
+}
+
 func (el *NextNetworkAutoConfiguration) GetNext() (IP string, networkConfig *network.NetworkingConfig, err error) {
+	IP, _, networkConfig, err = el.GetNextDualStack()
+	return
+}
+
+// GetNextDualStack
+//
+// English:
+//
+//	Returns the next IPv4 and IPv6 addresses and the network configuration of the container. The IPv6 address is
+//	empty when the network is not dual-stack
+//
+// Português:
+//
+//	Retorna os próximos endereços IPv4 e IPv6 e a configuração de rede do container. O endereço IPv6 é vazio quando a
+//	rede não é dual-stack
+func (el *NextNetworkAutoConfiguration) GetNextDualStack() (IP, IPv6 string, networkConfig *network.NetworkingConfig, err error) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
 	IP = el.ip.String()
 	el.err = el.ip.IncCurrentIP()
-	return IP,
-		&network.NetworkingConfig{
-			EndpointsConfig: map[string]*network.EndpointSettings{
-				el.name: {
-					NetworkID: el.id,
-					Gateway:   el.gateway,
-					IPAMConfig: &network.EndpointIPAMConfig{
-						IPv4Address: IP,
-					},
-					IPAddress: IP,
+
+	if el.subnet6 != "" && el.err == nil {
+		IPv6 = el.ip6.String()
+		el.err = el.ip6.IncCurrentIP()
+	}
+
+	return IP, IPv6, el.endpointConfig(IP, IPv6), el.err
+}
+
+// Lease
+//
+// English:
+//
+//	Returns the addresses of the container and the network configuration. The addresses in use by the live endpoints
+//	of the network and by the other leases are skipped, and the container keeps its addresses until Release()
+//
+//	 Input:
+//	   containerName: name of the container, the key of the lease
+//	   ipV4: static IPv4 address, or "" for the next free address
+//	   ipV6: static IPv6 address, or "" for the next free address. Ignored when the network is not dual-stack
+//
+//	 Notes:
+//	   * Safe for concurrent use, the same network returns the same object to all managers;
+//	   * When the generator reaches the end of the subnet, it starts again from the address after the gateway, so the
+//	     released addresses are reused.
+//
+// Português:
+//
+//	Retorna os endereços do container e a configuração de rede. Os endereços em uso pelos endpoints vivos da rede e
+//	pelas demais reservas são pulados, e o container mantém os seus endereços até Release()
+//
+//	 Entrada:
+//	   containerName: nome do container, a chave da reserva
+//	   ipV4: endereço IPv4 estático, ou "" para o próximo endereço livre
+//	   ipV6: endereço IPv6 estático, ou "" para o próximo endereço livre. Ignorado quando a rede não é dual-stack
+//
+//	 Notas:
+//	   * Seguro para uso concorrente, a mesma rede retorna o mesmo objeto para todos os gerenciadores;
+//	   * Quando o gerador chega ao fim da subnet, ele começa de novo a partir do endereço depois do gateway, assim, os
+//	     endereços liberados são reutilizados.
+func (el *NextNetworkAutoConfiguration) Lease(containerName, ipV4, ipV6 string) (IP, IPv6 string, networkConfig *network.NetworkingConfig, err error) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	if el.err != nil {
+		err = el.err
+		return
+	}
+
+	var used map[string]string
+	if used, err = el.used(); err != nil {
+		return
+	}
+
+	// the live endpoint of the container, e.g. created by another manager, is the lease of the container
+	var lease, found = el.leases[containerName]
+	if !found {
+		for ip, name := range used {
+			if name != containerName {
+				continue
+			}
+
+			var address netip.Addr
+			if address, err = netip.ParseAddr(ip); err != nil {
+				err = fmt.Errorf("the network %v returned an invalid address for %v: %v", el.name, containerName, err)
+				return
+			}
+
+			if address.Is4() {
+				lease.ipV4 = ip
+			} else {
+				lease.ipV6 = ip
+			}
+		}
+	}
+
+	if ipV4 != "" {
+		if err = el.static(ipV4, el.subnet, containerName, used); err != nil {
+			return
+		}
+		lease.ipV4 = ipV4
+	} else if lease.ipV4 == "" {
+		if lease.ipV4, err = el.nextFree(false, used); err != nil {
+			return
+		}
+	}
+
+	if el.subnet6 != "" {
+		if ipV6 != "" {
+			if err = el.static(ipV6, el.subnet6, containerName, used); err != nil {
+				return
+			}
+			lease.ipV6 = ipV6
+		} else if lease.ipV6 == "" {
+			if lease.ipV6, err = el.nextFree(true, used); err != nil {
+				return
+			}
+		}
+	}
+
+	if el.leases == nil {
+		el.leases = make(map[string]networkLease)
+	}
+	el.leases[containerName] = lease
+
+	return lease.ipV4, lease.ipV6, el.endpointConfig(lease.ipV4, lease.ipV6), nil
+}
+
+// Release
+//
+// English:
+//
+//	Releases the addresses leased to the container, so they can be used by other containers
+//
+// Português:
+//
+//	Libera os endereços reservados para o container, assim, eles podem ser usados por outros containers
+func (el *NextNetworkAutoConfiguration) Release(containerName string) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	delete(el.leases, containerName)
+}
+
+// used
+//
+// Addresses in use by the live endpoints of the network and by the leases, where key is the address and value is the
+// container name
+func (el *NextNetworkAutoConfiguration) used() (used map[string]string, err error) {
+	used = make(map[string]string)
+
+	if el.inspect != nil {
+		var resource types.NetworkResource
+		if resource, err = el.inspect(); err != nil {
+			return
+		}
+
+		for _, endpoint := range resource.Containers {
+			for _, address := range []string{endpoint.IPv4Address, endpoint.IPv6Address} {
+				if address = strings.Split(address, "/")[0]; address != "" {
+					used[address] = endpoint.Name
+				}
+			}
+		}
+	}
+
+	for name, lease := range el.leases {
+		used[lease.ipV4] = name
+		if lease.ipV6 != "" {
+			used[lease.ipV6] = name
+		}
+	}
+
+	return
+}
+
+// static
+//
+// Verifies the static address is inside the subnet and is not the gateway nor used by another container
+func (el *NextNetworkAutoConfiguration) static(address, subnet, containerName string, used map[string]string) (err error) {
+	var ip netip.Addr
+	if ip, err = netip.ParseAddr(address); err != nil {
+		return
+	}
+
+	var prefix netip.Prefix
+	if prefix, err = netip.ParsePrefix(subnet); err != nil {
+		return
+	}
+
+	if !prefix.Contains(ip) {
+		return fmt.Errorf("the ip address %v is out of the subnet %v", address, subnet)
+	}
+
+	for _, gateway := range []string{el.gateway, el.gateway6} {
+		if address, errGateway := netip.ParseAddr(gateway); errGateway == nil && address == ip {
+			return fmt.Errorf("the ip address %v is the gateway of the network %v", address, el.name)
+		}
+	}
+
+	if name, found := used[ip.String()]; found && name != containerName {
+		return fmt.Errorf("the ip address %v is in use by %v", address, name)
+	}
+
+	return
+}
+
+// nextFree
+//
+// Returns the next address not used and moves the generator to the following address. The generator starts again
+// from the address after the gateway once, at the end of the subnet
+func (el *NextNetworkAutoConfiguration) nextFree(ipV6 bool, used map[string]string) (ip string, err error) {
+	var subnet = el.subnet
+	if ipV6 {
+		subnet = el.subnet6
+	}
+
+	var prefix netip.Prefix
+	if prefix, err = netip.ParsePrefix(subnet); err != nil {
+		return
+	}
+	prefix = prefix.Masked()
+
+	var restarted bool
+	for {
+		var errInc error
+		if ipV6 {
+			ip = el.ip6.String()
+			errInc = el.ip6.IncCurrentIP()
+		} else {
+			ip = el.ip.String()
+			errInc = el.ip.IncCurrentIP()
+		}
+
+		var address, errParse = netip.ParseAddr(ip)
+		var inside = errParse == nil && prefix.Contains(address) && address != prefixLast(prefix)
+		if _, found := used[ip]; inside && !found {
+			if errInc != nil {
+				// the last address of the generator, the next call starts again
+				_ = el.restart(ipV6)
+			}
+			return ip, nil
+		}
+
+		if inside && errInc == nil {
+			continue
+		}
+
+		if restarted {
+			return "", fmt.Errorf("there is no free ip address in the network %v", el.name)
+		}
+
+		restarted = true
+		if err = el.restart(ipV6); err != nil {
+			return
+		}
+	}
+}
+
+// prefixLast
+//
+// Last address of the subnet, the broadcast address of an IPv4 subnet
+func prefixLast(prefix netip.Prefix) (last netip.Addr) {
+	var address = prefix.Addr().AsSlice()
+	for bit := prefix.Bits(); bit < len(address)*8; bit += 1 {
+		address[bit/8] |= 1 << (7 - bit%8)
+	}
+
+	last, _ = netip.AddrFromSlice(address)
+	return
+}
+
+// restart
+//
+// Moves the generator to the address after the gateway
+func (el *NextNetworkAutoConfiguration) restart(ipV6 bool) (err error) {
+	if ipV6 {
+		return el.ip6.InitWithString(el.gateway6, el.subnet6)
+	}
+
+	return el.ip.InitWithString(el.gateway, el.subnet)
+}
+
+// endpointConfig
+//
+// Network configuration of the container with the addresses
+func (el *NextNetworkAutoConfiguration) endpointConfig(IP, IPv6 string) (networkConfig *network.NetworkingConfig) {
+	return &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			el.name: {
+				NetworkID: el.id,
+				Gateway:   el.gateway,
+				IPAMConfig: &network.EndpointIPAMConfig{
+					IPv4Address: IP,
+					IPv6Address: IPv6,
 				},
+				IPAddress:         IP,
+				IPv6Gateway:       el.gateway6,
+				GlobalIPv6Address: IPv6,
 			},
 		},
-		el.err
+	}
 }
 
 func (el *NextNetworkAutoConfiguration) GetCurrentIpAddress() (IP string, err error) {
 	return el.ip.String(), el.err
 }
+
+// GetCurrentIpV6Address
+//
+// English:
+//
+//	Returns the next IPv6 address, empty when the network is not dual-stack
+//
+// Português:
+//
+//	Retorna o próximo endereço IPv6, vazio quando a rede não é dual-stack
+func (el *NextNetworkAutoConfiguration) GetCurrentIpV6Address() (IP string, err error) {
+	if el.subnet6 == "" {
+		return "", el.err
+	}
+
+	return el.ip6.String(), el.err
+}
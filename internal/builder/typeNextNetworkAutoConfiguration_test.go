@@ -0,0 +1,108 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types"
+	"sync"
+	"testing"
+)
+
+func TestNextNetworkAutoConfiguration_Lease(t *testing.T) {
+	var generator NextNetworkAutoConfiguration
+	generator.Init("id", "delete_network", "10.0.0.1", "10.0.0.0/29")
+
+	// live endpoint of a container created by another manager
+	generator.inspect = func() (types.NetworkResource, error) {
+		return types.NetworkResource{
+			Containers: map[string]types.EndpointResource{
+				"a": {Name: "delete_other_0", IPv4Address: "10.0.0.2/29"},
+			},
+		}, nil
+	}
+
+	ip, _, _, err := generator.Lease("delete_db_0", "", "")
+	if err != nil || ip != "10.0.0.3" {
+		t.Fatalf("the live endpoint must be skipped: %v, %v", ip, err)
+	}
+
+	if ip, _, _, _ = generator.Lease("delete_db_0", "", ""); ip != "10.0.0.3" {
+		t.Errorf("a recreated container must keep its address: %v", ip)
+	}
+
+	if ip, _, _, _ = generator.Lease("delete_other_0", "", ""); ip != "10.0.0.2" {
+		t.Errorf("the live endpoint is the lease of the container: %v", ip)
+	}
+
+	if _, _, _, err = generator.Lease("delete_db_1", "10.0.0.3", ""); err == nil {
+		t.Errorf("a static address in use must fail")
+	}
+
+	if _, _, _, err = generator.Lease("delete_db_1", "10.0.1.3", ""); err == nil {
+		t.Errorf("a static address out of the subnet must fail")
+	}
+
+	if _, _, _, err = generator.Lease("delete_db_1", "10.0.0.1", ""); err == nil {
+		t.Errorf("the gateway must fail as a static address")
+	}
+
+	if ip, _, _, _ = generator.Lease("delete_db_1", "10.0.0.6", ""); ip != "10.0.0.6" {
+		t.Errorf("unexpected static address: %v", ip)
+	}
+
+	// 10.0.0.4 and 10.0.0.5 are free, 10.0.0.7 is the broadcast address
+	for _, name := range []string{"delete_db_2", "delete_db_3"} {
+		if _, _, _, err = generator.Lease(name, "", ""); err != nil {
+			t.Fatalf("Lease(%v).error: %v", name, err)
+		}
+	}
+
+	if _, _, _, err = generator.Lease("delete_db_4", "", ""); err == nil {
+		t.Errorf("a full network must fail")
+	}
+
+	generator.Release("delete_db_0")
+	if ip, _, _, _ = generator.Lease("delete_db_4", "", ""); ip != "10.0.0.3" {
+		t.Errorf("a released address must be reused: %v", ip)
+	}
+}
+
+func TestNextNetworkAutoConfiguration_LeaseInvalidEndpoint(t *testing.T) {
+	var generator NextNetworkAutoConfiguration
+	generator.Init("id", "delete_network", "10.0.0.1", "10.0.0.0/29")
+
+	generator.inspect = func() (types.NetworkResource, error) {
+		return types.NetworkResource{
+			Containers: map[string]types.EndpointResource{
+				"a": {Name: "delete_db_0", IPv4Address: "10.0.0/29"},
+			},
+		}, nil
+	}
+
+	// the invalid address of the inspect must not panic
+	if _, _, _, err := generator.Lease("delete_db_0", "", ""); err == nil {
+		t.Errorf("an invalid address of the live endpoint must fail")
+	}
+}
+
+func TestNextNetworkAutoConfiguration_LeaseConcurrent(t *testing.T) {
+	var generator NextNetworkAutoConfiguration
+	generator.Init("id", "delete_network", "10.0.0.1", "10.0.0.0/16")
+
+	var wg sync.WaitGroup
+	var list = make([]string, 100)
+	for i := range list {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			list[i], _, _, _ = generator.Lease(string(rune('a'+i%26))+string(rune('a'+i/26)), "", "")
+		}(i)
+	}
+	wg.Wait()
+
+	var unique = make(map[string]bool)
+	for _, ip := range list {
+		if ip == "" || unique[ip] {
+			t.Fatalf("duplicated or empty address: %q", ip)
+		}
+		unique[ip] = true
+	}
+}
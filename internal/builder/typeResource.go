@@ -0,0 +1,22 @@
+package builder
+
+// Kinds of the docker elements returned by ResourceListByLabel()
+const (
+	KResourceContainer = "container"
+	KResourceNetwork   = "network"
+	KResourceVolume    = "volume"
+	KResourceImage     = "image"
+)
+
+// Resource (English): Docker element found by ResourceListByLabel()
+//
+// Resource (Português): Elemento docker encontrado por ResourceListByLabel()
+type Resource struct {
+	// One of KResourceContainer, KResourceNetwork, KResourceVolume or KResourceImage
+	Kind string
+
+	ID   string
+	Name string
+
+	Labels map[string]string
+}
@@ -13,7 +13,7 @@ func (el *DockerSystem) VolumeCreate(
 	err error,
 ) {
 
-	volume, err = el.cli.VolumeCreate(el.ctx, volumeTypes.VolumeCreateBody{Labels: labels, Name: name})
+	volume, err = el.cli.VolumeCreate(el.ctx, volumeTypes.VolumeCreateBody{Labels: el.labelsMerge(labels), Name: name})
 
 	return
 }
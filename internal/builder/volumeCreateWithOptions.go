@@ -0,0 +1,41 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types"
+	volumeTypes "github.com/docker/docker/api/types/volume"
+)
+
+// VolumeCreateWithOptions (English): Creates a volume with a driver and its options
+//
+//	labels: volume labels
+//	name: volume name
+//	driver: volume driver. Use "" for the default driver, local
+//	driverOpts: driver options. E.g.: map[string]string{"type": "tmpfs", "device": "tmpfs", "o": "size=64m"}, a volume
+//	  with size limit
+//
+// VolumeCreateWithOptions (Português): Cria um volume com um driver e as suas opções
+//
+//	labels: rótulos do volume
+//	name: nome do volume
+//	driver: driver do volume. Use "" para o driver padrão, local
+//	driverOpts: opções do driver. Ex.: map[string]string{"type": "tmpfs", "device": "tmpfs", "o": "size=64m"}, um
+//	  volume com limite de tamanho
+func (el *DockerSystem) VolumeCreateWithOptions(
+	labels map[string]string,
+	name string,
+	driver string,
+	driverOpts map[string]string,
+) (
+	volume types.Volume,
+	err error,
+) {
+
+	volume, err = el.cli.VolumeCreate(el.ctx, volumeTypes.VolumeCreateBody{
+		Labels:     el.labelsMerge(labels),
+		Name:       name,
+		Driver:     driver,
+		DriverOpts: driverOpts,
+	})
+
+	return
+}
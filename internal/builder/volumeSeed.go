@@ -0,0 +1,66 @@
+package builder
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"io"
+)
+
+// kVolumeSeedPath is the folder where the volume is mounted during the seed
+const kVolumeSeedPath = "/chaos-seed"
+
+// VolumeSeed (English): Copies files into a volume, before the container that uses it is created
+//
+//	name: volume name
+//	image: image of the auxiliary container used to copy the files. The container is never started
+//	content: files in the tar format. E.g.: archive.TarWithOptions(hostPath, &archive.TarOptions{})
+//
+//	Note: the image platform can be defined by SetPlatform()
+//
+// VolumeSeed (Português): Copia arquivos para dentro de um volume, antes do container que o usa ser criado
+//
+//	name: nome do volume
+//	image: imagem do container auxiliar usado para copiar os arquivos. O container nunca é iniciado
+//	content: arquivos no formato tar. Ex.: archive.TarWithOptions(hostPath, &archive.TarOptions{})
+//
+//	Nota: a plataforma da imagem pode ser definida por SetPlatform()
+func (el *DockerSystem) VolumeSeed(
+	name string,
+	image string,
+	content io.Reader,
+) (
+	err error,
+) {
+
+	var resp container.ContainerCreateCreatedBody
+	resp, err = el.cli.ContainerCreate(
+		el.ctx,
+		&container.Config{
+			Image: image,
+			Cmd:   []string{"seed"},
+		},
+		&container.HostConfig{
+			Mounts: []mount.Mount{
+				{
+					Type:   KVolumeMountTypeVolumeString,
+					Source: name,
+					Target: kVolumeSeedPath,
+				},
+			},
+		},
+		nil,
+		el.platform,
+		"",
+	)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_ = el.cli.ContainerRemove(el.ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
+	}()
+
+	err = el.cli.CopyToContainer(el.ctx, resp.ID, kVolumeSeedPath, content, types.CopyToContainerOptions{})
+	return
+}
@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -24,6 +25,9 @@ type DockerfileGolang struct {
 	workDir            string
 	buildDst           string
 	buildSrc           string
+	coverage           bool
+	buildSecrets       []string
+	sshAgent           bool
 }
 
 // SetFinalImageName
@@ -84,6 +88,60 @@ func (e *DockerfileGolang) SetDefaultSshFileName(name string) {
 	e.sshDefaultFileName = name
 }
 
+// EnableCoverage
+//
+// English:
+//
+//	Compiles the project with the `-cover` flag, so the binary writes coverage data into the folder defined by the
+//	GOCOVERDIR environment variable.
+//
+//	 Note:
+//	   * Requires golang 1.20 or later. If SetFinalImageName() was not called, golang:1.20-alpine is used;
+//	   * Coverage counters are only written when the program exits normally, so the code under test must handle
+//	     SIGTERM and return from main().
+//
+// Português:
+//
+//	Compila o projeto com o flag `-cover`, para que o binário escreva os dados de cobertura na pasta definida pela
+//	variável de ambiente GOCOVERDIR.
+//
+//	 Nota:
+//	   * Requer golang 1.20 ou posterior. Caso SetFinalImageName() não tenha sido chamada, golang:1.20-alpine é usada;
+//	   * Os contadores de cobertura só são escritos quando o programa termina normalmente, por isto, o código sob teste
+//	     deve tratar o SIGTERM e retornar da main().
+func (e *DockerfileGolang) EnableCoverage() {
+	e.coverage = true
+}
+
+// EnableBuildSecrets
+//
+// English:
+//
+//	Mounts the credentials as BuildKit secrets in the steps that download the dependencies, instead of build args,
+//	so the credentials never are written in the image layers or in the `docker history`.
+//
+//	 Input:
+//	   secrets: list of secret ids. ssh_key is mounted as /root/.ssh/(ssh file name), ssh_key_ecdsa as
+//	     /root/.ssh/id_ecdsa, except when ssh_key already uses this name, known_hosts as /root/.ssh/known_hosts,
+//	     gitconfig is included in /root/.gitconfig and others as /run/secrets/(id)
+//	   sshAgent: forwards the ssh agent of the host computer, RUN --mount=type=ssh
+//
+// Português:
+//
+//	Monta as credenciais como segredos do BuildKit nas etapas que baixam as dependências, em vez de build args,
+//	para que as credenciais nunca sejam escritas nas camadas da imagem ou no `docker history`.
+//
+//	 Entrada:
+//	   secrets: lista de ids de segredos. ssh_key é montado como /root/.ssh/(nome do arquivo ssh), ssh_key_ecdsa como
+//	     /root/.ssh/id_ecdsa, exceto quando ssh_key já usa este nome, known_hosts como /root/.ssh/known_hosts,
+//	     gitconfig é incluído em /root/.gitconfig e outros como /run/secrets/(id)
+//	   sshAgent: encaminha o agente ssh do computador hospedeiro, RUN --mount=type=ssh
+func (e *DockerfileGolang) EnableBuildSecrets(secrets []string, sshAgent bool) {
+	e.buildSecrets = secrets
+	e.sshAgent = sshAgent
+	sort.Strings(e.buildSecrets)
+}
+
 // Prayer
 //
 // English:
@@ -184,6 +242,10 @@ func (e *DockerfileGolang) MountDefaultDockerfile(
 		e.buildSrc = "/app/main.go"
 	}
 
+	if e.finalImageName == "" && e.coverage == true {
+		e.finalImageName = "golang:1.20-alpine"
+	}
+
 	if e.finalImageName == "" {
 		e.finalImageName = "golang:1.19-alpine"
 	}
@@ -342,6 +404,43 @@ ARG CGO_ENABLED=0
 RUN git config --global url.ssh://git@github.com/.insteadOf https://github.com/
 `
 
+	// (en) BuildKit secrets exist only while the RUN instruction that mounts them is running
+	// (pt) os segredos do BuildKit só existem enquanto a instrução RUN que os monta está rodando
+	var mountSecrets string
+	var sshKey bool
+	for _, id := range e.buildSecrets {
+		sshKey = sshKey || id == "ssh_key"
+	}
+
+	for _, id := range e.buildSecrets {
+		switch id {
+		case "ssh_key":
+			mountSecrets += `--mount=type=secret,id=ssh_key,target=/root/.ssh/` + e.sshDefaultFileName + `,mode=0600 `
+		case "ssh_key_ecdsa":
+			// (en) ssh_key already uses the file name id_ecdsa
+			// (pt) ssh_key já usa o nome de arquivo id_ecdsa
+			if e.sshDefaultFileName == "id_ecdsa" && sshKey == true {
+				continue
+			}
+			mountSecrets += `--mount=type=secret,id=ssh_key_ecdsa,target=/root/.ssh/id_ecdsa,mode=0600 `
+		case "known_hosts":
+			mountSecrets += `--mount=type=secret,id=known_hosts,target=/root/.ssh/known_hosts `
+		case "gitconfig":
+			mountSecrets += `--mount=type=secret,id=gitconfig `
+			dockerfile += `
+# (en) includes the .gitconfig file, mounted as a secret, into the git configuration
+# (pt) inclui o arquivo .gitconfig, montado como segredo, na configuração do git
+RUN git config --global include.path /run/secrets/gitconfig
+`
+		default:
+			mountSecrets += `--mount=type=secret,id=` + id + ` `
+		}
+	}
+
+	if e.sshAgent == true {
+		mountSecrets += `--mount=type=ssh `
+	}
+
 	_, found = args["GIT_PRIVATE_REPO"]
 	if found == true {
 		dockerfile += `
@@ -351,13 +450,18 @@ RUN go env -w GOPRIVATE=$GIT_PRIVATE_REPO
 `
 	}
 
+	var buildFlags = `-ldflags="-w -s"`
+	if e.coverage == true {
+		buildFlags = `-cover ` + buildFlags
+	}
+
 	dockerfile += `
 # (en) install the dependencies in the go.mod file
 # (pt) instala as dependências no arquivo go.mod
-RUN go mod tidy
+RUN ` + mountSecrets + `go mod tidy
 # (en) compiles the main.go file
 # (pt) compila o arquivo main.go
-RUN go build -ldflags="-w -s" -o ` + e.buildDst + ` ` + e.buildSrc + `
+RUN ` + mountSecrets + `go build ` + buildFlags + ` -o ` + e.buildDst + ` ` + e.buildSrc + `
 # (en) creates a new scratch-based image
 # (pt) cria uma nova imagem baseada no scratch
 # (en) scratch is an extremely simple OS capable of generating very small images
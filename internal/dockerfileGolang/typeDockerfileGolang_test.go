@@ -0,0 +1,118 @@
+package dockerfileGolang
+
+import (
+	"strings"
+	"testing"
+)
+
+// dockerfileMount
+//
+// Returns the Dockerfile without arguments, ports and volumes, with the line breaks \n
+func dockerfileMount(t *testing.T, e *DockerfileGolang) (dockerfile string) {
+	var err error
+	if dockerfile, err = e.MountDefaultDockerfile(nil, nil, nil, false, false, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.ReplaceAll(dockerfile, "\r\n", "\n")
+}
+
+func TestDockerfileGolang_EnableBuildSecrets(t *testing.T) {
+	var tests = []struct {
+		name     string
+		secrets  []string
+		sshAgent bool
+		sshFile  string
+		want     []string
+		wantNot  []string
+	}{
+		{
+			name:    "disabled",
+			want:    []string{"\nRUN go mod tidy\n", "\nRUN go build -ldflags=\"-w -s\" -o /app/main /app/main.go\n"},
+			wantNot: []string{"--mount=", "include.path"},
+		},
+		{
+			name:    "secrets",
+			secrets: []string{"ssh_key", "known_hosts", "gitconfig", "npm_token"},
+			sshFile: "id_rsa",
+			want: []string{
+				"\nRUN git config --global include.path /run/secrets/gitconfig\n",
+				"\nRUN --mount=type=secret,id=gitconfig --mount=type=secret,id=known_hosts,target=/root/.ssh/known_hosts " +
+					"--mount=type=secret,id=npm_token --mount=type=secret,id=ssh_key,target=/root/.ssh/id_rsa,mode=0600 go mod tidy\n",
+				"--mount=type=secret,id=ssh_key,target=/root/.ssh/id_rsa,mode=0600 go build ",
+			},
+			wantNot: []string{"--mount=type=ssh", "SSH_ID_RSA_FILE"},
+		},
+		{
+			name:     "ssh agent",
+			sshAgent: true,
+			want:     []string{"\nRUN --mount=type=ssh go mod tidy\n"},
+			wantNot:  []string{"--mount=type=secret"},
+		},
+		{
+			name:    "two keys",
+			secrets: []string{"ssh_key_ecdsa", "ssh_key"},
+			sshFile: "id_rsa",
+			want: []string{
+				"\nRUN --mount=type=secret,id=ssh_key,target=/root/.ssh/id_rsa,mode=0600 " +
+					"--mount=type=secret,id=ssh_key_ecdsa,target=/root/.ssh/id_ecdsa,mode=0600 go mod tidy\n",
+			},
+		},
+		{
+			name:    "two keys with the same file name",
+			secrets: []string{"ssh_key", "ssh_key_ecdsa"},
+			want:    []string{"\nRUN --mount=type=secret,id=ssh_key,target=/root/.ssh/id_ecdsa,mode=0600 go mod tidy\n"},
+			wantNot: []string{"id=ssh_key_ecdsa"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var e = new(DockerfileGolang)
+			if test.sshFile != "" {
+				e.SetDefaultSshFileName(test.sshFile)
+			}
+			if test.secrets != nil || test.sshAgent {
+				e.EnableBuildSecrets(test.secrets, test.sshAgent)
+			}
+
+			var dockerfile = dockerfileMount(t, e)
+			for _, text := range test.want {
+				if !strings.Contains(dockerfile, text) {
+					t.Errorf("the dockerfile must contain %q:\n%v", text, dockerfile)
+				}
+			}
+
+			for _, text := range test.wantNot {
+				if strings.Contains(dockerfile, text) {
+					t.Errorf("the dockerfile must not contain %q:\n%v", text, dockerfile)
+				}
+			}
+		})
+	}
+}
+
+func TestDockerfileGolang_EnableCoverage(t *testing.T) {
+	var dockerfile = dockerfileMount(t, new(DockerfileGolang))
+	if strings.Contains(dockerfile, "-cover") || !strings.Contains(dockerfile, "FROM golang:1.19-alpine as builder") {
+		t.Errorf("the coverage must be disabled by default:\n%v", dockerfile)
+	}
+
+	var e = new(DockerfileGolang)
+	e.EnableCoverage()
+	dockerfile = dockerfileMount(t, e)
+
+	// -cover requires golang 1.20
+	if !strings.Contains(dockerfile, "\nRUN go build -cover -ldflags=\"-w -s\" -o /app/main /app/main.go\n") ||
+		!strings.Contains(dockerfile, "FROM golang:1.20-alpine as builder") {
+		t.Errorf("unexpected dockerfile:\n%v", dockerfile)
+	}
+
+	// the image defined by the user is kept
+	e = new(DockerfileGolang)
+	e.SetFinalImageName("golang:1.21-alpine")
+	e.EnableCoverage()
+	if dockerfile = dockerfileMount(t, e); !strings.Contains(dockerfile, "FROM golang:1.21-alpine as builder") {
+		t.Errorf("unexpected dockerfile:\n%v", dockerfile)
+	}
+}
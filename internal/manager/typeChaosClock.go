@@ -0,0 +1,340 @@
+package manager
+
+import (
+	"fmt"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/helmutkemper/chaos/internal/builder"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// kClockContainerDir is the folder, inside the container, with libfaketime and its control file
+	kClockContainerDir = "/chaos/faketime"
+
+	// kClockLibrary is the name of libfaketime inside the container
+	kClockLibrary = "libfaketime.so.1"
+
+	// kClockControlDir is the folder of the control file, mounted from the host computer, inside kClockContainerDir
+	kClockControlDir = "control"
+
+	// kClockControlFile is the name of the control file read by libfaketime
+	kClockControlFile = "faketimerc"
+
+	// kClockNoSkew is the libfaketime setting without skew
+	kClockNoSkew = "+0"
+)
+
+const (
+	kClockOffset = "offset"
+	kClockDrift  = "drift"
+)
+
+// chaosClock
+//
+// Clock skew chaos defined by ClockSkewLibrary(), ChaosClock*()
+type chaosClock struct {
+	// Path of libfaketime on the host computer
+	library string
+
+	// Offset of the clock
+	offsetMin time.Duration
+	offsetMax time.Duration
+
+	// Speed of the clock, where 1.0 is the real speed
+	driftMin float64
+	driftMax float64
+
+	// Maximum number of copies with clock skew at the same time. Zero for no limit
+	maxSkewed int
+
+	// Folder of the control file on the host computer, where the key is the index from Create(copies)
+	controlDir []string
+}
+
+// kinds
+//
+// List of the clock skews defined
+func (el chaosClock) kinds() (kinds []string) {
+	if el.library == "" {
+		return
+	}
+
+	if el.offsetMin != 0 || el.offsetMax != 0 {
+		kinds = append(kinds, kClockOffset)
+	}
+
+	if el.driftMax > 0 {
+		kinds = append(kinds, kClockDrift)
+	}
+
+	return
+}
+
+// environment
+//
+// Environment variables that load libfaketime in the processes of the container. FAKETIME is not defined, because it
+// takes precedence over the control file, and without the control file the clock is not changed
+func (el chaosClock) environment() (env []string) {
+	return []string{
+		"LD_PRELOAD=" + kClockContainerDir + "/" + kClockLibrary,
+		"FAKETIME_TIMESTAMP_FILE=" + kClockContainerDir + "/" + kClockControlDir + "/" + kClockControlFile,
+		"FAKETIME_NO_CACHE=1",
+	}
+}
+
+// mount
+//
+// Mount of libfaketime inside the container
+func (el chaosClock) mount() (volume mount.Mount) {
+	return mount.Mount{
+		Type:     builder.KVolumeMountTypeBindString,
+		Source:   el.library,
+		Target:   kClockContainerDir + "/" + kClockLibrary,
+		ReadOnly: true,
+	}
+}
+
+// setting
+//
+// libfaketime setting of the skew. The value is chosen between minimum and maximum by random, from 0.0 to 1.0
+//
+//	offset: "+120" or "-30", in seconds
+//	drift: "+0 x1.500", the clock runs 1.5 times faster
+func (el chaosClock) setting(kind string, random float64) (setting string) {
+	switch kind {
+	case kClockOffset:
+		var offset = el.offsetMin + time.Duration(float64(el.offsetMax-el.offsetMin)*random)
+		setting = fmt.Sprintf("%+d", int64(offset/time.Second))
+	case kClockDrift:
+		setting = fmt.Sprintf("+0 x%.3f", el.driftMin+(el.driftMax-el.driftMin)*random)
+	default:
+		setting = kClockNoSkew
+	}
+
+	return
+}
+
+// ClockSkewLibrary
+//
+// English:
+//
+//	Loads libfaketime in the processes of all copies, so ChaosClockOffset() and ChaosClockDrift() can change the
+//	clock perceived by the project.
+//
+//	 Input:
+//	   hostPath: libfaketime on the host computer, built for the libc of the image. e.g.
+//	     /usr/lib/x86_64-linux-gnu/faketime/libfaketime.so.1 (Debian package faketime)
+//
+//	 Notes:
+//	   * The library is mounted in /chaos/faketime/libfaketime.so.1 and loaded by LD_PRELOAD;
+//	   * The control file of each copy is written on the host computer, in clock/<name> inside the save folder of
+//	     Test(), and mounted in /chaos/faketime/control, so the clock skew works with ReadonlyRootfs();
+//	   * Only dynamically linked programs that read the clock through libc are affected, e.g. Java, Node.js, Python
+//	     and C. Go binaries read the clock directly from the kernel and are not affected.
+//
+// Português:
+//
+//	Carrega libfaketime nos processos de todas as cópias, assim, ChaosClockOffset() e ChaosClockDrift() podem
+//	alterar o relógio percebido pelo projeto.
+//
+//	 Entrada:
+//	   hostPath: libfaketime no computador hospedeiro, compilada para a libc da imagem. ex.
+//	     /usr/lib/x86_64-linux-gnu/faketime/libfaketime.so.1 (pacote Debian faketime)
+//
+//	 Notas:
+//	   * A biblioteca é montada em /chaos/faketime/libfaketime.so.1 e carregada por LD_PRELOAD;
+//	   * O arquivo de controle de cada cópia é escrito no computador hospedeiro, em clock/<nome> na pasta de Test(), e
+//	     montado em /chaos/faketime/control, assim, o caos de relógio funciona com ReadonlyRootfs();
+//	   * Apenas programas ligados dinamicamente que leem o relógio pela libc são afetados, ex. Java, Node.js, Python
+//	     e C. Binários Go leem o relógio direto do kernel e não são afetados.
+func (el *ContainerFromImage) ClockSkewLibrary(hostPath string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	var err error
+	if el.clock.library, err = filepath.Abs(hostPath); err != nil {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("containerFromImage.ClockSkewLibrary().error: %v", err)
+		return el
+	}
+
+	return el
+}
+
+// ChaosClockOffset
+//
+// English:
+//
+//	During the chaos test, shifts the clock of one copy for a time window and then restores it.
+//
+//	 Input:
+//	   min, max: offset of the clock, e.g. -5 * time.Minute and 5 * time.Minute
+//
+//	 Notes:
+//	   * Requires EnableChaos() and ClockSkewLibrary();
+//	   * The time window is defined by ChaosClockSkew().
+//
+// Português:
+//
+//	Durante o teste de caos, desloca o relógio de uma cópia por uma janela de tempo e depois o restaura.
+//
+//	 Entrada:
+//	   min, max: deslocamento do relógio, ex. -5 * time.Minute e 5 * time.Minute
+//
+//	 Notas:
+//	   * Requer EnableChaos() e ClockSkewLibrary();
+//	   * A janela de tempo é definida por ChaosClockSkew().
+func (el *ContainerFromImage) ChaosClockOffset(min, max time.Duration) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.clock.offsetMin = min
+	el.clock.offsetMax = max
+	return el
+}
+
+// ChaosClockDrift
+//
+// English:
+//
+//	During the chaos test, changes the speed of the clock of one copy for a time window and then restores it.
+//
+//	 Input:
+//	   min, max: speed of the clock, where 1.0 is the real speed. e.g. 0.9 and 1.1
+//
+//	 Notes:
+//	   * Requires EnableChaos() and ClockSkewLibrary();
+//	   * The time window is defined by ChaosClockSkew().
+//
+// Português:
+//
+//	Durante o teste de caos, altera a velocidade do relógio de uma cópia por uma janela de tempo e depois a restaura.
+//
+//	 Entrada:
+//	   min, max: velocidade do relógio, onde 1.0 é a velocidade real. ex. 0.9 e 1.1
+//
+//	 Notas:
+//	   * Requer EnableChaos() e ClockSkewLibrary();
+//	   * A janela de tempo é definida por ChaosClockSkew().
+func (el *ContainerFromImage) ChaosClockDrift(min, max float64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.clock.driftMin = min
+	el.clock.driftMax = max
+	return el
+}
+
+// ChaosClockSkew
+//
+// English:
+//
+//	Defines the clock skew chaos, ChaosClockOffset() and ChaosClockDrift().
+//
+//	 Input:
+//	   maxSkewed: maximum number of copies with clock skew at the same time. Zero for no limit
+//	   min, max: time window of the skew (Default: 30s to 90s)
+//
+// Português:
+//
+//	Define o caos de relógio, ChaosClockOffset() e ChaosClockDrift().
+//
+//	 Entrada:
+//	   maxSkewed: quantidade máxima de cópias com o relógio alterado ao mesmo tempo. Zero para sem limite
+//	   min, max: janela de tempo da alteração (Padrão: 30s a 90s)
+func (el *ContainerFromImage) ChaosClockSkew(maxSkewed int, min, max time.Duration) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.clock.maxSkewed = maxSkewed
+	el.manager.ChaosConfig.minimumTimeClockSkew = min
+	el.manager.ChaosConfig.maximumTimeClockSkew = max
+	return el
+}
+
+// queueContainerClockSkew
+//
+// Changes the clock of the copy, offset or drift chosen by random, and restores it at the end of the time window
+func (el *ContainerFromImage) queueContainerClockSkew(iCopy int) {
+	var kinds = el.clock.kinds()
+	var kind = kinds[rand.Intn(len(kinds))]
+	var setting = el.clock.setting(kind, rand.Float64())
+
+	var chaos chaosAction
+	nextTime := time.Now().Add(el.selectDuration(el.manager.ChaosConfig.maximumTimeDelay, el.manager.ChaosConfig.minimumTimeDelay))
+	chaos = chaosAction{
+		display: "clockSkew(" + setting + ")",
+		time:    nextTime,
+		action: func(_ string) (err error) {
+			return el.clockSkew(iCopy, setting)
+		},
+		id: el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+
+	nextTime = nextTime.Add(el.selectDuration(el.manager.ChaosConfig.maximumTimeClockSkew, el.manager.ChaosConfig.minimumTimeClockSkew))
+	chaos = chaosAction{
+		display: "clockRestore()",
+		time:    nextTime,
+		action: func(_ string) (err error) {
+			return el.clockSkew(iCopy, kClockNoSkew)
+		},
+		id: el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+	el.manager.Chaos[iCopy].Type = "clock" //todo: const
+}
+
+// clockCopy
+//
+// Creates the folder of the control file of the copy, without skew, and returns its mount
+func (el *ContainerFromImage) clockCopy(iCopy int) (volume mount.Mount, err error) {
+	var dir string
+	if dir, err = filepath.Abs(filepath.Join(testPathGlobal, "clock", el.copyName(iCopy))); err != nil {
+		err = fmt.Errorf("container.clockCopy().Abs().error: %v", err)
+		return
+	}
+
+	_ = os.RemoveAll(dir)
+	if err = os.MkdirAll(dir, fs.ModePerm); err != nil {
+		err = fmt.Errorf("container.clockCopy().MkdirAll().error: %v", err)
+		return
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, kClockControlFile), []byte(kClockNoSkew+"\n"), 0644); err != nil {
+		err = fmt.Errorf("container.clockCopy().WriteFile().error: %v", err)
+		return
+	}
+
+	el.clock.controlDir = append(el.clock.controlDir, dir)
+
+	volume = mount.Mount{
+		Type:   builder.KVolumeMountTypeBindString,
+		Source: dir,
+		Target: kClockContainerDir + "/" + kClockControlDir,
+	}
+	return
+}
+
+// clockSkew
+//
+// Writes the libfaketime control file of the copy on the host computer. The file is replaced by rename, so libfaketime
+// never reads half of the setting
+func (el *ContainerFromImage) clockSkew(iCopy int, setting string) (err error) {
+	var dir = el.clock.controlDir[iCopy]
+	var temp = filepath.Join(dir, kClockControlFile+".tmp")
+	if err = os.WriteFile(temp, []byte(setting+"\n"), 0644); err != nil {
+		return
+	}
+
+	return os.Rename(temp, filepath.Join(dir, kClockControlFile))
+}
@@ -0,0 +1,212 @@
+package manager
+
+import (
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/helmutkemper/chaos/internal/builder"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChaosClock_setting(t *testing.T) {
+	var clock = chaosClock{
+		offsetMin: -5 * time.Minute,
+		offsetMax: 5 * time.Minute,
+		driftMin:  0.5,
+		driftMax:  1.5,
+	}
+
+	if len(clock.kinds()) != 0 {
+		t.Errorf("without library, the clock skew must be disabled")
+	}
+
+	clock.library = "/usr/lib/faketime/libfaketime.so.1"
+	if len(clock.kinds()) != 2 {
+		t.Errorf("kinds: %v", clock.kinds())
+	}
+
+	var list = []struct {
+		kind     string
+		random   float64
+		expected string
+	}{
+		{kind: kClockOffset, random: 0, expected: "-300"},
+		{kind: kClockOffset, random: 1, expected: "+300"},
+		{kind: kClockOffset, random: 0.5, expected: "+0"},
+		{kind: kClockDrift, random: 0.5, expected: "+0 x1.000"},
+		{kind: "", random: 0.5, expected: kClockNoSkew},
+	}
+
+	for _, test := range list {
+		if setting := clock.setting(test.kind, test.random); setting != test.expected {
+			t.Errorf("%v(%v): %v", test.kind, test.random, setting)
+		}
+	}
+}
+
+func TestChaosClock_environment(t *testing.T) {
+	for _, env := range (chaosClock{}).environment() {
+		// FAKETIME takes precedence over the control file, so the skew would never be applied
+		if strings.HasPrefix(env, "FAKETIME=") {
+			t.Errorf("FAKETIME must not be defined: %v", env)
+		}
+	}
+}
+
+// clockTestLibrary
+//
+// Returns libfaketime of the host computer, defined by CHAOS_FAKETIME_LIBRARY or installed by the Debian package
+func clockTestLibrary() (library string) {
+	var list = []string{
+		os.Getenv("CHAOS_FAKETIME_LIBRARY"),
+		"/usr/lib/x86_64-linux-gnu/faketime/libfaketime.so.1",
+		"/usr/lib/aarch64-linux-gnu/faketime/libfaketime.so.1",
+		"/usr/lib/faketime/libfaketime.so.1",
+	}
+
+	for _, library = range list {
+		if library == "" {
+			continue
+		}
+
+		if _, err := os.Stat(library); err == nil {
+			return
+		}
+	}
+
+	return ""
+}
+
+func TestContainerFromImage_clockCopy(t *testing.T) {
+	var path = testPathGlobal
+	testPathGlobal = t.TempDir()
+	t.Cleanup(func() {
+		testPathGlobal = path
+	})
+
+	var el = new(ContainerFromImage)
+	el.containerName = "delete_app"
+	volume, err := el.clockCopy(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dir = filepath.Join(testPathGlobal, "clock", "delete_app_0")
+	if volume.Type != "bind" || volume.Source != dir || volume.Target != kClockContainerDir+"/"+kClockControlDir || volume.ReadOnly {
+		t.Errorf("unexpected volume: %+v", volume)
+	}
+
+	var setting = func() string {
+		data, err := os.ReadFile(filepath.Join(dir, kClockControlFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if setting() != kClockNoSkew+"\n" {
+		t.Errorf("the copy must start without skew: %q", setting())
+	}
+
+	if err = el.clockSkew(0, "+120"); err != nil || setting() != "+120\n" {
+		t.Errorf("unexpected control file: %q, %v", setting(), err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("only the control file must be left in the folder: %v", entries)
+	}
+
+	var found = false
+	for _, env := range el.clock.environment() {
+		found = found || env == "FAKETIME_TIMESTAMP_FILE="+volume.Target+"/"+kClockControlFile
+	}
+	if !found {
+		t.Errorf("FAKETIME_TIMESTAMP_FILE must point to the mounted control file: %v", el.clock.environment())
+	}
+}
+
+func TestContainerFromImage_clockSkew(t *testing.T) {
+	var library = clockTestLibrary()
+	if library == "" {
+		t.Skip("libfaketime is not installed, install the package faketime or define CHAOS_FAKETIME_LIBRARY")
+	}
+
+	var dockerSys = new(builder.DockerSystem)
+	if err := dockerSys.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	const image = "debian:bookworm-slim"
+	if _, _, err := dockerSys.ImagePull(image, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var path = testPathGlobal
+	testPathGlobal = t.TempDir()
+	t.Cleanup(func() {
+		testPathGlobal = path
+	})
+
+	var el = new(ContainerFromImage)
+	el.containerName = "delete_clock_skew"
+	el.clock.library = library
+	control, err := el.clockCopy(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the control file is written on the host computer, so the root filesystem can be read only
+	var config = &dockerContainer.Config{Image: image, Cmd: []string{"sleep", "300"}, Env: el.clock.environment()}
+	dockerSys.SetHostConfig(dockerContainer.HostConfig{ReadonlyRootfs: true})
+	id, _, err := dockerSys.ContainerCreateWithConfig(config, "delete_clock_skew", builder.KRestartPolicyNo, nil, []mount.Mount{el.clock.mount(), control}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = dockerSys.ContainerRemove(id, true, false, true)
+	})
+
+	if err = dockerSys.ContainerStart(id); err != nil {
+		t.Fatal(err)
+	}
+
+	el.manager = &Manager{DockerSys: []*builder.DockerSystem{dockerSys}, Id: []string{id}}
+
+	var clockInside = func() time.Time {
+		exitCode, stdOutput, stdError, err := dockerSys.ContainerExecOutput(id, []string{"date", "+%s"}, 10*time.Second)
+		if err != nil || exitCode != 0 {
+			t.Fatalf("date: %v, %v, %s", exitCode, err, stdError)
+		}
+
+		seconds, err := strconv.ParseInt(strings.TrimSpace(string(stdOutput)), 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return time.Unix(seconds, 0)
+	}
+
+	if skew := clockInside().Sub(time.Now()); skew > time.Minute || skew < -time.Minute {
+		t.Errorf("without skew, the clock must not change: %v", skew)
+	}
+
+	if err = el.clockSkew(0, "+86400"); err != nil {
+		t.Fatal(err)
+	}
+
+	if skew := clockInside().Sub(time.Now()); skew < 23*time.Hour || skew > 25*time.Hour {
+		t.Errorf("the skew of the control file must be visible inside the container: %v", skew)
+	}
+
+	if err = el.clockSkew(0, kClockNoSkew); err != nil {
+		t.Fatal(err)
+	}
+
+	if skew := clockInside().Sub(time.Now()); skew > time.Minute || skew < -time.Minute {
+		t.Errorf("the clock must be restored: %v", skew)
+	}
+}
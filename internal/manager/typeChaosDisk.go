@@ -0,0 +1,454 @@
+package manager
+
+import (
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	networkTypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/helmutkemper/chaos/internal/builder"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"github.com/helmutkemper/chaos/internal/stressor"
+	"math/rand"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	kDiskFill     = "fill"
+	kDiskReadOnly = "readOnly"
+	kDiskLatency  = "latency"
+	kDiskCorrupt  = "corrupt"
+	kDiskTruncate = "truncate"
+)
+
+// containerCreateArgs
+//
+// Arguments used by Create() to create one copy
+type containerCreateArgs struct {
+	config        dockerContainer.Config
+	name          string
+	restartPolicy builder.RestartPolicy
+	ports         nat.PortMap
+	volumes       []mount.Mount
+	network       *networkTypes.NetworkingConfig
+
+	// Networks connected after the creation, defined by Networks()
+	extraNetworks []containerNetworkEndpoint
+}
+
+// diskFile
+//
+// File changed by the disk-fault chaos
+type diskFile struct {
+	path string
+
+	// Size of the truncated file, or number of corrupted bytes
+	size int64
+}
+
+// chaosDisk
+//
+// Disk-fault chaos defined by ChaosDisk*()
+type chaosDisk struct {
+	// Folders, inside the container, filled until the disk is full
+	fill []string
+
+	// Folders, inside the container, mounted as read only. Empty list with readOnlyEnabled for all mounts
+	readOnly        []string
+	readOnlyEnabled bool
+
+	// Folders, inside the container, where the stressor keeps the disk busy
+	latency []string
+
+	corrupt  []diskFile
+	truncate []diskFile
+
+	// Maximum number of copies with disk fault at the same time. Zero for no limit
+	maxFaulted int
+}
+
+// kinds
+//
+// List of the disk faults defined
+func (el chaosDisk) kinds() (kinds []string) {
+	if len(el.fill) != 0 {
+		kinds = append(kinds, kDiskFill)
+	}
+
+	if el.readOnlyEnabled {
+		kinds = append(kinds, kDiskReadOnly)
+	}
+
+	if len(el.latency) != 0 {
+		kinds = append(kinds, kDiskLatency)
+	}
+
+	if len(el.corrupt) != 0 {
+		kinds = append(kinds, kDiskCorrupt)
+	}
+
+	if len(el.truncate) != 0 {
+		kinds = append(kinds, kDiskTruncate)
+	}
+
+	return
+}
+
+// stressCommand
+//
+// Command line of the stressor for the disk fault. The target is chosen by random
+func (el chaosDisk) stressCommand(kind string, window time.Duration, random *rand.Rand) (command []string) {
+	command = []string{"/" + stressor.KBinaryName}
+	switch kind {
+	case kDiskFill:
+		var folder = el.fill[random.Intn(len(el.fill))]
+		command = append(command, "-duration", window.String(), "-disk", "-1", "-path", path.Join(folder, "chaos-fill"))
+	case kDiskLatency:
+		var folder = el.latency[random.Intn(len(el.latency))]
+		command = append(command, "-duration", window.String(), "-io", path.Join(folder, "chaos-io"))
+	case kDiskCorrupt:
+		var file = el.corrupt[random.Intn(len(el.corrupt))]
+		command = append(command, "-corrupt", file.path, "-bytes", strconv.FormatInt(file.size, 10))
+	case kDiskTruncate:
+		var file = el.truncate[random.Intn(len(el.truncate))]
+		command = append(command, "-truncate", file.path, "-size", strconv.FormatInt(file.size, 10))
+	}
+
+	return
+}
+
+// readOnlyVolumes
+//
+// Returns a copy of the mounts, with the folders of the list as read only. An empty list changes all mounts, except
+// the coverage folder
+func readOnlyVolumes(volumes []mount.Mount, folders []string) (changed []mount.Mount) {
+	changed = make([]mount.Mount, len(volumes))
+	copy(changed, volumes)
+
+	for k := range changed {
+		if changed[k].Target == kCoverageContainerDir {
+			continue
+		}
+
+		if len(folders) == 0 {
+			changed[k].ReadOnly = true
+			continue
+		}
+
+		for _, folder := range folders {
+			if path.Clean(folder) == path.Clean(changed[k].Target) {
+				changed[k].ReadOnly = true
+			}
+		}
+	}
+
+	return
+}
+
+// ChaosDiskFill
+//
+// English:
+//
+//	During the chaos test, fills the disk of one copy until it is full (ENOSPC) for a time window, and then removes
+//	the file.
+//
+//	 Input:
+//	   containerPath: list of folders, inside the container, one is chosen by random. e.g. /data/db
+//
+//	 Notes:
+//	   * Requires EnableChaos() and the go compiler on the host computer, as ChaosStressDisk();
+//	   * A named volume, or a bind, is filled until the disk of the host computer is full. Use VolumeNamedSize() or
+//	     Tmpfs() for a folder with size limit;
+//	   * The time window is defined by ChaosDiskFault().
+//
+// Português:
+//
+//	Durante o teste de caos, enche o disco de uma cópia até ele ficar cheio (ENOSPC) por uma janela de tempo, e
+//	depois apaga o arquivo.
+//
+//	 Entrada:
+//	   containerPath: lista de pastas, dentro do container, uma é escolhida ao acaso. ex. /data/db
+//
+//	 Notas:
+//	   * Requer EnableChaos() e o compilador go no computador hospedeiro, como ChaosStressDisk();
+//	   * Um volume nomeado, ou um bind, é preenchido até o disco do computador hospedeiro ficar cheio. Use
+//	     VolumeNamedSize() ou Tmpfs() para uma pasta com limite de tamanho;
+//	   * A janela de tempo é definida por ChaosDiskFault().
+func (el *ContainerFromImage) ChaosDiskFill(containerPath ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.disk.fill = append(el.disk.fill, containerPath...)
+	return el
+}
+
+// ChaosDiskReadOnly
+//
+// English:
+//
+//	During the chaos test, mounts the volumes of one copy as read only for a time window.
+//
+//	 Input:
+//	   containerPath: list of folders, inside the container, defined by Volumes(), VolumeNamed() and similar.
+//	     Without values, all volumes
+//
+//	 Notes:
+//	   * Requires EnableChaos();
+//	   * Docker can't change a mount of a running container, so the copy is created again, with the same name,
+//	     network and volumes, at the beginning and at the end of the window. The process restarts and the data
+//	     outside the volumes is lost;
+//	   * The time window is defined by ChaosDiskFault().
+//
+// Português:
+//
+//	Durante o teste de caos, monta os volumes de uma cópia como somente leitura por uma janela de tempo.
+//
+//	 Entrada:
+//	   containerPath: lista de pastas, dentro do container, definidas por Volumes(), VolumeNamed() e similares.
+//	     Sem valores, todos os volumes
+//
+//	 Notas:
+//	   * Requer EnableChaos();
+//	   * O docker não altera a montagem de um container em execução, por isto, a cópia é criada novamente, com o
+//	     mesmo nome, rede e volumes, no início e no fim da janela. O processo reinicia e os dados fora dos volumes
+//	     são perdidos;
+//	   * A janela de tempo é definida por ChaosDiskFault().
+func (el *ContainerFromImage) ChaosDiskReadOnly(containerPath ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.disk.readOnly = append(el.disk.readOnly, containerPath...)
+	el.disk.readOnlyEnabled = true
+	return el
+}
+
+// ChaosDiskLatency
+//
+// English:
+//
+//	During the chaos test, keeps the disk of one copy busy for a time window, writing and synchronizing a file in
+//	loop, so the writes of the project wait longer.
+//
+//	 Input:
+//	   containerPath: list of folders, inside the container, one is chosen by random. e.g. /data/db
+//
+//	 Notes:
+//	   * Requires EnableChaos() and the go compiler on the host computer, as ChaosStressDisk();
+//	   * This is a stand-in for a volume driver with latency: the delay depends on the disk of the host computer and
+//	     affects all containers that use the same disk. Use BlkioDeviceWriteBps() for a fixed limit;
+//	   * The time window is defined by ChaosDiskFault().
+//
+// Português:
+//
+//	Durante o teste de caos, mantém o disco de uma cópia ocupado por uma janela de tempo, escrevendo e sincronizando
+//	um arquivo em laço, assim, as escritas do projeto esperam mais.
+//
+//	 Entrada:
+//	   containerPath: lista de pastas, dentro do container, uma é escolhida ao acaso. ex. /data/db
+//
+//	 Notas:
+//	   * Requer EnableChaos() e o compilador go no computador hospedeiro, como ChaosStressDisk();
+//	   * Isto substitui um driver de volume com latência: o atraso depende do disco do computador hospedeiro e afeta
+//	     todos os containers que usam o mesmo disco. Use BlkioDeviceWriteBps() para um limite fixo;
+//	   * A janela de tempo é definida por ChaosDiskFault().
+func (el *ContainerFromImage) ChaosDiskLatency(containerPath ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.disk.latency = append(el.disk.latency, containerPath...)
+	return el
+}
+
+// ChaosDiskCorrupt
+//
+// English:
+//
+//	During the chaos test, writes random bytes in random positions of a file of one copy, as a partial write.
+//
+//	 Input:
+//	   filePath: file inside the container. e.g. /data/db/journal
+//	   bytes: number of changed bytes
+//
+//	 Notes:
+//	   * Requires EnableChaos() and the go compiler on the host computer, as ChaosStressDisk();
+//	   * When called more than once, one file is chosen by random.
+//
+// Português:
+//
+//	Durante o teste de caos, escreve bytes aleatórios em posições aleatórias de um arquivo de uma cópia, como uma
+//	escrita parcial.
+//
+//	 Entrada:
+//	   filePath: arquivo dentro do container. ex. /data/db/journal
+//	   bytes: quantidade de bytes alterados
+//
+//	 Notas:
+//	   * Requer EnableChaos() e o compilador go no computador hospedeiro, como ChaosStressDisk();
+//	   * Quando chamada mais de uma vez, um arquivo é escolhido ao acaso.
+func (el *ContainerFromImage) ChaosDiskCorrupt(filePath string, bytes int) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.disk.corrupt = append(el.disk.corrupt, diskFile{path: filePath, size: int64(bytes)})
+	return el
+}
+
+// ChaosDiskTruncate
+//
+// English:
+//
+//	During the chaos test, truncates a file of one copy, as an interrupted write.
+//
+//	 Input:
+//	   filePath: file inside the container. e.g. /data/db/journal
+//	   size: size of the file after the truncation, in bytes
+//
+//	 Notes:
+//	   * Requires EnableChaos() and the go compiler on the host computer, as ChaosStressDisk();
+//	   * When called more than once, one file is chosen by random.
+//
+// Português:
+//
+//	Durante o teste de caos, trunca um arquivo de uma cópia, como uma escrita interrompida.
+//
+//	 Entrada:
+//	   filePath: arquivo dentro do container. ex. /data/db/journal
+//	   size: tamanho do arquivo depois de truncado, em bytes
+//
+//	 Notas:
+//	   * Requer EnableChaos() e o compilador go no computador hospedeiro, como ChaosStressDisk();
+//	   * Quando chamada mais de uma vez, um arquivo é escolhido ao acaso.
+func (el *ContainerFromImage) ChaosDiskTruncate(filePath string, size int64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.disk.truncate = append(el.disk.truncate, diskFile{path: filePath, size: size})
+	return el
+}
+
+// ChaosDiskFault
+//
+// English:
+//
+//	Defines the disk-fault chaos, ChaosDisk*().
+//
+//	 Input:
+//	   maxFaulted: maximum number of copies with disk fault at the same time. Zero for no limit
+//	   min, max: time window of the fault (Default: 30s to 90s)
+//
+// Português:
+//
+//	Define o caos de falha de disco, ChaosDisk*().
+//
+//	 Entrada:
+//	   maxFaulted: quantidade máxima de cópias com falha de disco ao mesmo tempo. Zero para sem limite
+//	   min, max: janela de tempo da falha (Padrão: 30s a 90s)
+func (el *ContainerFromImage) ChaosDiskFault(maxFaulted int, min, max time.Duration) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.disk.maxFaulted = maxFaulted
+	el.manager.ChaosConfig.minimumTimeDiskFault = min
+	el.manager.ChaosConfig.maximumTimeDiskFault = max
+	return el
+}
+
+// queueContainerDiskFault
+//
+// Queues one disk fault, chosen by random, and the end of the time window
+func (el *ContainerFromImage) queueContainerDiskFault(iCopy int) {
+	var kinds = el.disk.kinds()
+	var kind = kinds[rand.Intn(len(kinds))]
+	var window = el.selectDuration(el.manager.ChaosConfig.maximumTimeDiskFault, el.manager.ChaosConfig.minimumTimeDiskFault)
+
+	var chaos chaosAction
+	nextTime := time.Now().Add(el.selectDuration(el.manager.ChaosConfig.maximumTimeDelay, el.manager.ChaosConfig.minimumTimeDelay))
+
+	if kind == kDiskReadOnly {
+		chaos = chaosAction{
+			display: "diskReadOnly(" + strings.Join(el.disk.readOnly, ", ") + ")",
+			time:    nextTime,
+			action: func(_ string) (err error) {
+				return el.containerRecreate(iCopy, readOnlyVolumes(el.createArgs[iCopy].volumes, el.disk.readOnly))
+			},
+			id: el.copyId(iCopy),
+		}
+		el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+
+		chaos = chaosAction{
+			display: "diskReadWrite()",
+			time:    nextTime.Add(window),
+			action: func(_ string) (err error) {
+				return el.containerRecreate(iCopy, el.createArgs[iCopy].volumes)
+			},
+			id: el.copyId(iCopy),
+		}
+		el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+		el.manager.Chaos[iCopy].Type = "disk" //todo: const
+		return
+	}
+
+	var command = el.disk.stressCommand(kind, window, el.getRandSeed())
+	chaos = chaosAction{
+		display: "disk" + strings.ToUpper(kind[:1]) + kind[1:] + "(" + strings.Join(command[1:], " ") + ")",
+		time:    nextTime,
+		action: func(_ string) (err error) {
+			return el.containerStress(iCopy, el.copyId(iCopy), command)
+		},
+		id: el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+
+	// the stressor ends by itself, this action only keeps the copy with disk fault until the end of the window
+	chaos = chaosAction{
+		display: "diskEnd(" + kind + ")",
+		time:    nextTime.Add(window),
+		action:  el.chaosDoNotting,
+		id:      el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+	el.manager.Chaos[iCopy].Type = "disk" //todo: const
+}
+
+// containerRecreate
+//
+// Removes the copy and creates it again, with the same arguments of Create(), except the mounts, and starts it
+func (el *ContainerFromImage) containerRecreate(iCopy int, volumes []mount.Mount) (err error) {
+	var args = el.createArgs[iCopy]
+	var dockerSys = el.manager.DockerSys[iCopy]
+	var id = el.copyId(iCopy)
+
+	// the stats and the fail flag threads skip the copy until it is running again
+	el.copyRecreatingSet(iCopy, true)
+	defer el.copyRecreatingSet(iCopy, false)
+
+	if err = dockerSys.ContainerStop(id); err != nil {
+		return
+	}
+
+	if err = dockerSys.ContainerRemove(id, false, false, true); err != nil {
+		return
+	}
+
+	var config = args.config
+	if id, _, err = dockerSys.ContainerCreateWithConfig(&config, args.name, args.restartPolicy, args.ports, volumes, args.network); err != nil {
+		return
+	}
+
+	el.copyIdSet(iCopy, id)
+	delete(el.pressure.stressCopied, iCopy)
+
+	if err = el.networkConnect(iCopy, id, args.extraNetworks); err != nil {
+		return
+	}
+
+	return dockerSys.ContainerStart(id)
+}
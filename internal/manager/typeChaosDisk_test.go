@@ -0,0 +1,197 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/helmutkemper/chaos/internal/builder"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChaosDisk_stressCommand(t *testing.T) {
+	var disk chaosDisk
+	if len(disk.kinds()) != 0 {
+		t.Fatalf("no disk fault must be defined")
+	}
+
+	disk.fill = []string{"/data/"}
+	disk.truncate = []diskFile{{path: "/data/journal", size: 10}}
+
+	var random = rand.New(rand.NewSource(1))
+	var command = disk.stressCommand(kDiskFill, time.Minute, random)
+	if len(command) != 7 || command[4] != "-1" || command[6] != "/data/chaos-fill" {
+		t.Errorf("fill: %v", command)
+	}
+
+	command = disk.stressCommand(kDiskTruncate, time.Minute, random)
+	if len(command) != 5 || command[1] != "-truncate" || command[2] != "/data/journal" || command[4] != "10" {
+		t.Errorf("truncate: %v", command)
+	}
+}
+
+func TestReadOnlyVolumes(t *testing.T) {
+	var volumes = []mount.Mount{
+		{Target: "/data/db"},
+		{Target: "/config"},
+		{Target: kCoverageContainerDir},
+	}
+
+	var changed = readOnlyVolumes(volumes, []string{"/data/db/"})
+	if !changed[0].ReadOnly || changed[1].ReadOnly || changed[2].ReadOnly {
+		t.Errorf("only the folder of the list must be read only: %+v", changed)
+	}
+
+	if volumes[0].ReadOnly {
+		t.Errorf("the original list must not change")
+	}
+
+	changed = readOnlyVolumes(volumes, nil)
+	if !changed[0].ReadOnly || !changed[1].ReadOnly || changed[2].ReadOnly {
+		t.Errorf("all folders, except the coverage, must be read only: %+v", changed)
+	}
+}
+
+// dockerFake
+//
+// Docker api with the calls of the stats and logs threads and of containerRecreate()
+type dockerFake struct {
+	mutex   sync.Mutex
+	alive   map[string]bool
+	created int
+}
+
+func (el *dockerFake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	w.Header().Set("Api-Version", "1.41")
+	w.Header().Set("Content-Type", "application/json")
+
+	// e.g. /v1.41/containers/copy-0/stats
+	var parts = strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 0 && strings.HasPrefix(parts[0], "v1.") {
+		parts = parts[1:]
+	}
+
+	switch {
+	case len(parts) == 1 && parts[0] == "_ping":
+		_, _ = w.Write([]byte("OK"))
+		return
+	case len(parts) == 2 && parts[1] == "create":
+		el.created += 1
+		var id = fmt.Sprintf("copy-%v", el.created)
+		el.alive[id] = true
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"Id": id, "Warnings": []string{}})
+		return
+	case len(parts) < 2 || parts[0] != "containers":
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var id = parts[1]
+	if !el.alive[id] {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "No such container: " + id})
+		return
+	}
+
+	if len(parts) == 2 && r.Method == http.MethodDelete {
+		delete(el.alive, id)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	switch parts[len(parts)-1] {
+	case "stats":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"read": time.Now(), "memory_stats": map[string]int{"limit": 1}})
+	case "json":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"Id": id, "State": map[string]interface{}{"Running": true, "Status": "running"}})
+	case "logs":
+		w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// removeAll
+//
+// Removes all containers, so the logs thread ends
+func (el *dockerFake) removeAll() {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	el.alive = map[string]bool{}
+}
+
+func TestContainerFromImage_containerRecreate(t *testing.T) {
+	monitorErrReset(t)
+
+	var errorCh = ErrorCh
+	ErrorCh = make(chan error, 100)
+	t.Cleanup(func() {
+		ErrorCh = errorCh
+	})
+
+	var daemon = &dockerFake{alive: map[string]bool{"copy-0": true}}
+	var server = httptest.NewServer(daemon)
+	defer server.Close()
+	t.Setenv("DOCKER_HOST", "tcp://"+server.Listener.Addr().String())
+
+	var dockerSys = new(builder.DockerSystem)
+	if err := dockerSys.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the version of the api is negotiated by the first call, made by Create() before the threads
+	if _, err := dockerSys.ContainerInspect("copy-0"); err != nil {
+		t.Fatal(err)
+	}
+
+	var el = new(ContainerFromImage)
+	el.containerName = "delete_recreate"
+	el.copies = 1
+	el.manager = &Manager{Id: []string{"copy-0"}, DockerSys: []*builder.DockerSystem{dockerSys}}
+	el.createArgs = []containerCreateArgs{{config: dockerContainer.Config{Image: "app"}, name: "delete_recreate_0"}}
+	el.failLogsLastSize = make([]int, 1)
+	el.csvPath = t.TempDir()
+	el.logsPath = t.TempDir()
+	el.statsInterval = time.Millisecond
+
+	// the stats and logs threads read the id while the chaos thread creates the copy again
+	el.statsThread()
+	el.logsThread()
+	for i := 0; i != 20; i += 1 {
+		if err := el.containerRecreate(0, nil); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	el.manager.TickerStats.Stop()
+	time.Sleep(50 * time.Millisecond)
+	daemon.removeAll()
+
+	if id := el.copyId(0); id != "copy-20" {
+		t.Errorf("unexpected id: %v", id)
+	}
+
+	// the samples of the copy being created again are skipped
+	if monitor.Err {
+		t.Errorf("the monitor error flag must not be set")
+	}
+
+	select {
+	case err := <-ErrorCh:
+		t.Errorf("unexpected error: %v", err)
+	default:
+	}
+}
@@ -0,0 +1,289 @@
+package manager
+
+import (
+	"fmt"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"github.com/helmutkemper/chaos/internal/stressor"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	kDnsNxDomain = "nxdomain"
+	kDnsServFail = "servfail"
+	kDnsTimeout  = "timeout"
+	kDnsWrong    = "wrong"
+)
+
+// kDnsStartTimeout is the maximum time for the dns stand-in to start inside the copy
+const kDnsStartTimeout = 10 * time.Second
+
+// kDnsRestoreTimeout is the maximum time waiting for the dns stand-in to restore /etc/resolv.conf by itself
+const kDnsRestoreTimeout = 10 * time.Second
+
+// chaosDns
+//
+// DNS failure chaos defined by ChaosDnsFailure(), ChaosDnsTimeout(), ChaosDnsWrongAddress() and ChaosDns()
+type chaosDns struct {
+	// Failure answered as nxdomain and servfail
+	failure bool
+
+	// Failure answered with silence
+	timeout bool
+
+	// Address answered by the failure wrong address
+	wrongAddress string
+
+	// Names affected by the failure. Empty for all names
+	names []string
+
+	// Maximum number of copies with dns failure at the same time. Zero for no limit
+	maxFaulted int
+}
+
+// kinds
+//
+// List of the dns failures defined
+func (el chaosDns) kinds() (kinds []string) {
+	if el.failure {
+		kinds = append(kinds, kDnsNxDomain, kDnsServFail)
+	}
+
+	if el.timeout {
+		kinds = append(kinds, kDnsTimeout)
+	}
+
+	if el.wrongAddress != "" {
+		kinds = append(kinds, kDnsWrong)
+	}
+
+	return
+}
+
+// stressCommand
+//
+// Command line of the stressor for the dns failure
+func (el chaosDns) stressCommand(kind string, window time.Duration) (command []string) {
+	command = []string{"/" + stressor.KBinaryName, "-duration", window.String(), "-dns", kind}
+	if kind == kDnsWrong {
+		command = append(command, "-address", el.wrongAddress)
+	}
+
+	if len(el.names) != 0 {
+		command = append(command, "-names", strings.Join(el.names, ","))
+	}
+
+	return
+}
+
+// ChaosDnsFailure
+//
+// English:
+//
+//	During the chaos test, the name resolution of one copy fails, as nxdomain or servfail, for a time window.
+//
+//	 Notes:
+//	   * Requires EnableChaos(), the go compiler on the host computer and the network created by NetworkCreate();
+//	   * The names affected and the time window are defined by ChaosDns().
+//
+// Português:
+//
+//	Durante o teste de caos, a resolução de nomes de uma cópia falha, como nxdomain ou servfail, por uma janela de
+//	tempo.
+//
+//	 Notas:
+//	   * Requer EnableChaos(), o compilador go no computador hospedeiro e a rede criada por NetworkCreate();
+//	   * Os nomes afetados e a janela de tempo são definidos por ChaosDns().
+func (el *ContainerFromImage) ChaosDnsFailure() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.dns.failure = true
+	return el
+}
+
+// ChaosDnsTimeout
+//
+// English:
+//
+//	During the chaos test, the name resolution of one copy doesn't answer, so the client waits until its timeout, for a
+//	time window.
+//
+//	 Notes:
+//	   * Requires EnableChaos(), the go compiler on the host computer and the network created by NetworkCreate();
+//	   * The names affected and the time window are defined by ChaosDns().
+//
+// Português:
+//
+//	Durante o teste de caos, a resolução de nomes de uma cópia não responde, assim, o cliente espera até o seu tempo
+//	limite, por uma janela de tempo.
+//
+//	 Notas:
+//	   * Requer EnableChaos(), o compilador go no computador hospedeiro e a rede criada por NetworkCreate();
+//	   * Os nomes afetados e a janela de tempo são definidos por ChaosDns().
+func (el *ContainerFromImage) ChaosDnsTimeout() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.dns.timeout = true
+	return el
+}
+
+// ChaosDnsWrongAddress
+//
+// English:
+//
+//	During the chaos test, the name resolution of one copy returns a wrong address, for a time window.
+//
+//	 Input:
+//	   address: IPv4 or IPv6 address answered, e.g. an address of the network without container
+//
+//	 Notes:
+//	   * Requires EnableChaos(), the go compiler on the host computer and the network created by NetworkCreate();
+//	   * The address is answered with time to live zero, so the client resolves the name again after the window;
+//	   * The names affected and the time window are defined by ChaosDns().
+//
+// Português:
+//
+//	Durante o teste de caos, a resolução de nomes de uma cópia retorna um endereço errado, por uma janela de tempo.
+//
+//	 Entrada:
+//	   address: endereço IPv4 ou IPv6 respondido, ex. um endereço da rede sem container
+//
+//	 Notas:
+//	   * Requer EnableChaos(), o compilador go no computador hospedeiro e a rede criada por NetworkCreate();
+//	   * O endereço é respondido com tempo de vida zero, assim, o cliente resolve o nome de novo depois da janela;
+//	   * Os nomes afetados e a janela de tempo são definidos por ChaosDns().
+func (el *ContainerFromImage) ChaosDnsWrongAddress(address string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	if net.ParseIP(address) == nil {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("containerFromImage.ChaosDnsWrongAddress().error: invalid address: %v", address)
+		return el
+	}
+
+	el.dns.wrongAddress = address
+	return el
+}
+
+// ChaosDns
+//
+// English:
+//
+//	Defines the dns failure chaos, ChaosDnsFailure(), ChaosDnsTimeout() and ChaosDnsWrongAddress().
+//
+//	 Input:
+//	   maxFaulted: maximum number of copies with dns failure at the same time. Zero for no limit
+//	   min, max: time window of the failure (Default: 30s to 90s)
+//	   names: names affected by the failure, including their subdomains, e.g. the container names of the peers. Empty
+//	     for all names
+//
+//	 Notes:
+//	   * Docker resolves the container names in the embedded resolver, 127.0.0.11, before any server defined by Dns(),
+//	     so a resolver outside the copy can't change the answer for the peers. During the window, the stressor, copied
+//	     to /chaos-stress, runs as root as a dns stand-in on 127.0.0.1:53 and replaces the name servers of
+//	     /etc/resolv.conf. The names not affected are forwarded to 127.0.0.11;
+//	   * The stand-in answers UDP and TCP queries. A failure to start the stand-in is returned as an error of the test;
+//	   * /etc/resolv.conf is restored at the end of the window, even if the stand-in was killed;
+//	   * Clients with their own resolver, or that read /etc/resolv.conf only on start, are not affected.
+//
+// Português:
+//
+//	Define o caos de falha de dns, ChaosDnsFailure(), ChaosDnsTimeout() e ChaosDnsWrongAddress().
+//
+//	 Entrada:
+//	   maxFaulted: quantidade máxima de cópias com falha de dns ao mesmo tempo. Zero para sem limite
+//	   min, max: janela de tempo da falha (Padrão: 30s a 90s)
+//	   names: nomes afetados pela falha, incluindo os seus subdomínios, ex. os nomes dos containers vizinhos. Vazio
+//	     para todos os nomes
+//
+//	 Notas:
+//	   * O docker resolve os nomes dos containers no resolvedor embutido, 127.0.0.11, antes de qualquer servidor
+//	     definido por Dns(), assim, um resolvedor fora da cópia não consegue alterar a resposta para os vizinhos.
+//	     Durante a janela, o estressor, copiado para /chaos-stress, roda como root como um dns substituto em
+//	     127.0.0.1:53 e troca os servidores de nomes de /etc/resolv.conf. Os nomes não afetados são encaminhados para
+//	     127.0.0.11;
+//	   * O substituto responde consultas UDP e TCP. Uma falha no início do substituto é retornada como um erro do teste;
+//	   * /etc/resolv.conf é restaurado no fim da janela, mesmo se o substituto foi morto;
+//	   * Clientes com resolvedor próprio, ou que leem /etc/resolv.conf apenas no início, não são afetados.
+func (el *ContainerFromImage) ChaosDns(maxFaulted int, min, max time.Duration, names ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.dns.maxFaulted = maxFaulted
+	el.dns.names = names
+	el.manager.ChaosConfig.minimumTimeDnsFault = min
+	el.manager.ChaosConfig.maximumTimeDnsFault = max
+	return el
+}
+
+// queueContainerDnsFault
+//
+// Runs the dns stand-in inside the copy, with the failure chosen by random, during the time window
+func (el *ContainerFromImage) queueContainerDnsFault(iCopy int) {
+	var kinds = el.dns.kinds()
+	var kind = kinds[rand.Intn(len(kinds))]
+	var window = el.selectDuration(el.manager.ChaosConfig.maximumTimeDnsFault, el.manager.ChaosConfig.minimumTimeDnsFault)
+	var command = el.dns.stressCommand(kind, window)
+
+	var chaos chaosAction
+	nextTime := time.Now().Add(el.selectDuration(el.manager.ChaosConfig.maximumTimeDelay, el.manager.ChaosConfig.minimumTimeDelay))
+	chaos = chaosAction{
+		display: "dns(" + strings.Join(command[3:], " ") + ")",
+		time:    nextTime,
+		action: func(id string) (err error) {
+			if err = el.stressCopy(iCopy, id); err != nil {
+				return
+			}
+
+			// port 53 and /etc/resolv.conf require root
+			if err = el.manager.DockerSys[iCopy].ContainerExecDetachedWithUser(id, "root", command); err != nil {
+				return
+			}
+
+			return el.dnsWait(iCopy, id)
+		},
+		id: el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+
+	// the stand-in restores /etc/resolv.conf and ends by itself, this action restores it if the stand-in was killed
+	chaos = chaosAction{
+		display: "dnsEnd(" + kind + ")",
+		time:    nextTime.Add(window),
+		action: func(id string) (err error) {
+			var restore = []string{"/" + stressor.KBinaryName, "-dns-restore", kDnsRestoreTimeout.String()}
+			return el.manager.DockerSys[iCopy].ContainerExecDetachedWithUser(id, "root", restore)
+		},
+		id: el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+	el.manager.Chaos[iCopy].Type = "dns" //todo: const
+}
+
+// dnsWait
+//
+// Waits for the start of the dns stand-in, executed by a detached exec, and returns its error
+func (el *ContainerFromImage) dnsWait(iCopy int, id string) (err error) {
+	var exitCode int
+	var stdError []byte
+	var command = []string{"/" + stressor.KBinaryName, "-dns-wait", kDnsStartTimeout.String()}
+	exitCode, _, stdError, err = el.manager.DockerSys[iCopy].ContainerExecOutput(id, command, kDnsStartTimeout+5*time.Second)
+	if err != nil {
+		return
+	}
+
+	if exitCode != 0 {
+		return fmt.Errorf("the dns stand-in didn't start: %v", strings.TrimSpace(string(stdError)))
+	}
+
+	return
+}
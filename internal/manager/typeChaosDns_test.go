@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChaosDns_stressCommand(t *testing.T) {
+	var dns chaosDns
+	if len(dns.kinds()) != 0 {
+		t.Errorf("without failure, the dns chaos must be disabled")
+	}
+
+	dns = chaosDns{
+		failure:      true,
+		timeout:      true,
+		wrongAddress: "10.0.0.250",
+		names:        []string{"delete_db_0", "delete_cache_0"},
+	}
+
+	if kinds := dns.kinds(); !reflect.DeepEqual(kinds, []string{kDnsNxDomain, kDnsServFail, kDnsTimeout, kDnsWrong}) {
+		t.Errorf("kinds: %v", kinds)
+	}
+
+	var command = dns.stressCommand(kDnsWrong, 30*time.Second)
+	var expected = []string{"/chaos-stress", "-duration", "30s", "-dns", "wrong", "-address", "10.0.0.250", "-names", "delete_db_0,delete_cache_0"}
+	if !reflect.DeepEqual(command, expected) {
+		t.Errorf("command: %v", command)
+	}
+
+	dns.names = nil
+	command = dns.stressCommand(kDnsTimeout, time.Minute)
+	expected = []string{"/chaos-stress", "-duration", "1m0s", "-dns", "timeout"}
+	if !reflect.DeepEqual(command, expected) {
+		t.Errorf("command: %v", command)
+	}
+}
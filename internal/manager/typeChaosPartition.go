@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"math/rand"
+	"time"
+)
+
+// chaosPartition
+//
+// Network partition chaos defined by ChaosNetworkPartition()
+type chaosPartition struct {
+	enabled bool
+
+	// Networks that can be partitioned. Empty for all networks of the copy
+	networks []string
+
+	// Maximum number of copies partitioned at the same time. Zero for no limit
+	maxPartitioned int
+}
+
+// targets
+//
+// Connections of the copy that can be partitioned
+func (el chaosPartition) targets(endpoints []containerNetworkEndpoint) (targets []containerNetworkEndpoint) {
+	if len(el.networks) == 0 {
+		return endpoints
+	}
+
+	for _, endpoint := range endpoints {
+		for _, name := range el.networks {
+			if endpoint.networkName == name || endpoint.networkName == "delete_"+name {
+				targets = append(targets, endpoint)
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// ChaosNetworkPartition
+//
+// English:
+//
+//	During the chaos test, disconnects one copy from one of its networks for a time window, while the other networks
+//	stay up, and then connects it again with the same addresses.
+//
+//	 Input:
+//	   maxPartitioned: maximum number of copies partitioned at the same time. Zero for no limit
+//	   min, max: time window of the partition (Default: 30s to 90s)
+//	   networks: networks that can be partitioned, with or without the prefix `delete_`. Empty for all networks of the
+//	     copy
+//
+//	 Notes:
+//	   * Requires EnableChaos();
+//	   * The networks of the copy are defined by Networks().
+//
+// Português:
+//
+//	Durante o teste de caos, desconecta uma cópia de uma das suas redes por uma janela de tempo, enquanto as demais
+//	redes continuam de pé, e depois a conecta de novo com os mesmos endereços.
+//
+//	 Entrada:
+//	   maxPartitioned: quantidade máxima de cópias particionadas ao mesmo tempo. Zero para sem limite
+//	   min, max: janela de tempo da partição (Padrão: 30s a 90s)
+//	   networks: redes que podem ser particionadas, com ou sem o prefixo `delete_`. Vazio para todas as redes da cópia
+//
+//	 Notas:
+//	   * Requer EnableChaos();
+//	   * As redes da cópia são definidas por Networks().
+func (el *ContainerFromImage) ChaosNetworkPartition(maxPartitioned int, min, max time.Duration, networks ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.partition.enabled = true
+	el.partition.maxPartitioned = maxPartitioned
+	el.partition.networks = networks
+	el.manager.ChaosConfig.minimumTimePartition = min
+	el.manager.ChaosConfig.maximumTimePartition = max
+	return el
+}
+
+// queueContainerPartition
+//
+// Disconnects the copy from one network, chosen by random, and connects it again at the end of the time window
+func (el *ContainerFromImage) queueContainerPartition(iCopy int) {
+	var targets = el.partition.targets(el.copyEndpoints(iCopy))
+	if len(targets) == 0 {
+		el.queueContainerDoNotting(iCopy)
+		return
+	}
+
+	var target = targets[rand.Intn(len(targets))]
+
+	var chaos chaosAction
+	nextTime := time.Now().Add(el.selectDuration(el.manager.ChaosConfig.maximumTimeDelay, el.manager.ChaosConfig.minimumTimeDelay))
+	chaos = chaosAction{
+		display: "partition(" + target.networkName + ")",
+		time:    nextTime,
+		action: func(id string) (err error) {
+			return el.manager.DockerSys[iCopy].NetworkDisconnect(target.networkID, id, true)
+		},
+		id: el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+
+	nextTime = nextTime.Add(el.selectDuration(el.manager.ChaosConfig.maximumTimePartition, el.manager.ChaosConfig.minimumTimePartition))
+	chaos = chaosAction{
+		display: "reconnect(" + target.networkName + ")",
+		time:    nextTime,
+		action: func(id string) (err error) {
+			return el.manager.DockerSys[iCopy].NetworkConnect(target.networkID, id, target.settings)
+		},
+		id: el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+	el.manager.Chaos[iCopy].Type = "partition" //todo: const
+}
@@ -0,0 +1,24 @@
+package manager
+
+import (
+	"testing"
+)
+
+func TestChaosPartition_targets(t *testing.T) {
+	var endpoints = []containerNetworkEndpoint{
+		{networkName: "delete_frontend"},
+		{networkName: "delete_backend"},
+		{networkName: "delete_replication"},
+	}
+
+	var partition chaosPartition
+	if targets := partition.targets(endpoints); len(targets) != 3 {
+		t.Errorf("without networks, all networks must be partitioned: %v", targets)
+	}
+
+	partition.networks = []string{"replication", "delete_backend"}
+	var targets = partition.targets(endpoints)
+	if len(targets) != 2 || targets[0].networkName != "delete_backend" || targets[1].networkName != "delete_replication" {
+		t.Errorf("unexpected targets: %v", targets)
+	}
+}
@@ -0,0 +1,608 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"github.com/helmutkemper/chaos/internal/stressor"
+	"log"
+	"math/rand"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// kChaosCpuPeriod is the CFS period used by the cpu throttle, in microseconds (docker default)
+	kChaosCpuPeriod = 100000
+
+	// kChaosCpuQuotaMinimum is the minimum cpu quota accepted by docker, in microseconds
+	kChaosCpuQuotaMinimum = 1000
+
+	// kChaosBlkioWeightDefault is the block IO weight of a container without weight defined
+	kChaosBlkioWeightDefault = 500
+)
+
+const (
+	kPressureCpu    = "cpu"
+	kPressureMemory = "memory"
+	kPressureBlkio  = "blkio"
+	kPressureDisk   = "disk"
+)
+
+// chaosPressure
+//
+// Resource-pressure chaos defined by ChaosThrottle*(), ChaosStress*() and ChaosPressure()
+type chaosPressure struct {
+	// Number of cpus left to the container, e.g. 0.1 is 10% of one cpu
+	cpuMin float64
+	cpuMax float64
+
+	// Memory limit in bytes
+	memoryMin int64
+	memoryMax int64
+
+	// Block IO weight, between 10 and 1000
+	blkioWeightMin uint16
+	blkioWeightMax uint16
+
+	// Number of cpus burned by the stressor
+	stressCpu int
+
+	// Bytes of memory filled by the stressor
+	stressMemory int64
+
+	// Bytes of disk filled by the stressor, inside the stressDiskPath folder
+	stressDisk     int64
+	stressDiskPath string
+
+	// Maximum number of copies under pressure at the same time. Zero for no limit
+	maxPressured int
+
+	// Stressor archive, in the format used by ContainerCopyTo(), where key is the platform
+	stressArchive map[string][]byte
+
+	// Flag indicating the stressor was copied into the container, where key is index from Create(copies)
+	stressCopied map[int]bool
+}
+
+// throttleKinds
+//
+// List of the resources to be throttled
+func (el chaosPressure) throttleKinds() (kinds []string) {
+	if el.cpuMax > 0 {
+		kinds = append(kinds, kPressureCpu)
+	}
+
+	if el.memoryMax > 0 {
+		kinds = append(kinds, kPressureMemory)
+	}
+
+	if el.blkioWeightMax > 0 {
+		kinds = append(kinds, kPressureBlkio)
+	}
+
+	return
+}
+
+// stressKinds
+//
+// List of the resources to be stressed
+func (el chaosPressure) stressKinds() (kinds []string) {
+	if el.stressCpu > 0 {
+		kinds = append(kinds, kPressureCpu)
+	}
+
+	if el.stressMemory > 0 {
+		kinds = append(kinds, kPressureMemory)
+	}
+
+	if el.stressDisk > 0 {
+		kinds = append(kinds, kPressureDisk)
+	}
+
+	return
+}
+
+// throttleResources
+//
+// Resources squeezed by the throttle chaos. The value is chosen between minimum and maximum by random, from 0.0 to 1.0
+func (el chaosPressure) throttleResources(kind string, random float64) (resources dockerContainer.Resources, display string) {
+	switch kind {
+	case kPressureCpu:
+		var cpus = el.cpuMin + (el.cpuMax-el.cpuMin)*random
+		resources.CPUPeriod = kChaosCpuPeriod
+		resources.CPUQuota = int64(cpus * kChaosCpuPeriod)
+		if resources.CPUQuota < kChaosCpuQuotaMinimum {
+			resources.CPUQuota = kChaosCpuQuotaMinimum
+		}
+		display = fmt.Sprintf("throttle(cpus: %.2f)", float64(resources.CPUQuota)/kChaosCpuPeriod)
+
+	case kPressureMemory:
+		// swap equal to memory disables the swap, so the container feels the pressure
+		resources.Memory = el.memoryMin + int64(float64(el.memoryMax-el.memoryMin)*random)
+		resources.MemorySwap = resources.Memory
+		display = fmt.Sprintf("throttle(memory: %v)", resources.Memory)
+
+	case kPressureBlkio:
+		resources.BlkioWeight = el.blkioWeightMin + uint16(float64(el.blkioWeightMax-el.blkioWeightMin)*random)
+		display = fmt.Sprintf("throttle(blkio weight: %v)", resources.BlkioWeight)
+	}
+
+	return
+}
+
+// restoreResources
+//
+// Resources defined on creation of the container, used to end the throttle chaos. Docker doesn't change zero values,
+// so the resources without limit receive the value equivalent to no limit
+//
+//	Input:
+//	  kind: throttled resource
+//	  base: resources defined on creation of the container
+//	  memoryTotal: memory of the host, used as limit when the container has no memory limit
+func restoreResources(kind string, base dockerContainer.Resources, memoryTotal int64) (resources dockerContainer.Resources) {
+	switch kind {
+	case kPressureCpu:
+		resources.CPUPeriod = base.CPUPeriod
+		if resources.CPUPeriod == 0 {
+			resources.CPUPeriod = kChaosCpuPeriod
+		}
+
+		resources.CPUQuota = base.CPUQuota
+		if resources.CPUQuota == 0 {
+			resources.CPUQuota = -1
+		}
+
+	case kPressureMemory:
+		resources.Memory = base.Memory
+		resources.MemorySwap = base.MemorySwap
+		if resources.Memory == 0 {
+			resources.Memory = memoryTotal
+			resources.MemorySwap = -1
+		} else if resources.MemorySwap == 0 {
+			// docker default: swap plus memory is double of the memory
+			resources.MemorySwap = 2 * resources.Memory
+		}
+
+	case kPressureBlkio:
+		resources.BlkioWeight = base.BlkioWeight
+		if resources.BlkioWeight == 0 {
+			resources.BlkioWeight = kChaosBlkioWeightDefault
+		}
+	}
+
+	return
+}
+
+// stressCommand
+//
+// Command line of the stressor
+func (el chaosPressure) stressCommand(kind string, window time.Duration) (command []string) {
+	command = []string{"/" + stressor.KBinaryName, "-duration", window.String()}
+	switch kind {
+	case kPressureCpu:
+		command = append(command, "-cpu", strconv.FormatInt(int64(el.stressCpu), 10))
+	case kPressureMemory:
+		command = append(command, "-memory", strconv.FormatInt(el.stressMemory, 10))
+	case kPressureDisk:
+		// the subfolder keeps the data of the container safe, as the disk fill of ChaosDiskFill()
+		command = append(command, "-disk", strconv.FormatInt(el.stressDisk, 10), "-path", path.Join(el.stressDiskPath, "chaos-stress"))
+	}
+
+	return
+}
+
+// ChaosThrottleCpu
+//
+// English:
+//
+//	During the chaos test, squeezes the cpu quota of one copy for a time window and then restores it.
+//
+//	 Input:
+//	   min, max: number of cpus left to the container, e.g. 0.05 and 0.25 (5% to 25% of one cpu)
+//
+//	 Notes:
+//	   * Requires EnableChaos();
+//	   * The time window is defined by ChaosPressure().
+//
+// Português:
+//
+//	Durante o teste de caos, aperta a cota de cpu de uma cópia por uma janela de tempo e depois a restaura.
+//
+//	 Entrada:
+//	   min, max: quantidade de cpus deixada para o container, ex. 0.05 e 0.25 (5% a 25% de uma cpu)
+//
+//	 Notas:
+//	   * Requer EnableChaos();
+//	   * A janela de tempo é definida por ChaosPressure().
+func (el *ContainerFromImage) ChaosThrottleCpu(min, max float64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.pressure.cpuMin = min
+	el.pressure.cpuMax = max
+	return el
+}
+
+// ChaosThrottleMemory
+//
+// English:
+//
+//	During the chaos test, squeezes the memory limit of one copy, without swap, for a time window and then restores
+//	it.
+//
+//	 Input:
+//	   min, max: memory limit in bytes. The minimum allowed by docker is 6 * KMegaByte
+//
+//	 Notes:
+//	   * Requires EnableChaos();
+//	   * A limit below the memory in use can make the kernel kill the process (OOM);
+//	   * The time window is defined by ChaosPressure().
+//
+// Português:
+//
+//	Durante o teste de caos, aperta o limite de memória de uma cópia, sem swap, por uma janela de tempo e depois o
+//	restaura.
+//
+//	 Entrada:
+//	   min, max: limite de memória em bytes. O mínimo permitido pelo docker é 6 * KMegaByte
+//
+//	 Notas:
+//	   * Requer EnableChaos();
+//	   * Um limite abaixo da memória em uso pode fazer o kernel matar o processo (OOM);
+//	   * A janela de tempo é definida por ChaosPressure().
+func (el *ContainerFromImage) ChaosThrottleMemory(min, max int64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.pressure.memoryMin = min
+	el.pressure.memoryMax = max
+	return el
+}
+
+// ChaosThrottleBlkio
+//
+// English:
+//
+//	During the chaos test, reduces the block IO weight of one copy for a time window and then restores it.
+//
+//	 Input:
+//	   min, max: block IO weight, between 10 and 1000
+//
+//	 Notes:
+//	   * Requires EnableChaos();
+//	   * Docker only changes the weight of a running container. The limits by device, BlkioDeviceReadBps() and
+//	     similar, are defined only on creation;
+//	   * The time window is defined by ChaosPressure().
+//
+// Português:
+//
+//	Durante o teste de caos, reduz o peso do block IO de uma cópia por uma janela de tempo e depois o restaura.
+//
+//	 Entrada:
+//	   min, max: peso do block IO, entre 10 e 1000
+//
+//	 Notas:
+//	   * Requer EnableChaos();
+//	   * O docker só altera o peso de um container em execução. Os limites por dispositivo, BlkioDeviceReadBps() e
+//	     similares, são definidos apenas na criação;
+//	   * A janela de tempo é definida por ChaosPressure().
+func (el *ContainerFromImage) ChaosThrottleBlkio(min, max uint16) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.pressure.blkioWeightMin = min
+	el.pressure.blkioWeightMax = max
+	return el
+}
+
+// ChaosStressCpu
+//
+// English:
+//
+//	During the chaos test, burns cpus inside one copy for a time window, as a noisy neighbour.
+//
+//	 Input:
+//	   cpus: number of cpus to burn
+//
+//	 Notes:
+//	   * Requires EnableChaos() and the go compiler on the host computer. The stressor is compiled for the platform of
+//	     the container and copied to /chaos-stress;
+//	   * The time window is defined by ChaosPressure().
+//
+// Português:
+//
+//	Durante o teste de caos, queima cpus dentro de uma cópia por uma janela de tempo, como um vizinho barulhento.
+//
+//	 Entrada:
+//	   cpus: quantidade de cpus a queimar
+//
+//	 Notas:
+//	   * Requer EnableChaos() e o compilador go no computador hospedeiro. O estressor é compilado para a plataforma do
+//	     container e copiado para /chaos-stress;
+//	   * A janela de tempo é definida por ChaosPressure().
+func (el *ContainerFromImage) ChaosStressCpu(cpus int) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.pressure.stressCpu = cpus
+	return el
+}
+
+// ChaosStressMemory
+//
+// English:
+//
+//	During the chaos test, fills the memory inside one copy for a time window, as a noisy neighbour.
+//
+//	 Input:
+//	   size: bytes of memory to fill. Use value * KKiloByte, value * KMegaByte and value * KGigaByte
+//
+//	 Notes:
+//	   * Requires EnableChaos() and the go compiler on the host computer. The stressor is compiled for the platform of
+//	     the container and copied to /chaos-stress;
+//	   * The time window is defined by ChaosPressure().
+//
+// Português:
+//
+//	Durante o teste de caos, enche a memória dentro de uma cópia por uma janela de tempo, como um vizinho barulhento.
+//
+//	 Entrada:
+//	   size: bytes de memória a encher. Use value * KKiloByte, value * KMegaByte e value * KGigaByte
+//
+//	 Notas:
+//	   * Requer EnableChaos() e o compilador go no computador hospedeiro. O estressor é compilado para a plataforma do
+//	     container e copiado para /chaos-stress;
+//	   * A janela de tempo é definida por ChaosPressure().
+func (el *ContainerFromImage) ChaosStressMemory(size int64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.pressure.stressMemory = size
+	return el
+}
+
+// ChaosStressDisk
+//
+// English:
+//
+//	During the chaos test, fills the disk inside one copy for a time window, as a noisy neighbour.
+//
+//	 Input:
+//	   path: folder, inside the container, of the disk filled. e.g. /data/db
+//	   size: bytes of disk to fill. Use value * KKiloByte, value * KMegaByte and value * KGigaByte
+//
+//	 Notes:
+//	   * Requires EnableChaos() and the go compiler on the host computer. The stressor is compiled for the platform of
+//	     the container and copied to /chaos-stress;
+//	   * The file is written in the subfolder chaos-stress, e.g. /data/db/chaos-stress, removed at the end of the time
+//	     window, defined by ChaosPressure(). The other files of the folder are not changed.
+//
+// Português:
+//
+//	Durante o teste de caos, enche o disco dentro de uma cópia por uma janela de tempo, como um vizinho barulhento.
+//
+//	 Entrada:
+//	   path: pasta, dentro do container, do disco a encher. ex. /data/db
+//	   size: bytes de disco a encher. Use value * KKiloByte, value * KMegaByte e value * KGigaByte
+//
+//	 Notas:
+//	   * Requer EnableChaos() e o compilador go no computador hospedeiro. O estressor é compilado para a plataforma do
+//	     container e copiado para /chaos-stress;
+//	   * O arquivo é escrito na subpasta chaos-stress, ex. /data/db/chaos-stress, apagada no fim da janela de tempo,
+//	     definida por ChaosPressure(). Os outros arquivos da pasta não são alterados.
+func (el *ContainerFromImage) ChaosStressDisk(path string, size int64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.pressure.stressDiskPath = path
+	el.pressure.stressDisk = size
+	return el
+}
+
+// ChaosPressure
+//
+// English:
+//
+//	Defines the resource-pressure chaos, ChaosThrottle*() and ChaosStress*().
+//
+//	 Input:
+//	   maxPressured: maximum number of copies under pressure at the same time. Zero for no limit
+//	   min, max: time window of the pressure (Default: 30s to 90s)
+//
+// Português:
+//
+//	Define o caos de pressão de recursos, ChaosThrottle*() e ChaosStress*().
+//
+//	 Entrada:
+//	   maxPressured: quantidade máxima de cópias sob pressão ao mesmo tempo. Zero para sem limite
+//	   min, max: janela de tempo da pressão (Padrão: 30s a 90s)
+func (el *ContainerFromImage) ChaosPressure(maxPressured int, min, max time.Duration) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.pressure.maxPressured = maxPressured
+	el.manager.ChaosConfig.minimumTimeUnderPressure = min
+	el.manager.ChaosConfig.maximumTimeUnderPressure = max
+	return el
+}
+
+// queueContainerThrottle
+//
+// Squeezes one resource of the copy, chosen by random, and restores it at the end of the time window
+func (el *ContainerFromImage) queueContainerThrottle(iCopy int) {
+	var kinds = el.pressure.throttleKinds()
+	var kind = kinds[rand.Intn(len(kinds))]
+	var resources, display = el.pressure.throttleResources(kind, rand.Float64())
+
+	var chaos chaosAction
+	nextTime := time.Now().Add(el.selectDuration(el.manager.ChaosConfig.maximumTimeDelay, el.manager.ChaosConfig.minimumTimeDelay))
+	chaos = chaosAction{
+		display: display,
+		time:    nextTime,
+		action: func(id string) (err error) {
+			return el.containerUpdate(iCopy, id, resources)
+		},
+		id: el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+
+	nextTime = nextTime.Add(el.selectDuration(el.manager.ChaosConfig.maximumTimeUnderPressure, el.manager.ChaosConfig.minimumTimeUnderPressure))
+	chaos = chaosAction{
+		display: "restore(" + kind + ")",
+		time:    nextTime,
+		action: func(id string) (err error) {
+			var memoryTotal int64
+			if kind == kPressureMemory {
+				memoryTotal, err = el.hostMemoryTotal(iCopy)
+				if err != nil {
+					return
+				}
+			}
+
+			return el.containerUpdate(iCopy, id, restoreResources(kind, el.mapHostConfig(iCopy).Resources, memoryTotal))
+		},
+		id: el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+	el.manager.Chaos[iCopy].Type = "throttle" //todo: const
+}
+
+// queueContainerStress
+//
+// Runs the stressor inside the copy, for one resource chosen by random, during the time window
+func (el *ContainerFromImage) queueContainerStress(iCopy int) {
+	var kinds = el.pressure.stressKinds()
+	var kind = kinds[rand.Intn(len(kinds))]
+	var window = el.selectDuration(el.manager.ChaosConfig.maximumTimeUnderPressure, el.manager.ChaosConfig.minimumTimeUnderPressure)
+	var command = el.pressure.stressCommand(kind, window)
+
+	var chaos chaosAction
+	nextTime := time.Now().Add(el.selectDuration(el.manager.ChaosConfig.maximumTimeDelay, el.manager.ChaosConfig.minimumTimeDelay))
+	chaos = chaosAction{
+		display: "stress(" + strings.Join(command[1:], " ") + ")",
+		time:    nextTime,
+		action: func(id string) (err error) {
+			return el.containerStress(iCopy, id, command)
+		},
+		id: el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+
+	// the stressor ends by itself, this action only keeps the copy under pressure until the end of the window
+	chaos = chaosAction{
+		display: "stressEnd(" + kind + ")",
+		time:    nextTime.Add(window),
+		action:  el.chaosDoNotting,
+		id:      el.copyId(iCopy),
+	}
+	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
+	el.manager.Chaos[iCopy].Type = "stress" //todo: const
+}
+
+// containerUpdate
+//
+// Changes the resources of the copy. Docker warnings, e.g. the kernel does not support swap limit, are not errors
+func (el *ContainerFromImage) containerUpdate(iCopy int, id string, resources dockerContainer.Resources) (err error) {
+	var warnings []string
+	warnings, err = el.manager.DockerSys[iCopy].ContainerUpdate(id, resources)
+	if len(warnings) != 0 {
+		log.Printf("container[%v].ContainerUpdate().warnings: %v", iCopy, strings.Join(warnings, "; "))
+	}
+
+	return
+}
+
+// hostMemoryTotal
+//
+// Memory of the docker host, in bytes
+func (el *ContainerFromImage) hostMemoryTotal(iCopy int) (memoryTotal int64, err error) {
+	var info types.Info
+	if info, err = el.manager.DockerSys[iCopy].DockerInfo(); err != nil {
+		return
+	}
+
+	memoryTotal = info.MemTotal
+	return
+}
+
+// containerStress
+//
+// Copies the stressor into the copy, only once, and runs it without waiting for its end
+func (el *ContainerFromImage) containerStress(iCopy int, id string, command []string) (err error) {
+	if err = el.stressCopy(iCopy, id); err != nil {
+		return
+	}
+
+	return el.manager.DockerSys[iCopy].ContainerExecDetached(id, command)
+}
+
+// stressCopy
+//
+// Copies the stressor into the copy, only once
+func (el *ContainerFromImage) stressCopy(iCopy int, id string) (err error) {
+	if el.pressure.stressCopied[iCopy] {
+		return
+	}
+
+	var archive []byte
+	if archive, err = el.stressArchive(el.copyPlatform(iCopy)); err != nil {
+		return
+	}
+
+	if err = el.manager.DockerSys[iCopy].ContainerCopyTo(id, "/", bytes.NewReader(archive)); err != nil {
+		return
+	}
+
+	if el.pressure.stressCopied == nil {
+		el.pressure.stressCopied = make(map[int]bool)
+	}
+	el.pressure.stressCopied[iCopy] = true
+	return
+}
+
+// stressArchive
+//
+// Compiles the stressor for the platform, only once, and archives it in the format used by ContainerCopyTo()
+func (el *ContainerFromImage) stressArchive(platform string) (archive []byte, err error) {
+	if archive = el.pressure.stressArchive[platform]; archive != nil {
+		return
+	}
+
+	var tmpDir string
+	if tmpDir, err = os.MkdirTemp("", "chaos__"); err != nil {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	var binary string
+	if binary, err = stressor.Build(tmpDir, platform); err != nil {
+		return
+	}
+
+	var buffer *bytes.Buffer
+	if buffer, err = stressor.Tar(binary); err != nil {
+		return
+	}
+
+	if el.pressure.stressArchive == nil {
+		el.pressure.stressArchive = make(map[string][]byte)
+	}
+	archive = buffer.Bytes()
+	el.pressure.stressArchive[platform] = archive
+	return
+}
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"testing"
+	"time"
+)
+
+func TestChaosPressure_throttleResources(t *testing.T) {
+	var pressure = chaosPressure{
+		cpuMin:         0.001,
+		cpuMax:         0.5,
+		memoryMin:      64 * 1024 * 1024,
+		memoryMax:      128 * 1024 * 1024,
+		blkioWeightMin: 10,
+		blkioWeightMax: 100,
+	}
+
+	if kinds := pressure.throttleKinds(); len(kinds) != 3 {
+		t.Fatalf("throttle kinds: %v", kinds)
+	}
+
+	var resources, _ = pressure.throttleResources(kPressureCpu, 0)
+	if resources.CPUPeriod != kChaosCpuPeriod || resources.CPUQuota != kChaosCpuQuotaMinimum {
+		t.Errorf("the cpu quota must respect the docker minimum: %+v", resources)
+	}
+
+	resources, _ = pressure.throttleResources(kPressureCpu, 1)
+	if resources.CPUQuota != kChaosCpuPeriod/2 {
+		t.Errorf("cpu quota: %v", resources.CPUQuota)
+	}
+
+	resources, _ = pressure.throttleResources(kPressureMemory, 0.5)
+	if resources.Memory != 96*1024*1024 || resources.MemorySwap != resources.Memory {
+		t.Errorf("memory: %v, swap: %v", resources.Memory, resources.MemorySwap)
+	}
+
+	resources, _ = pressure.throttleResources(kPressureBlkio, 1)
+	if resources.BlkioWeight != 100 {
+		t.Errorf("blkio weight: %v", resources.BlkioWeight)
+	}
+}
+
+func TestChaosPressure_restoreResources(t *testing.T) {
+	var resources = restoreResources(kPressureCpu, dockerContainer.Resources{}, 0)
+	if resources.CPUQuota != -1 || resources.CPUPeriod != kChaosCpuPeriod {
+		t.Errorf("a container without cpu limit must be restored without limit: %+v", resources)
+	}
+
+	resources = restoreResources(kPressureMemory, dockerContainer.Resources{}, 1024)
+	if resources.Memory != 1024 || resources.MemorySwap != -1 {
+		t.Errorf("a container without memory limit must receive the host memory: %+v", resources)
+	}
+
+	resources = restoreResources(kPressureMemory, dockerContainer.Resources{Memory: 256}, 1024)
+	if resources.Memory != 256 || resources.MemorySwap != 512 {
+		t.Errorf("the memory defined on creation must be restored: %+v", resources)
+	}
+
+	resources = restoreResources(kPressureBlkio, dockerContainer.Resources{BlkioWeight: 300}, 0)
+	if resources.BlkioWeight != 300 {
+		t.Errorf("blkio weight: %v", resources.BlkioWeight)
+	}
+}
+
+func TestChaosPressure_stressCommand(t *testing.T) {
+	var pressure = chaosPressure{stressDisk: 1024, stressDiskPath: "/tmp/fill"}
+	var command = pressure.stressCommand(kPressureDisk, time.Minute)
+	var expected = []string{"/chaos-stress", "-duration", "1m0s", "-disk", "1024", "-path", "/tmp/fill/chaos-stress"}
+
+	if len(command) != len(expected) {
+		t.Fatalf("command: %v", command)
+	}
+
+	for k := range expected {
+		if command[k] != expected[k] {
+			t.Errorf("command: %v", command)
+			break
+		}
+	}
+}
@@ -7,29 +7,40 @@ import (
 	"errors"
 	"fmt"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/blkiodev"
 	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/mount"
 	networkTypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	sshGit "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/helmutkemper/chaos/internal/builder"
 	"github.com/helmutkemper/chaos/internal/dockerfileGolang"
 	"github.com/helmutkemper/chaos/internal/monitor"
+	"github.com/helmutkemper/chaos/internal/standalone"
 	"github.com/helmutkemper/chaos/internal/util/utilCopy"
+	"io"
 	"io/fs"
 	"io/ioutil"
 	"log"
 	"math"
 	"math/rand"
 	"os"
+	"os/exec"
 	"os/user"
 	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -38,6 +49,33 @@ const (
 	kSrc = 1
 )
 
+// Folder, inside the container, where the coverage data is written (GOCOVERDIR)
+const kCoverageContainerDir = "/chaos/coverage"
+
+// Ids of the BuildKit secrets used by the image build. e.g. RUN --mount=type=secret,id=ssh_key
+const (
+	// Key of ~/.ssh read by PrivateRepositoryAutoConfig(), mounted with the name of its file, e.g. /root/.ssh/id_rsa
+	kSecretSshKey = "ssh_key"
+
+	// Key id_ecdsa, when it is defined besides the key above, mounted as /root/.ssh/id_ecdsa
+	kSecretSshKeyEcdsa = "ssh_key_ecdsa"
+
+	kSecretKnownHosts = "known_hosts"
+	kSecretGitConfig  = "gitconfig"
+)
+
+// Image labels recording the git revision used to build the image
+const (
+	kLabelGitCommit = "chaos.git.commit"
+	kLabelGitRef    = "chaos.git.ref"
+)
+
+// gitCommitRegexp matches a commit SHA, complete or abbreviated, defined by GitRef()
+var gitCommitRegexp = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// gitReportNameRegexp matches the characters of the image name not accepted in the name of the build report
+var gitReportNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
 // Report from project https://github.com/google/osv-scanner
 type reportData struct {
 	Results []struct {
@@ -87,9 +125,13 @@ type reportData struct {
 					Type string `json:"type"`
 					Url  string `json:"url"`
 				} `json:"references"`
+				DatabaseSpecific struct {
+					Severity string `json:"severity"`
+				} `json:"database_specific"`
 			} `json:"vulnerabilities"`
 			Groups []struct {
-				Ids []string `json:"ids"`
+				Ids         []string `json:"ids"`
+				MaxSeverity string   `json:"max_severity"`
 			} `json:"groups"`
 		} `json:"packages"`
 	} `json:"results"`
@@ -99,6 +141,9 @@ type containerCommon struct {
 	// Lista de todos os IPs em uso
 	IPV4Address []string
 
+	// List of all IPv6 addresses in use, when the network is dual-stack
+	IPV6Address []string
+
 	// Detach container from monitor and network
 	detach bool
 
@@ -114,9 +159,24 @@ type containerCommon struct {
 	// Volume inside container
 	volumeContainer []string
 
-	// Volume inside host computer, where key is index from Create(copies) e.g. [key][]string
+	// Volume inside host computer, where key is index from Create(copies) e.g. [key][]string. For named volumes, the
+	// only value is the volume name
 	volumeHost [][]string
 
+	// Mount type, builder.KVolumeMountTypeBindString or builder.KVolumeMountTypeVolumeString, where key is the same
+	// from volumeContainer
+	volumeType []string
+
+	// Mounts the volume as read only, where key is the same from volumeContainer
+	volumeReadOnly []bool
+
+	// Host folder copied into the named volume on creation, where key is the same from volumeContainer
+	volumeSeed []string
+
+	// Size of the named volume kept in RAM memory, where key is the same from volumeContainer. Zero for a volume of the
+	// default driver
+	volumeSize []int64
+
 	// Pointer from the container manager (must be initialized)
 	manager *Manager
 
@@ -228,6 +288,30 @@ type containerCommon struct {
 	// Path from git private key
 	gitSshPrivateKeyPath string
 
+	// Branch, tag or commit to be cloned. Empty for the default branch
+	gitRef string
+
+	// Number of commits fetched by the clone. Zero for the full history
+	gitDepth int
+
+	// Clones the submodules recursively
+	gitRecurseSubmodules bool
+
+	// Folder, inside the repository, used as the build context
+	gitSubdir string
+
+	// Commit SHA used to build the image
+	gitCommit string
+
+	// Credentials passed to the image build as BuildKit secrets, where key is the secret id
+	buildSecrets map[string][]byte
+
+	// Path of the ssh agent socket forwarded to the image build
+	sshAgentSocket string
+
+	// Passes the credentials as build args, the old and insecure behavior
+	gitCredentialsAsBuildArgs bool
+
 	ChaosEnabled                  bool
 	ChaosMaxStopped               int
 	ChaosMaxPaused                int
@@ -238,8 +322,176 @@ type containerCommon struct {
 	VulnerabilityReport     bool
 	VulnerabilityReportPath string
 
+	// Scanner defined by VulnerabilityScannerEngine(). When nil, osv-scanner is built from the git repository
+	scanner Scanner
+
+	// SBOM generator and format defined by Sbom()
+	sbomMaker  SbomMaker
+	sbomFormat string
+
+	// Minimum severity that fails the test
+	vulnerabilityThreshold string
+
+	// Ids of accepted vulnerabilities
+	vulnerabilityAllowList []string
+
 	ContainerWaitTextInLog        string
 	ContainerWaitTextInLogTimeout time.Duration
+
+	// Compiles the project with `-cover` and collects the coverage data at the end of the test
+	coverage bool
+
+	// Folder, inside the host computer, where each copy writes its coverage data, where key is index from
+	// Create(copies)
+	coverageHostDir []string
+
+	// Collects the coverage data once, by End() or by the cleanup of Test()
+	coverageOnce sync.Once
+
+	// List of platforms to build and run, in the format os[/arch[/variant]]. e.g. linux/amd64, linux/arm64
+	platforms []string
+
+	// Number of copies defined in Create(), for each platform
+	copiesPerPlatform int
+
+	// Resources applied to the running containers
+	resources containerResources
+
+	// Resource-pressure chaos
+	pressure chaosPressure
+
+	// Host configuration applied to the running containers, e.g. capabilities, DNS and log driver
+	hostOptions containerHostOptions
+
+	// Disk-fault chaos
+	disk chaosDisk
+
+	// Arguments used to create each copy, used to create the copy again, where key is index from Create(copies)
+	createArgs []containerCreateArgs
+
+	// Clock skew chaos
+	clock chaosClock
+
+	// DNS failure chaos
+	dns chaosDns
+
+	// Networks of each copy, defined by Networks()
+	networks []string
+
+	// Addresses of each copy in each network, where key is index from Create(copies)
+	networkAddress [][]containerNetworkAddress
+
+	// Network partition chaos
+	partition chaosPartition
+
+	// Static addresses of each copy, defined by StaticIpAddress() and StaticIpV6Address()
+	staticIpV4Address []string
+	staticIpV6Address []string
+
+	// Protects el.manager.Id, copyRecreating and failLogsLastSize, changed by containerRecreate() in the chaos thread
+	// while the stats, logs and fail flag threads read them
+	copyIdMutex sync.Mutex
+
+	// Copies removed and created again by containerRecreate(), the docker api fails on the old id meanwhile
+	copyRecreating map[int]bool
+
+	// Chaos actions executed during the test, appended by the chaos thread
+	chaosTimeline      []ChaosEvent
+	chaosTimelineMutex sync.Mutex
+
+	// Chaos window in progress in each copy, shown by the statistics
+	chaosState chaosStateList
+
+	// Sampling interval of the statistics, defined by StatsInterval()
+	statsInterval time.Duration
+
+	// Adds the windows columns to the statistics, defined by StatsWindowsColumns()
+	statsWindows bool
+
+	// Directory of the logs streamed to disk, defined by SaveLogs()
+	logsPath string
+
+	// Rotation of the logs, defined by LogsRotation()
+	logsMaxSize  int64
+	logsMaxFiles int
+	logsRotation bool
+
+	// Keeps the escape sequences of the terminal in the logs, defined by LogsKeepAnsi()
+	logsKeepAnsi bool
+
+	// Actions executed when a fail flag is found, defined by ForensicsCommit(), ForensicsCopy(), ForensicsExec() and
+	// ForensicsGoroutineDump()
+	forensicsCommit     bool
+	forensicsPaths      []string
+	forensicsExec       []forensicsCommand
+	forensicsGoroutines bool
+}
+
+// ChaosEvent
+//
+// Chaos action executed during the test
+type ChaosEvent struct {
+	Time      time.Time `json:"time"`
+	Container string    `json:"container"`
+	Action    string    `json:"action"`
+}
+
+// containerResources
+//
+// Resources applied to the running containers. When a list has only one value, all copies receive it, otherwise, the
+// key is the index from Create(copies), see copyValue()
+type containerResources struct {
+	memory     []int64
+	memorySwap []int64
+	cpuShares  []int64
+	cpuSetMems []string
+	cpuSetCpus []string
+	cpuQuota   []int64
+	cpuPeriod  []int64
+	pidsLimit  []int64
+
+	blkioWeight          []uint16
+	blkioDeviceReadBps   []blkioThrottle
+	blkioDeviceWriteBps  []blkioThrottle
+	blkioDeviceReadIOps  []blkioThrottle
+	blkioDeviceWriteIOps []blkioThrottle
+
+	ulimits        []*units.Ulimit
+	oomKillDisable bool
+
+	tmpfs []tmpfsMount
+}
+
+// blkioThrottle
+//
+// Block IO limit of a device, where key of rate is index from Create(copies)
+type blkioThrottle struct {
+	path string
+	rate []uint64
+}
+
+// tmpfsMount
+//
+// Tmpfs folder inside the container, where key of size is index from Create(copies)
+type tmpfsMount struct {
+	path string
+	size []int64
+}
+
+// copyValue
+//
+// Returns the value of the copy. A list with only one value is applied to all copies, and the copies after the end of
+// a longer list receive the zero value, the docker default
+func copyValue[T any](list []T, iCopy int) (value T) {
+	if len(list) == 1 {
+		return list[0]
+	}
+
+	if len(list) > iCopy {
+		return list[iCopy]
+	}
+
+	return
 }
 
 type ContainerFromImage struct {
@@ -317,6 +569,8 @@ func (el *ContainerFromImage) WaitForFlagTimeout(text string, timeout time.Durat
 //		    receive `pathA`, the second, `pathB` and the third will not receive value;
 //		    - Imagine creating 3 containers and passing the values `pathA`, `` and `pathB`. The first container created will
 //		    receive `pathA`, the second will not receive value, and the third receive `pathB`.
+//		  * For data that must stay outside the project folder, use VolumeNamed(), and, for data kept in RAM memory,
+//		    Tmpfs().
 func (el *ContainerFromImage) Volumes(containerPath string, hostPath ...string) (ref *ContainerFromImage) {
 	if monitor.Err {
 		return el
@@ -324,11 +578,6 @@ func (el *ContainerFromImage) Volumes(containerPath string, hostPath ...string)
 
 	var err error
 
-	if el.volumeContainer == nil {
-		el.volumeContainer = make([]string, 0)
-		el.volumeHost = make([][]string, 0)
-	}
-
 	var absolutePath string
 	var absolutePathList []string
 	for k := range hostPath {
@@ -346,11 +595,208 @@ func (el *ContainerFromImage) Volumes(containerPath string, hostPath ...string)
 		absolutePathList = append(absolutePathList, absolutePath)
 	}
 
-	el.volumeContainer = append(el.volumeContainer, containerPath)
-	el.volumeHost = append(el.volumeHost, absolutePathList)
+	el.volumeAdd(containerPath, builder.KVolumeMountTypeBindString, absolutePathList, false)
+	return el
+}
+
+// VolumesReadOnly
+//
+// English:
+//
+//	Same as Volumes(), but the folders or files are mounted as read only
+//
+//	 Input:
+//	   containerPath: folder or file path inside the container
+//	   hostPath: list of folders or files within the host computer, one per copy
+//
+// Português:
+//
+//	Igual a Volumes(), mas as pastas ou arquivos são montados como somente leitura
+//
+//	 Entrada:
+//	   containerPath: caminho da pasta ou arquivo dentro do container
+//	   hostPath: lista de pastas ou arquivos no computador hospedeiro, um por cópia
+func (el *ContainerFromImage) VolumesReadOnly(containerPath string, hostPath ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.Volumes(containerPath, hostPath...)
+	if monitor.Err {
+		return el
+	}
+
+	el.volumeReadOnly[len(el.volumeReadOnly)-1] = true
+	return el
+}
+
+// VolumeNamed
+//
+// English:
+//
+//	Mounts a named docker volume, so the data written by the container stays outside the project folder
+//
+//	 Input:
+//	   containerPath: folder path inside the container. e.g. /data/db
+//	   name: volume name. Each copy receives its own volume, e.g. delete_mongo_0_data, delete_mongo_1_data
+//	   readOnly: mounts the volume as read only
+//
+//	 Notes:
+//	   * The volumes are created by Create() and removed by the garbage collector, as the containers;
+//	   * Use VolumeSeed() to copy a host folder into the volume.
+//
+// Português:
+//
+//	Monta um volume docker nomeado, assim, os dados escritos pelo container ficam fora da pasta do projeto
+//
+//	 Entrada:
+//	   containerPath: caminho da pasta dentro do container. ex. /data/db
+//	   name: nome do volume. Cada cópia recebe o seu próprio volume, ex. delete_mongo_0_data, delete_mongo_1_data
+//	   readOnly: monta o volume como somente leitura
+//
+//	 Notas:
+//	   * Os volumes são criados por Create() e apagados pelo coletor de lixo, como os containers;
+//	   * Use VolumeSeed() para copiar uma pasta do hospedeiro para dentro do volume.
+func (el *ContainerFromImage) VolumeNamed(containerPath, name string, readOnly bool) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.volumeAdd(containerPath, builder.KVolumeMountTypeVolumeString, []string{name}, readOnly)
+	return el
+}
+
+// VolumeNamedSize
+//
+// English:
+//
+//	Mounts a named docker volume with size limit, kept in RAM memory, so ChaosDiskFill() fills the volume, and not
+//	the disk of the host computer
+//
+//	 Input:
+//	   containerPath: folder path inside the container. e.g. /data/db
+//	   name: volume name. Each copy receives its own volume, e.g. delete_mongo_0_data, delete_mongo_1_data
+//	   size: size in bytes. Use value * KKiloByte, value * KMegaByte and value * KGigaByte
+//
+// Português:
+//
+//	Monta um volume docker nomeado com limite de tamanho, mantido na memória RAM, assim, ChaosDiskFill() enche o
+//	volume, e não o disco do computador hospedeiro
+//
+//	 Entrada:
+//	   containerPath: caminho da pasta dentro do container. ex. /data/db
+//	   name: nome do volume. Cada cópia recebe o seu próprio volume, ex. delete_mongo_0_data, delete_mongo_1_data
+//	   size: tamanho em bytes. Use value * KKiloByte, value * KMegaByte e value * KGigaByte
+func (el *ContainerFromImage) VolumeNamedSize(containerPath, name string, size int64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.volumeAdd(containerPath, builder.KVolumeMountTypeVolumeString, []string{name}, false)
+	el.volumeSize[len(el.volumeSize)-1] = size
+	return el
+}
+
+// VolumeSeed
+//
+// English:
+//
+//	Copies the content of a host folder into the named volume of each copy, before the container is created
+//
+//	 Input:
+//	   containerPath: folder path inside the container, defined by VolumeNamed()
+//	   hostPath: folder within the host computer. e.g. ./testdata/mongo
+//
+// Português:
+//
+//	Copia o conteúdo de uma pasta do hospedeiro para dentro do volume nomeado de cada cópia, antes do container ser
+//	criado
+//
+//	 Entrada:
+//	   containerPath: caminho da pasta dentro do container, definido por VolumeNamed()
+//	   hostPath: pasta no computador hospedeiro. ex. ./testdata/mongo
+func (el *ContainerFromImage) VolumeSeed(containerPath, hostPath string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	var err error
+	if hostPath, err = filepath.Abs(hostPath); err != nil {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("containerFromImage.VolumeSeed().error: %v", err)
+		return el
+	}
+
+	for k := range el.volumeContainer {
+		if el.volumeContainer[k] == containerPath && el.volumeType[k] == builder.KVolumeMountTypeVolumeString {
+			el.volumeSeed[k] = hostPath
+			return el
+		}
+	}
+
+	monitor.Err = true
+	ErrorCh <- fmt.Errorf("containerFromImage.VolumeSeed().error: named volume not found: %v. Use VolumeNamed() first", containerPath)
 	return el
 }
 
+// volumeAdd
+//
+// Adds a mount to the volume lists
+func (el *ContainerFromImage) volumeAdd(containerPath, mountType string, hostPath []string, readOnly bool) {
+	el.volumeContainer = append(el.volumeContainer, containerPath)
+	el.volumeHost = append(el.volumeHost, hostPath)
+	el.volumeType = append(el.volumeType, mountType)
+	el.volumeReadOnly = append(el.volumeReadOnly, readOnly)
+	el.volumeSeed = append(el.volumeSeed, "")
+	el.volumeSize = append(el.volumeSize, 0)
+}
+
+// volumeName
+//
+// Returns the name of the named volume of the copy. e.g. delete_mongo_0_data
+func (el *ContainerFromImage) volumeName(k, iCopy int) (name string) {
+	return el.copyName(iCopy) + "_" + el.volumeHost[k][0]
+}
+
+// volumeNamedCreate
+//
+// Creates the named volumes of the copy and copies the seed folders into them
+func (el *ContainerFromImage) volumeNamedCreate(iCopy int, imageName string) (err error) {
+	for k := range el.volumeContainer {
+		if el.volumeType[k] != builder.KVolumeMountTypeVolumeString {
+			continue
+		}
+
+		var name = el.volumeName(k, iCopy)
+		if el.volumeSize[k] > 0 {
+			var options = map[string]string{"type": "tmpfs", "device": "tmpfs", "o": "size=" + strconv.FormatInt(el.volumeSize[k], 10)}
+			_, err = el.manager.DockerSys[iCopy].VolumeCreateWithOptions(nil, name, "", options)
+		} else {
+			_, err = el.manager.DockerSys[iCopy].VolumeCreate(nil, name)
+		}
+		if err != nil {
+			return
+		}
+
+		if el.volumeSeed[k] == "" {
+			continue
+		}
+
+		var content io.ReadCloser
+		if content, err = archive.TarWithOptions(el.volumeSeed[k], &archive.TarOptions{}); err != nil {
+			return
+		}
+
+		err = el.manager.DockerSys[iCopy].VolumeSeed(name, imageName, content)
+		_ = content.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 // Ports
 //
 // Defines, which port of the container will be exposed to the world
@@ -732,16 +1178,44 @@ func (el *ContainerFromImage) Healthcheck(interval, timeout, startPeriod time.Du
 
 // SaveStatistics
 //
-// Salva um arquivo com as estatísticas de consumo de memória e processamento do container durante os testes
+// English:
+//
+//	Saves one CSV file per copy, stats.<name>.csv, with the memory, processing, disk and network usage of the container
+//	during the tests.
+//
+//	 Input:
+//	   path: directory of the files
+//
+//	 Notes:
+//	   * The sampling interval is defined by StatsInterval() (Default: 10s);
+//	   * The columns "cpu - percent", "blkio - read iops" and "blkio - write iops" are calculated from the previous
+//	     sample, where 100% is one cpu. The iops columns are empty on cgroup v2, which doesn't count the operations;
+//	   * "memory - working set" is the memory usage without the inactive page cache;
+//	   * Each network interface of the first sample has the columns rx/tx bytes, errors and dropped;
+//	   * The columns "chaos - action", "chaos - paused" and "chaos - stopped" show the chaos window in progress;
+//	   * The windows columns are added by StatsWindowsColumns().
+//
+//	| time                      | state - running | ... | chaos - action | chaos - paused | chaos - stopped | read                                | ... | cpu - percent | ... | memory - working set | ... | blkio - read bytes | ... | network eth0 - rx bytes | ... |
+//	|---------------------------|-----------------|-----|----------------|----------------|-----------------|-------------------------------------|-----|---------------|-----|----------------------|-----|--------------------|-----|-------------------------|-----|
+//	| 2022-11-20T10:15:00-03:00 | true            | ... |                | false          | false           | 2022-11-20T13:15:00.270355545Z      | ... | 12.35         | ... | 67489792             | ... | 1261568            | ... | 5824                    | ... |
+//	| 2022-11-20T10:15:10-03:00 | false           | ... | pause()        | true           | false           | 2022-11-20T13:15:10.315625547Z      | ... | 0.00          | ... | 74043392             | ... | 1261568            | ... | 6102                    | ... |
+//
+// Português:
+//
+//	Salva um arquivo CSV por cópia, stats.<nome>.csv, com o consumo de memória, processamento, disco e rede do
+//	container durante os testes.
 //
-//	| read      | pre read  | pids - current (linux) | pids - limit (linux) | num of process (windows) | storage - read count (windows) | storage - write count (windows) | cpu - online | cpu - system usage | cpu - usage in user mode | cpu - usage in kernel mode | cpu - total usage | cpu - throttled time | cpu - throttled periods | cpu - throttling periods | pre cpu - online | pre cpu - system usage | pre cpu - usage in user mode | pre cpu - usage in kernel mode | pre cpu - total usage | pre cpu - throttled time | pre cpu - throttled periods | pre cpu - throttling periods | memory - limit | memory - commit peak | memory - commit | memory - fail cnt | memory - usage | memory - max usage | memory - private working set |
-//	|-----------|-----------|------------------------|----------------------|--------------------------|--------------------------------|---------------------------------|--------------|--------------------|--------------------------|----------------------------|-------------------|----------------------|-------------------------|--------------------------|------------------|------------------------|------------------------------|--------------------------------|-----------------------|--------------------------|-----------------------------|------------------------------|----------------|----------------------|-----------------|-------------------|----------------|--------------------|------------------------------|
-//	| 270355545 | 267925794 | 36                     | -1                   | 0                        | 0                              | 0                               | 8            | 128396690000000    | 1333036000               | 273231000                  | 1606267000        | 0                    | 0                       | 0                        | 8                | 128388860000000        | 1122134000                   | 188896000                      | 1311030000            | 0                        | 0                           | 0                            | 12544057344    | 0                    | 0               | 0                 | 67489792       | 0                  | 0                            |
-//	| 315625547 | 312487880 | 36                     | -1                   | 0                        | 0                              | 0                               | 8            | 128443910000000    | 2428358000               | 705437000                  | 3133796000        | 0                    | 0                       | 0                        | 8                | 128436100000000        | 2261894000                   | 623029000                      | 2884924000            | 0                        | 0                           | 0                            | 12544057344    | 0                    | 0               | 0                 | 74043392       | 0                  | 0                            |
-//	| 331017884 | 328716175 | 37                     | -1                   | 0                        | 0                              | 0                               | 8            | 128490870000000    | 3388019000               | 1217971000                 | 4605991000        | 0                    | 0                       | 0                        | 8                | 128483010000000        | 3235788000                   | 1129258000                     | 4365046000            | 0                        | 0                           | 0                            | 12544057344    | 0                    | 0               | 0                 | 79872000       | 0                  | 0                            |
-//	| 375934470 | 373538303 | 37                     | -1                   | 0                        | 0                              | 0                               | 8            | 128538150000000    | 4373956000               | 1736955000                 | 6110912000        | 0                    | 0                       | 0                        | 8                | 128530300000000        | 4209072000                   | 1648809000                     | 5857882000            | 0                        | 0                           | 0                            | 12544057344    | 0                    | 0               | 0                 | 85491712       | 0                  | 0                            |
-//	| 392846000 | 389797833 | 37                     | -1                   | 0                        | 0                              | 0                               | 8            | 128585060000000    | 5392002000               | 2341771000                 | 7733774000        | 0                    | 0                       | 0                        | 8                | 128577290000000        | 5213464000                   | 2236247000                     | 7449711000            | 0                        | 0                           | 0                            | 12544057344    | 0                    | 0               | 0                 | 91275264       | 0                  | 0                            |
-//	| 438223378 | 435128169 | 36                     | -1                   | 0                        | 0                              | 0                               | 8            | 128632160000000    | 6476036000               | 2913993000                 | 9390029000        | 0                    | 0                       | 0                        | 8                | 128624350000000        | 6290689000                   | 2803815000                     | 9094505000            | 0                        | 0                           | 0                            | 12544057344    | 0                    | 0               | 0                 | 97112064       | 0                  | 0                            |
+//	 Entrada:
+//	   path: diretório dos arquivos
+//
+//	 Notas:
+//	   * O intervalo de amostragem é definido por StatsInterval() (Padrão: 10s);
+//	   * As colunas "cpu - percent", "blkio - read iops" e "blkio - write iops" são calculadas a partir da amostra
+//	     anterior, onde 100% é uma cpu. As colunas de iops ficam vazias no cgroup v2, que não conta as operações;
+//	   * "memory - working set" é o consumo de memória sem o cache de páginas inativo;
+//	   * Cada interface de rede da primeira amostra tem as colunas rx/tx bytes, errors e dropped;
+//	   * As colunas "chaos - action", "chaos - paused" e "chaos - stopped" mostram a janela de caos em andamento;
+//	   * As colunas do windows são adicionadas por StatsWindowsColumns().
 func (el *ContainerFromImage) SaveStatistics(path string) (ref *ContainerFromImage) {
 	if monitor.Err {
 		return el
@@ -765,6 +1239,30 @@ func (el *ContainerFromImage) SaveStatistics(path string) (ref *ContainerFromIma
 	return el
 }
 
+// Coverage
+//
+// Compiles the golang project with the `-cover` flag and collects the code coverage reached inside the containers.
+//
+// Each copy receives the environment variable GOCOVERDIR pointing to a folder mounted from the host computer,
+// `coverage/<container name>_<copy>` inside the Test() save folder. After End(), and after the last chaos action, all
+// copies are stopped and their coverage data is merged into the `coverage.<container name>.out` profile, inside the
+// same folder. When the test fails before End(), the coverage is collected by the cleanup of Test().
+//
+//	Notes:
+//	  * Only works with MakeDockerfile(), because the flag is added by the automatic Dockerfile generator;
+//	  * Requires golang 1.20 or later, inside the image and on the host computer (`go tool covdata`);
+//	  * Coverage counters are only written when the program exits normally, so the code under test must handle
+//	    SIGTERM and return from main();
+//	  * The profile can be viewed with `go tool cover -html=coverage.<container name>.out`.
+func (el *ContainerFromImage) Coverage() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.coverage = true
+	return el
+}
+
 // ReplaceBeforeBuild
 //
 // Replaces or adds files to the project, in the temporary folder, before the image is created.
@@ -855,7 +1353,7 @@ func (el *ContainerFromImage) Command(key int, command ...string) (exitCode int,
 		return
 	}
 
-	return el.manager.DockerSys[key].ContainerExecCommand(el.manager.Id[key], command)
+	return el.manager.DockerSys[key].ContainerExecCommand(el.copyId(key), command)
 }
 
 // VulnerabilityScanner
@@ -1026,6 +1524,9 @@ VOLUME /scan
 		return
 	}
 
+	var found, _ = vulnerabilityFilter(osvVulnerabilities(reportSt), el.vulnerabilityAllowList)
+	el.vulnerabilityThresholdCheck(reportName, found)
+
 	reportText := "# Vulnerability Report\n\n"
 	reportText += fmt.Sprintf("This report is based on an open database and shows known vulnerabilities. Validity: %v\n\n", time.Now().Format(time.ANSIC))
 
@@ -1084,41 +1585,356 @@ VOLUME /scan
 	}
 
 	reportText += "\n"
-	_ = os.WriteFile(filepath.Join(el.VulnerabilityReportPath, fmt.Sprintf("report.%v.md", reportName)), []byte(reportText), fs.ModePerm)
+	_ = os.WriteFile(filepath.Join(el.VulnerabilityReportPath, fmt.Sprintf("report.%v.md", reportName)), []byte(monitor.Redact(reportText)), fs.ModePerm)
 	return el
 }
 
-// Stop
+// VulnerabilityScannerEngine
 //
-// Stops all containers controlled by the control object
-func (el *ContainerFromImage) Stop() (ref *ContainerFromImage) {
+// English:
+//
+//	Replaces the osv-scanner, built from the git repository, by a scanner image that already exists on the local
+//	computer, so the test can run offline.
+//
+//	 Input:
+//	   scanner: ScannerOsv, ScannerGrype, ScannerTrivy or any implementation of the Scanner interface
+//
+//	 Notes:
+//	   * The image is scanned after the build and the report is written as report.(image).md and
+//	     report.(image).json in the folder defined by VulnerabilityScanner();
+//	   * ScannerGrype and ScannerTrivy scan the image, ScannerOsv scans the lockfiles of the project folder;
+//	   * Set DbPath of the scanner with a local mirror of the vulnerability database to work offline.
+//
+//	 Example:
+//
+//	   VulnerabilityScanner("./").
+//	   VulnerabilityScannerEngine(factory.NewScannerTrivy("aquasec/trivy:0.44.0", "/mirror/trivy")).
+//	   VulnerabilityThreshold(factory.KSeverityHigh).
+//	   VulnerabilityAllowList("./vulnerability.allow")
+//
+// Português:
+//
+//	Troca o osv-scanner, construído a partir do repositório git, por uma imagem de scanner que já existe no
+//	computador local, para que o teste possa rodar offline.
+//
+//	 Entrada:
+//	   scanner: ScannerOsv, ScannerGrype, ScannerTrivy ou qualquer implementação da interface Scanner
+//
+//	 Notas:
+//	   * A imagem é escaneada após a construção e o relatório é escrito como report.(imagem).md e
+//	     report.(imagem).json na pasta definida por VulnerabilityScanner();
+//	   * ScannerGrype e ScannerTrivy escaneiam a imagem, ScannerOsv escaneia os lockfiles da pasta do projeto;
+//	   * Defina DbPath do scanner com um espelho local do banco de dados de vulnerabilidades para trabalhar offline.
+func (el *ContainerFromImage) VulnerabilityScannerEngine(scanner Scanner) (ref *ContainerFromImage) {
 	if monitor.Err {
 		return el
 	}
 
-	var err error
-
-	for i := 0; i != el.copies; i += 1 {
-		err = el.manager.DockerSys[i].ContainerStop(el.manager.Id[i])
-		if err != nil {
-			monitor.Err = true
-			ErrorCh <- fmt.Errorf("container[%v].Stop().ContainerStop().error: %v", i, err)
-			return el
-		}
-	}
-
+	el.scanner = scanner
 	return el
 }
 
-func (el *ContainerFromImage) WaitStatusNotRunning(timeout time.Duration) (ref *ContainerFromImage) {
-	if monitor.Err {
-		return el
-	}
-
-	var err error
-
-	for i := 0; i != el.copies; i += 1 {
-		err = el.manager.DockerSys[i].ContainerWaitStatusNotRunning(el.manager.Id[i], timeout)
+// VulnerabilityThreshold
+//
+// English:
+//
+//	Fails the test when a vulnerability, not accepted by the allow-list, has severity equal to or greater than the
+//	threshold.
+//
+//	 Input:
+//	   severity: KSeverityLow, KSeverityMedium, KSeverityHigh or KSeverityCritical
+//
+// Português:
+//
+//	Falha o teste quando uma vulnerabilidade, não aceita pela lista de permissões, tem severidade igual ou maior que
+//	o limite.
+//
+//	 Entrada:
+//	   severity: KSeverityLow, KSeverityMedium, KSeverityHigh ou KSeverityCritical
+func (el *ContainerFromImage) VulnerabilityThreshold(severity string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	if severityNormalize(severity) == KSeverityUnknown {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("container.VulnerabilityThreshold().error: unknown severity %v", severity)
+		return el
+	}
+
+	el.vulnerabilityThreshold = severityNormalize(severity)
+	return el
+}
+
+// VulnerabilityAllowList
+//
+// English:
+//
+//	Reads a file with the ids of the accepted vulnerabilities, one per line, where the text after # is a comment.
+//	Accepted vulnerabilities do not fail the test and are listed apart in the report.
+//
+//	 Input:
+//	   path: path of the allow-list file
+//
+//	 Example:
+//
+//	   # accepted until the next release of golang.org/x/net
+//	   CVE-2022-41717
+//	   GHSA-xrjj-mj9h-534m # not used by the project
+//
+// Português:
+//
+//	Lê um arquivo com os ids das vulnerabilidades aceitas, um por linha, onde o texto após # é um comentário.
+//	Vulnerabilidades aceitas não falham o teste e são listadas à parte no relatório.
+//
+//	 Entrada:
+//	   path: caminho do arquivo da lista de permissões
+func (el *ContainerFromImage) VulnerabilityAllowList(path string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	var err error
+	el.vulnerabilityAllowList, err = vulnerabilityAllowListRead(path)
+	if err != nil {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("container.VulnerabilityAllowList().error: %v", err)
+		return el
+	}
+
+	return el
+}
+
+// Sbom
+//
+// English:
+//
+//	Writes the SBOM, software bill of materials, of the image as sbom.(image).(format).json, in the folder defined
+//	by VulnerabilityScanner().
+//
+//	 Input:
+//	   maker: ScannerTrivy, SbomSyft or any implementation of the SbomMaker interface
+//	   format: KSbomSpdx or KSbomCycloneDx
+//
+// Português:
+//
+//	Escreve o SBOM, lista de materiais do software, da imagem como sbom.(imagem).(formato).json, na pasta definida
+//	por VulnerabilityScanner().
+//
+//	 Entrada:
+//	   maker: ScannerTrivy, SbomSyft ou qualquer implementação da interface SbomMaker
+//	   format: KSbomSpdx ou KSbomCycloneDx
+func (el *ContainerFromImage) Sbom(maker SbomMaker, format string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	if _, err := maker.SbomCommand(format); err != nil {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("container.Sbom().error: %v", err)
+		return el
+	}
+
+	el.sbomMaker = maker
+	el.sbomFormat = format
+	return el
+}
+
+// vulnerabilityScanEnabled
+//
+// Returns true when VulnerabilityScannerEngine() or Sbom() were defined
+func (el *ContainerFromImage) vulnerabilityScanEnabled() (enabled bool) {
+	return (el.VulnerabilityReport == true && el.scanner != nil) || el.sbomMaker != nil
+}
+
+// vulnerabilityScan
+//
+// Exports the image and runs the scanner and the SBOM generator defined by VulnerabilityScannerEngine() and Sbom()
+func (el *ContainerFromImage) vulnerabilityScan(reportName, projectDir, imageName string) {
+	if monitor.Err {
+		return
+	}
+
+	var err error
+	var tmpDir string
+	tmpDir, err = el.makeTmpDir()
+	if err != nil {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("container.vulnerabilityScan().makeTmpDir().error: %v", err)
+		return
+	}
+
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	var reportPath = el.VulnerabilityReportPath
+	if reportPath == "" {
+		reportPath = testPathGlobal
+	}
+
+	var scanDir = filepath.Join(tmpDir, "scan")
+	var reportDir = filepath.Join(tmpDir, "report")
+	for _, dir := range []string{scanDir, reportDir} {
+		// the scanner may run with a user other than root
+		if err = os.MkdirAll(dir, fs.ModePerm); err == nil {
+			err = os.Chmod(dir, fs.ModePerm)
+		}
+		if err != nil {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container.vulnerabilityScan().MkdirAll().error: %v", err)
+			return
+		}
+	}
+
+	err = el.manager.DockerSys[0].ImageSave(imageName, filepath.Join(scanDir, filepath.Base(kScannerArchive)))
+	if err != nil {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("container.vulnerabilityScan().ImageSave().error: %v", err)
+		return
+	}
+
+	if el.VulnerabilityReport == true && el.scanner != nil {
+		var report []byte
+		report, err = el.scannerRun(el.scanner.ScanCommand(), scanDir, projectDir, reportDir, "report.json")
+		if err != nil {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container.vulnerabilityScan().scannerRun().error: %v", err)
+			return
+		}
+
+		var vulnerabilities []Vulnerability
+		vulnerabilities, err = el.scanner.ScanParse(report)
+		if err != nil {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container.vulnerabilityScan().ScanParse().error: %v", err)
+			return
+		}
+
+		var found, allowed = vulnerabilityFilter(vulnerabilities, el.vulnerabilityAllowList)
+		var reportText = vulnerabilityMarkdown(imageName, found, allowed, el.vulnerabilityThreshold)
+		_ = os.WriteFile(filepath.Join(reportPath, fmt.Sprintf("report.%v.md", reportName)), []byte(monitor.Redact(reportText)), fs.ModePerm)
+		_ = os.WriteFile(filepath.Join(reportPath, fmt.Sprintf("report.%v.json", reportName)), report, fs.ModePerm)
+
+		reportGlobal.vulnerability(vulnerabilitySummary(reportName, imageName, filepath.Join(reportPath, fmt.Sprintf("report.%v.md", reportName)), found, allowed, el.vulnerabilityThreshold))
+		el.vulnerabilityThresholdCheck(reportName, found)
+	}
+
+	if el.sbomMaker != nil {
+		var command ScannerCommand
+		command, err = el.sbomMaker.SbomCommand(el.sbomFormat)
+		if err != nil {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container.vulnerabilityScan().SbomCommand().error: %v", err)
+			return
+		}
+
+		var sbom []byte
+		sbom, err = el.scannerRun(command, scanDir, projectDir, reportDir, "sbom.json")
+		if err != nil {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container.vulnerabilityScan().scannerRun().error: %v", err)
+			return
+		}
+
+		_ = os.WriteFile(filepath.Join(reportPath, fmt.Sprintf("sbom.%v.%v.json", reportName, el.sbomFormat)), sbom, fs.ModePerm)
+	}
+}
+
+// vulnerabilityThresholdCheck
+//
+// Fails the test when a vulnerability has severity equal to or greater than VulnerabilityThreshold()
+func (el *ContainerFromImage) vulnerabilityThresholdCheck(reportName string, found []Vulnerability) {
+	var ids = vulnerabilityAboveThreshold(found, el.vulnerabilityThreshold)
+	if len(ids) == 0 {
+		return
+	}
+
+	monitor.Err = true
+	ErrorCh <- fmt.Errorf("container.vulnerabilityThresholdCheck().error: %v has %v vulnerabilities with severity %v or greater: %v", reportName, len(ids), el.vulnerabilityThreshold, strings.Join(ids, ", "))
+}
+
+// scannerRun
+//
+// Runs the scanner container and returns the content of the file written in the report folder
+func (el *ContainerFromImage) scannerRun(command ScannerCommand, scanDir, projectDir, reportDir, fileName string) (data []byte, err error) {
+	manager := new(Manager)
+	manager.New()
+
+	container := new(ContainerFromImage)
+	container.manager = manager
+	container.imageName = command.Image
+	container.command = "fromImage" //fixme: contante
+	container.Detach().
+		Volumes(filepath.Dir(kScannerArchive), scanDir).
+		Volumes(kScannerReport, reportDir)
+
+	if projectDir != "" {
+		container.Volumes(kScannerProject, projectDir)
+	}
+
+	if command.DbPath != "" {
+		container.Volumes(kScannerDb, command.DbPath)
+	}
+
+	if len(command.Cmd) != 0 {
+		container.Cmd(command.Cmd)
+	}
+
+	if len(command.Env) != 0 {
+		container.EnvironmentVar(command.Env)
+	}
+
+	container.Create("scanner", 1).
+		Start().
+		WaitStatusNotRunning(kScannerTimeout).
+		Remove()
+
+	if monitor.Err {
+		err = fmt.Errorf("container.scannerRun(%v).error: the scanner container failed", command.Image)
+		return
+	}
+
+	data, err = os.ReadFile(filepath.Join(reportDir, fileName))
+	if err != nil {
+		err = fmt.Errorf("container.scannerRun(%v).ReadFile().error: %v", command.Image, err)
+		return
+	}
+
+	return
+}
+
+// Stop
+//
+// Stops all containers controlled by the control object
+func (el *ContainerFromImage) Stop() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	var err error
+
+	for i := 0; i != el.copies; i += 1 {
+		err = el.manager.DockerSys[i].ContainerStop(el.copyId(i))
+		if err != nil {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container[%v].Stop().ContainerStop().error: %v", i, err)
+			return el
+		}
+	}
+
+	return el
+}
+
+func (el *ContainerFromImage) WaitStatusNotRunning(timeout time.Duration) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	var err error
+
+	for i := 0; i != el.copies; i += 1 {
+		err = el.manager.DockerSys[i].ContainerWaitStatusNotRunning(el.copyId(i), timeout)
 		if err != nil {
 			monitor.Err = true
 			ErrorCh <- fmt.Errorf("container[%v].containerWaitStatusNotRunning().error: %v", i, err)
@@ -1140,12 +1956,15 @@ func (el *ContainerFromImage) Remove() (ref *ContainerFromImage) {
 	var err error
 
 	for i := 0; i != el.copies; i += 1 {
-		err = el.manager.DockerSys[i].ContainerRemove(el.manager.Id[i], true, false, true)
+		err = el.manager.DockerSys[i].ContainerRemove(el.copyId(i), true, false, true)
 		if err != nil {
 			monitor.Err = true
 			ErrorCh <- fmt.Errorf("container[%v].Remove().ContainerRemove().error: %v", i, err)
 			return el
 		}
+
+		el.networkRelease(i)
+		metricsGlobal.ready(el.metricsLabels(i), false)
 	}
 
 	return el
@@ -1162,7 +1981,13 @@ func (el *ContainerFromImage) Start() (ref *ContainerFromImage) {
 	var err error
 
 	for i := 0; i != el.copies; i += 1 {
-		err = el.manager.DockerSys[i].ContainerStart(el.manager.Id[i])
+		var iCopy = i
+		reportGlobal.register(el.metricsLabels(i), el.copyName(i), func() ([]byte, error) {
+			return el.manager.DockerSys[iCopy].ContainerLogs(el.copyId(iCopy))
+		})
+		reportGlobal.started(el.metricsLabels(i), time.Now())
+
+		err = el.manager.DockerSys[i].ContainerStart(el.copyId(i))
 		if err != nil {
 			monitor.Err = true
 			ErrorCh <- fmt.Errorf("container[%v].Start().ContainerStart().error: %v", i, err)
@@ -1170,14 +1995,24 @@ func (el *ContainerFromImage) Start() (ref *ContainerFromImage) {
 		}
 	}
 
+	// the logs are saved while waiting for the text, even if it never appears
+	el.logsThread()
+
+	// the chaos actions are saved even when the test ends without Monitor(), e.g. detached containers
+	monitor.AddCleanupFunc(el.chaosTimelineWrite)
+
+	if el.forensicsEnabled() {
+		forensicsGlobal.add(el)
+	}
+
 	for i := 0; i != el.copies; i += 1 {
 		if el.ContainerWaitTextInLog != "" && el.ContainerWaitTextInLogTimeout == 0 {
-			_, err = el.manager.DockerSys[i].ContainerLogsWaitText(el.manager.Id[i], el.ContainerWaitTextInLog, nil)
+			_, err = el.manager.DockerSys[i].ContainerLogsWaitText(el.copyId(i), el.ContainerWaitTextInLog, nil)
 			monitor.Err = true
 			ErrorCh <- fmt.Errorf("container[%v].Start().ContainerLogsWaitText().error: %v", i, err)
 			return el
 		} else if el.ContainerWaitTextInLog != "" && el.ContainerWaitTextInLogTimeout != 0 {
-			_, err = el.manager.DockerSys[i].ContainerLogsWaitTextWithTimeout(el.manager.Id[i], el.ContainerWaitTextInLog, el.ContainerWaitTextInLogTimeout, nil)
+			_, err = el.manager.DockerSys[i].ContainerLogsWaitTextWithTimeout(el.copyId(i), el.ContainerWaitTextInLog, el.ContainerWaitTextInLogTimeout, nil)
 			if err != nil {
 				monitor.Err = true
 				ErrorCh <- fmt.Errorf("container[%v].Start().ContainerLogsWaitTextWithTimeout().error: %v", i, err)
@@ -1186,13 +2021,18 @@ func (el *ContainerFromImage) Start() (ref *ContainerFromImage) {
 		}
 	}
 
+	for i := 0; i != el.copies; i += 1 {
+		metricsGlobal.ready(el.metricsLabels(i), true)
+		reportGlobal.ready(el.metricsLabels(i), time.Now())
+	}
+
 	if el.detach || el.detachMonitor == true {
 		return el
 	}
 
 	var inspect types.ContainerJSON
 	for i := 0; i != el.copies; i += 1 {
-		inspect, err = el.manager.DockerSys[i].ContainerInspect(el.manager.Id[i])
+		inspect, err = el.manager.DockerSys[i].ContainerInspect(el.copyId(i))
 		if err != nil {
 			monitor.Err = true
 			ErrorCh <- fmt.Errorf("container[%v].Start().ContainerInspect().error: %v", i, err)
@@ -1216,9 +2056,12 @@ func (el *ContainerFromImage) Start() (ref *ContainerFromImage) {
 
 func (el *ContainerFromImage) End() {
 	el.ChaosTestEnd = true
+	el.chaosTimelineWrite()
 	el.failToLog()
 
+	// with chaos, the coverage is collected by the chaos thread, after the last action
 	if el.ChaosEnabled == false {
+		el.coverageEnd()
 		el.manager.DoneCh <- struct{}{}
 	}
 
@@ -1233,6 +2076,7 @@ func (el *ContainerFromImage) chaosThread() {
 			case <-tm.C:
 				end = el.chaosExecuteAction()
 				if end && el.ChaosTestEnd {
+					el.coverageEnd()
 					el.manager.DoneCh <- struct{}{}
 					return
 				}
@@ -1262,7 +2106,7 @@ func (el *ContainerFromImage) queueContainerStop(iCopy int) {
 		display: "stop()",
 		time:    nextTime,
 		action:  el.manager.DockerSys[iCopy].ContainerStop,
-		id:      el.manager.Id[iCopy],
+		id:      el.copyId(iCopy),
 	}
 	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
 
@@ -1271,7 +2115,7 @@ func (el *ContainerFromImage) queueContainerStop(iCopy int) {
 		display: "start()",
 		time:    nextTime,
 		action:  el.manager.DockerSys[iCopy].ContainerStart,
-		id:      el.manager.Id[iCopy],
+		id:      el.copyId(iCopy),
 	}
 	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
 	el.manager.Chaos[iCopy].Type = "stop" //todo: const
@@ -1284,7 +2128,7 @@ func (el *ContainerFromImage) queueContainerPause(iCopy int) {
 		display: "pause()",
 		time:    nextTime,
 		action:  el.manager.DockerSys[iCopy].ContainerPause,
-		id:      el.manager.Id[iCopy],
+		id:      el.copyId(iCopy),
 	}
 	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
 
@@ -1293,7 +2137,7 @@ func (el *ContainerFromImage) queueContainerPause(iCopy int) {
 		display: "unpause()",
 		time:    nextTime,
 		action:  el.manager.DockerSys[iCopy].ContainerUnpause,
-		id:      el.manager.Id[iCopy],
+		id:      el.copyId(iCopy),
 	}
 	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
 	el.manager.Chaos[iCopy].Type = "pause" //todo: const
@@ -1306,7 +2150,7 @@ func (el *ContainerFromImage) queueContainerDoNotting(iCopy int) {
 		display: "doNotting()",
 		time:    nextTime,
 		action:  el.chaosDoNotting,
-		id:      el.manager.Id[iCopy],
+		id:      el.copyId(iCopy),
 	}
 	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
 
@@ -1315,7 +2159,7 @@ func (el *ContainerFromImage) queueContainerDoNotting(iCopy int) {
 		display: "doNotting()",
 		time:    nextTime,
 		action:  el.chaosDoNotting,
-		id:      el.manager.Id[iCopy],
+		id:      el.copyId(iCopy),
 	}
 	el.manager.Chaos[iCopy].Action = append(el.manager.Chaos[iCopy].Action, chaos)
 	el.manager.Chaos[iCopy].Type = "doNotting" //todo: const
@@ -1351,6 +2195,11 @@ func (el *ContainerFromImage) chaosMountActionsList() {
 
 	var stopped = 0
 	var paused = 0
+	var pressured = 0
+	var faulted = 0
+	var skewed = 0
+	var dnsFaulted = 0
+	var partitioned = 0
 	var doNotting = 0
 	var affected = 0
 
@@ -1362,14 +2211,57 @@ func (el *ContainerFromImage) chaosMountActionsList() {
 		case "pause":
 			paused += 1
 			affected += 1
+		case "throttle", "stress":
+			pressured += 1
+			affected += 1
+		case "disk":
+			faulted += 1
+			affected += 1
+		case "clock":
+			skewed += 1
+			affected += 1
+		case "dns":
+			dnsFaulted += 1
+			affected += 1
+		case "partition":
+			partitioned += 1
+			affected += 1
 		case "doNotting":
 			doNotting += 1
 			affected += 1
 		}
 	}
 
+	// a negative maximum, see EnableChaos(), limits the chaos to the other kinds
+	var actionList = make([]string, 0)
+	if el.ChaosMaxStopped >= 0 {
+		actionList = append(actionList, "stop")
+	}
+	if el.ChaosMaxPaused >= 0 {
+		actionList = append(actionList, "pause")
+	}
+	if len(el.pressure.throttleKinds()) != 0 {
+		actionList = append(actionList, "throttle")
+	}
+	if len(el.pressure.stressKinds()) != 0 {
+		actionList = append(actionList, "stress")
+	}
+	if len(el.disk.kinds()) != 0 {
+		actionList = append(actionList, "disk")
+	}
+	if len(el.clock.kinds()) != 0 {
+		actionList = append(actionList, "clock")
+	}
+	if len(el.dns.kinds()) != 0 {
+		actionList = append(actionList, "dns")
+	}
+	if el.partition.enabled {
+		actionList = append(actionList, "partition")
+	}
+
+	// a kind that hits its maximum leaves the list, and the loop ends when no kind is left
 	for {
-		if affected >= el.copies {
+		if affected >= el.copies || len(actionList) == 0 {
 			return
 		}
 
@@ -1378,15 +2270,17 @@ func (el *ContainerFromImage) chaosMountActionsList() {
 			continue
 		}
 
-		if el.ChaosMaxPausedStoppedSameTime != 0 && el.ChaosMaxPausedStoppedSameTime <= stopped+paused {
-			return
-		}
-
-		action := rand.Intn(2) //todo: melhorar
+		action := actionList[rand.Intn(len(actionList))] //todo: melhorar
 		switch action {
 
-		case 0: //stop
+		case "stop":
+			if el.ChaosMaxPausedStoppedSameTime != 0 && el.ChaosMaxPausedStoppedSameTime <= stopped+paused {
+				actionList = chaosActionsRemove(actionList, "stop", "pause")
+				continue
+			}
+
 			if el.ChaosMaxStopped != 0 && el.ChaosMaxStopped <= stopped {
+				actionList = chaosActionsRemove(actionList, action)
 				continue
 			}
 
@@ -1394,8 +2288,14 @@ func (el *ContainerFromImage) chaosMountActionsList() {
 			affected += 1
 			el.queueContainerStop(iCopy)
 
-		case 1: //pause
+		case "pause":
+			if el.ChaosMaxPausedStoppedSameTime != 0 && el.ChaosMaxPausedStoppedSameTime <= stopped+paused {
+				actionList = chaosActionsRemove(actionList, "stop", "pause")
+				continue
+			}
+
 			if el.ChaosMaxPaused != 0 && el.ChaosMaxPaused <= paused {
+				actionList = chaosActionsRemove(actionList, action)
 				continue
 			}
 
@@ -1403,6 +2303,60 @@ func (el *ContainerFromImage) chaosMountActionsList() {
 			affected += 1
 			el.queueContainerPause(iCopy)
 
+		case "throttle", "stress":
+			if el.pressure.maxPressured != 0 && el.pressure.maxPressured <= pressured {
+				actionList = chaosActionsRemove(actionList, "throttle", "stress")
+				continue
+			}
+
+			pressured += 1
+			affected += 1
+			if action == "throttle" {
+				el.queueContainerThrottle(iCopy)
+			} else {
+				el.queueContainerStress(iCopy)
+			}
+
+		case "disk":
+			if el.disk.maxFaulted != 0 && el.disk.maxFaulted <= faulted {
+				actionList = chaosActionsRemove(actionList, action)
+				continue
+			}
+
+			faulted += 1
+			affected += 1
+			el.queueContainerDiskFault(iCopy)
+
+		case "clock":
+			if el.clock.maxSkewed != 0 && el.clock.maxSkewed <= skewed {
+				actionList = chaosActionsRemove(actionList, action)
+				continue
+			}
+
+			skewed += 1
+			affected += 1
+			el.queueContainerClockSkew(iCopy)
+
+		case "dns":
+			if el.dns.maxFaulted != 0 && el.dns.maxFaulted <= dnsFaulted {
+				actionList = chaosActionsRemove(actionList, action)
+				continue
+			}
+
+			dnsFaulted += 1
+			affected += 1
+			el.queueContainerDnsFault(iCopy)
+
+		case "partition":
+			if el.partition.maxPartitioned != 0 && el.partition.maxPartitioned <= partitioned {
+				actionList = chaosActionsRemove(actionList, action)
+				continue
+			}
+
+			partitioned += 1
+			affected += 1
+			el.queueContainerPartition(iCopy)
+
 		default: //do notting
 			doNotting += 1
 			affected += 1
@@ -1411,6 +2365,28 @@ func (el *ContainerFromImage) chaosMountActionsList() {
 	}
 }
 
+// chaosActionsRemove
+//
+// Returns the list of chaos kinds without the kinds given
+func chaosActionsRemove(list []string, kinds ...string) (ret []string) {
+	ret = make([]string, 0, len(list))
+	for _, action := range list {
+		var found = false
+		for _, kind := range kinds {
+			if action == kind {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			ret = append(ret, action)
+		}
+	}
+
+	return
+}
+
 func (el *ContainerFromImage) chaosExecuteAction() (end bool) {
 	var err error
 	var chaos chaosAction
@@ -1421,19 +2397,35 @@ func (el *ContainerFromImage) chaosExecuteAction() (end bool) {
 			if time.Now().After(chaos.time) {
 				if chaos.action != nil {
 					log.Printf("%v: %v", chaos.display, el.manager.DockerSys[iCopy].ContainerName)
-					if err = chaos.action(chaos.id); err != nil {
+					// the id of the queue is old when the copy was created again after the action was queued
+					var id = el.copyId(iCopy)
+					if err = chaos.action(id); err != nil {
 						monitor.Err = true
-						ErrorCh <- fmt.Errorf("container[%v].chaosExecuteAction().chaos.action(%v).error: %v", iCopy, chaos.id, err)
+						ErrorCh <- fmt.Errorf("container[%v].chaosExecuteAction().chaos.action(%v).error: %v", iCopy, id, err)
 						return
 					}
 				} else {
 					log.Printf("bug: chaos.action() is nil")
 				}
 
+				var event = ChaosEvent{
+					Time:      time.Now(),
+					Container: el.manager.DockerSys[iCopy].ContainerName,
+					Action:    chaos.display,
+				}
+				el.chaosTimelineAdd(event)
+				reportGlobal.chaosEvent(el.metricsLabels(iCopy), event)
+
 				el.manager.Chaos[iCopy].Action = el.manager.Chaos[iCopy].Action[1:]
 				if len(el.manager.Chaos[iCopy].Action) == 0 {
 					el.manager.Chaos[iCopy].Type = ""
 				}
+				el.chaosState.set(iCopy, el.manager.Chaos[iCopy].Type, chaos.display)
+				metricsGlobal.chaosAction(el.metricsLabels(iCopy), chaos.display)
+
+				// the copy stopped or paused by the chaos is not ready until the end of the window
+				var chaosType = el.manager.Chaos[iCopy].Type
+				metricsGlobal.ready(el.metricsLabels(iCopy), chaosType != "stop" && chaosType != "pause")
 			}
 		}
 	}
@@ -1457,6 +2449,48 @@ func (el *ContainerFromImage) chaosDoNotting(_ string) (err error) {
 	return
 }
 
+// chaosTimelineAdd
+//
+// Adds one chaos action executed by the chaos thread to the timeline
+func (el *ContainerFromImage) chaosTimelineAdd(event ChaosEvent) {
+	el.chaosTimelineMutex.Lock()
+	defer el.chaosTimelineMutex.Unlock()
+
+	el.chaosTimeline = append(el.chaosTimeline, event)
+}
+
+// chaosTimelineWrite
+//
+// Writes the chaos actions executed during the test in the file chaos.<container name>.csv, at the end of Monitor()
+// and in the cleanup of Test()
+func (el *ContainerFromImage) chaosTimelineWrite() {
+	var buffer bytes.Buffer
+	var writer = csv.NewWriter(&buffer)
+	_ = writer.Write([]string{"time", "container", "action"})
+	for _, event := range el.GetChaosTimeline() {
+		_ = writer.Write([]string{event.Time.Format(time.RFC3339Nano), event.Container, event.Action})
+	}
+	writer.Flush()
+
+	_ = os.WriteFile(filepath.Join(testPathGlobal, fmt.Sprintf("chaos.%v.csv", el.containerName)), buffer.Bytes(), fs.ModePerm)
+}
+
+// GetChaosTimeline
+//
+// English:
+//
+//	Returns the chaos actions executed during the test, also written in the file chaos.<container name>.csv
+//
+// Português:
+//
+//	Retorna as ações de caos executadas durante o teste, também escritas no arquivo chaos.<nome do container>.csv
+func (el *ContainerFromImage) GetChaosTimeline() (timeline []ChaosEvent) {
+	el.chaosTimelineMutex.Lock()
+	defer el.chaosTimelineMutex.Unlock()
+
+	return append([]ChaosEvent(nil), el.chaosTimeline...)
+}
+
 func (el *ContainerFromImage) failToLog() {
 	var err error
 	var logs []byte
@@ -1466,8 +2500,14 @@ func (el *ContainerFromImage) failToLog() {
 
 	for i := 0; i != el.copies; i += 1 {
 
-		logs, err = el.manager.DockerSys[i].ContainerLogs(el.manager.Id[i])
+		var id = el.copyId(i)
+		logs, err = el.manager.DockerSys[i].ContainerLogs(id)
 		if err != nil {
+			// the copy is being created again by containerRecreate(), the logs are read at the next tick
+			if el.copyRecreated(i, id) {
+				continue
+			}
+
 			monitor.Err = true
 			ErrorCh <- fmt.Errorf("container[%v].failFlagThread().ContainerLogs().error: %v", i, err)
 			return
@@ -1475,6 +2515,11 @@ func (el *ContainerFromImage) failToLog() {
 
 		lineList = el.logsCleaner(logs, i)
 		if line, found = el.logsSearchAndReplaceIntoText(i, &logs, lineList, el.failPath, el.failFlag); found {
+			metricsGlobal.failFlag(el.metricsLabels(i))
+			reportGlobal.fail(el.metricsLabels(i), reportFail{time: time.Now(), line: string(line), context: logsContext(lineList, line)})
+
+			// the forensics run in the cleanup of Test(), the fail flag must reach the monitor first
+			forensicsGlobal.fail()
 			el.manager.FailCh <- string(line)
 		}
 
@@ -1515,8 +2560,15 @@ func (el *ContainerFromImage) logsCleaner(logs []byte, key int) (logsLine [][]by
 	}
 
 	// faz o log só lê a parte mais recente do mesmo
-	logs = logs[el.failLogsLastSize[key]:]
+	// the size is reset by containerRecreate(), the logs of the new container are shorter
+	el.copyIdMutex.Lock()
+	var last = el.failLogsLastSize[key]
+	if last > len(logs) {
+		last = 0
+	}
 	el.failLogsLastSize[key] = size
+	el.copyIdMutex.Unlock()
+	logs = logs[last:]
 
 	for i := 0; i != 32; i += 1 {
 		if i == 10 {
@@ -1547,8 +2599,8 @@ func (el *ContainerFromImage) logsSearchAndReplaceIntoText(key int, logs *[]byte
 						return
 					}
 					var totalOfFiles = strconv.Itoa(len(dirList))
-					var join = filepath.Join(pathLog, el.containerName+"_"+strconv.FormatInt(int64(key), 10)+"."+totalOfFiles+".fail.log")
-					err = ioutil.WriteFile(join, *logs, fs.ModePerm)
+					var join = filepath.Join(pathLog, el.copyName(key)+"."+totalOfFiles+".fail.log")
+					err = ioutil.WriteFile(join, []byte(monitor.Redact(string(*logs))), fs.ModePerm)
 					if err != nil {
 						monitor.Err = true
 						ErrorCh <- fmt.Errorf("container.logsSearchAndReplaceIntoText().ioutil.WriteFile(%v).error: %v", join, err)
@@ -1565,209 +2617,6 @@ func (el *ContainerFromImage) logsSearchAndReplaceIntoText(key int, logs *[]byte
 	return
 }
 
-// statsThread
-//
-// Inspects the container and saves container statistics information to a CSV file every 10 seconds
-func (el *ContainerFromImage) statsThread() {
-	if el.csvPath == "" {
-		return
-	}
-
-	var err error
-	el.manager.TickerStats = time.NewTicker(10 * time.Second)
-	go func() {
-		var inspect types.ContainerJSON
-		var stats types.Stats
-		var line [][]string
-		var writer *csv.Writer
-
-		var stateRunning string
-		var stateDead string
-		var stateOOMKilled string
-		var statePaused string
-		var stateRestarting string
-		var stateError string
-		var stateStatus string
-		var stateExitCode string
-		var stateHealth string
-
-		var file = make([]*os.File, el.copies)
-		for i := 0; i != el.copies; i += 1 {
-			var filePath = filepath.Join(el.csvPath, fmt.Sprintf("stats.%v.%v.csv", el.containerName, i))
-			_ = os.Remove(filePath)
-			file[i], err = os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, fs.ModePerm)
-			if err != nil {
-				monitor.Err = true
-				ErrorCh <- fmt.Errorf("container[%v].statsThread().OpenFile().error: %v", i, err)
-				return
-			}
-		}
-
-		defer func() {
-			for i := 0; i != el.copies; i += 1 {
-				_ = file[i].Close()
-			}
-		}()
-
-		line = [][]string{{
-			"time",
-
-			"state - running",
-			"state - dead",
-			"state - OOMKilled",
-			"state - paused",
-			"state - restarting",
-			"state - error",
-			"state - status",
-			"state - exitCode",
-			"state - health check",
-
-			"read",
-			"pre read",
-
-			"pids - current (linux)",
-			"pids - limit (linux)",
-
-			"num of process (windows)",
-
-			"storage - read count (windows)",
-			"storage - write count (windows)",
-
-			"cpu - online",
-			"cpu - system usage",
-			"cpu - usage in user mode",
-			"cpu - usage in kernel mode",
-			"cpu - total usage",
-			"cpu - throttled time",
-			"cpu - throttled periods",
-			"cpu - throttling periods",
-
-			"pre cpu - online",
-			"pre cpu - system usage",
-			"pre cpu - usage in user mode",
-			"pre cpu - usage in kernel mode",
-			"pre cpu - total usage",
-			"pre cpu - throttled time",
-			"pre cpu - throttled periods",
-			"pre cpu - throttling periods",
-
-			"memory - limit",
-			"memory - commit peak",
-			"memory - commit",
-			"memory - fail cnt",
-			"memory - usage",
-			"memory - max usage",
-			"memory - private working set",
-		}}
-
-		for i := 0; i != el.copies; i += 1 {
-			writer = csv.NewWriter(file[i])
-			err = writer.WriteAll(line)
-			if err != nil {
-				monitor.Err = true
-				ErrorCh <- fmt.Errorf("container[%v].statsThread().WriteAll(0).error: %v", i, err)
-				return
-			}
-		}
-
-		for {
-			select {
-			case <-el.manager.TickerStats.C:
-				for i := 0; i != el.copies; i += 1 {
-
-					stats, err = el.manager.DockerSys[i].ContainerStatisticsOneShot(el.manager.Id[i])
-					if err != nil {
-						monitor.Err = true
-						ErrorCh <- fmt.Errorf("container[%v].statsThread().ContainerInspect().error: %v", i, err)
-						continue
-					}
-
-					inspect, err = el.manager.DockerSys[i].ContainerInspect(el.manager.Id[i])
-					if err == nil && inspect.State != nil {
-						stateRunning = strconv.FormatBool(inspect.State.Running)
-						stateDead = strconv.FormatBool(inspect.State.Dead)
-						stateOOMKilled = strconv.FormatBool(inspect.State.OOMKilled)
-						statePaused = strconv.FormatBool(inspect.State.Paused)
-						stateRestarting = strconv.FormatBool(inspect.State.Restarting)
-						stateError = inspect.State.Error
-						stateStatus = inspect.State.Status
-
-						if inspect.State.ExitCode != 0 {
-							stateExitCode = strconv.FormatInt(int64(inspect.State.ExitCode), 10)
-						}
-
-						if inspect.State.Health != nil {
-							stateHealth = inspect.State.Health.Status
-						}
-					}
-
-					line = [][]string{{
-						time.Now().Format("2006-01-02 15:04:05"),
-
-						stateRunning,
-						stateDead,
-						stateOOMKilled,
-						statePaused,
-						stateRestarting,
-						stateError,
-						stateStatus,
-						stateExitCode,
-						stateHealth,
-
-						strconv.FormatInt(int64(stats.Read.Nanosecond()), 10),
-						strconv.FormatInt(int64(stats.PreRead.Nanosecond()), 10),
-
-						//linux
-						strconv.FormatInt(int64(stats.PidsStats.Current), 10),
-						strconv.FormatInt(int64(stats.PidsStats.Limit), 10),
-
-						//windows
-						strconv.FormatInt(int64(stats.NumProcs), 10),
-						strconv.FormatInt(int64(stats.StorageStats.ReadCountNormalized), 10),
-						strconv.FormatInt(int64(stats.StorageStats.WriteCountNormalized), 10),
-
-						// Shared stats
-						strconv.FormatUint(uint64(stats.CPUStats.OnlineCPUs), 10),
-						strconv.FormatUint(stats.CPUStats.SystemUsage, 10),
-						strconv.FormatUint(stats.CPUStats.CPUUsage.UsageInUsermode, 10),
-						strconv.FormatUint(stats.CPUStats.CPUUsage.UsageInKernelmode, 10),
-						strconv.FormatUint(stats.CPUStats.CPUUsage.TotalUsage, 10),
-						strconv.FormatUint(stats.CPUStats.ThrottlingData.ThrottledTime, 10),
-						strconv.FormatUint(stats.CPUStats.ThrottlingData.ThrottledPeriods, 10),
-						strconv.FormatUint(stats.CPUStats.ThrottlingData.Periods, 10),
-
-						strconv.FormatUint(uint64(stats.PreCPUStats.OnlineCPUs), 10),
-						strconv.FormatUint(stats.PreCPUStats.SystemUsage, 10),
-						strconv.FormatUint(stats.PreCPUStats.CPUUsage.UsageInUsermode, 10),
-						strconv.FormatUint(stats.PreCPUStats.CPUUsage.UsageInKernelmode, 10),
-						strconv.FormatUint(stats.PreCPUStats.CPUUsage.TotalUsage, 10),
-						strconv.FormatUint(stats.PreCPUStats.ThrottlingData.ThrottledTime, 10),
-						strconv.FormatUint(stats.PreCPUStats.ThrottlingData.ThrottledPeriods, 10),
-						strconv.FormatUint(stats.PreCPUStats.ThrottlingData.Periods, 10),
-
-						strconv.FormatUint(stats.MemoryStats.Limit, 10),
-						strconv.FormatUint(stats.MemoryStats.CommitPeak, 10),
-						strconv.FormatUint(stats.MemoryStats.Commit, 10),
-						strconv.FormatUint(stats.MemoryStats.Failcnt, 10),
-						strconv.FormatUint(stats.MemoryStats.Usage, 10),
-						strconv.FormatUint(stats.MemoryStats.MaxUsage, 10),
-						strconv.FormatUint(stats.MemoryStats.PrivateWorkingSet, 10),
-					}}
-
-					writer = csv.NewWriter(file[i])
-					err = writer.WriteAll(line)
-					if err != nil {
-						monitor.Err = true
-						ErrorCh <- fmt.Errorf("container[%v].statsThread().WriteAll(1).error: %v", i, err)
-						return
-					}
-
-				}
-			}
-		}
-	}()
-}
-
 // Create
 //
 // Cria o container.
@@ -1788,6 +2637,12 @@ func (el *ContainerFromImage) Create(containerName string, copies int) (ref *Con
 		return el
 	}
 
+	// each platform receives all copies
+	el.copiesPerPlatform = copies
+	if len(el.platforms) != 0 {
+		copies *= len(el.platforms)
+	}
+
 	if el.imageCacheName == "" {
 		el.imageCacheName = "cache:latest"
 	}
@@ -1796,6 +2651,10 @@ func (el *ContainerFromImage) Create(containerName string, copies int) (ref *Con
 		el.autoDockerfile = new(dockerfileGolang.DockerfileGolang)
 	}
 
+	if el.coverage == true {
+		el.autoDockerfile.EnableCoverage()
+	}
+
 	if !strings.Contains(containerName, "delete") {
 		containerName = "delete_" + containerName
 	}
@@ -1826,7 +2685,11 @@ func (el *ContainerFromImage) Create(containerName string, copies int) (ref *Con
 		}
 	}
 
-	err = el.imageBuild(el.imageName)
+	if len(el.platforms) == 0 {
+		err = el.imageBuild(el.imageName)
+	} else {
+		err = el.imageBuildPlatforms()
+	}
 	if err != nil {
 		monitor.Err = true
 		ErrorCh <- fmt.Errorf("container.Create().imageBuild(%v).error: %v", el.imageName, err)
@@ -1834,9 +2697,13 @@ func (el *ContainerFromImage) Create(containerName string, copies int) (ref *Con
 	}
 
 	var ipAddress string
+	var ipV6Address string
 	var netConfig *networkTypes.NetworkingConfig
+	var netExtra []containerNetworkEndpoint
 	var re = regexp.MustCompile(`_[0-9]+$`)
 	el.IPV4Address = make([]string, 0)
+	el.IPV6Address = make([]string, 0)
+	el.networkAddress = make([][]containerNetworkAddress, 0)
 	for iCopy := 0; iCopy != copies; iCopy += 1 {
 
 		// index zero is created when the manager object is created, the other indexes are created here, in case there is
@@ -1844,31 +2711,50 @@ func (el *ContainerFromImage) Create(containerName string, copies int) (ref *Con
 		if iCopy != 0 {
 			var dockerSys = new(builder.DockerSystem)
 			_ = dockerSys.Init()
+			dockerSys.SetLabels(standalone.SessionLabels())
 			el.manager.DockerSys = append(el.manager.DockerSys, dockerSys)
 		}
 
-		// get the next ip address from network
-		if networkManagerGlobal != nil && !el.detach {
-			ipAddress, netConfig, err = networkManagerGlobal.generator.GetNext()
+		// get the next ip address from each network defined by Networks()
+		if len(el.networks) != 0 && !el.detach {
+			var addresses []containerNetworkAddress
+			netConfig, netExtra, addresses, err = el.networkEndpoints(iCopy)
 			if err != nil {
 				monitor.Err = true
-				ErrorCh <- fmt.Errorf("container.Create().network.GetNext().error: %v", err)
+				ErrorCh <- fmt.Errorf("container[%v].Create().networkEndpoints().error: %v", iCopy, err)
 				return el
 			}
+			ipAddress, ipV6Address = addresses[0].ipV4Address, addresses[0].ipV6Address
 			el.IPV4Address = append(el.IPV4Address, ipAddress)
+			if ipV6Address != "" {
+				el.IPV6Address = append(el.IPV6Address, ipV6Address)
+			}
+			el.networkAddress = append(el.networkAddress, addresses)
+
+			// get the next ip address from network
+		} else if networkManagerGlobal != nil && !el.detach {
+			ipAddress, ipV6Address, netConfig, err = networkManagerGlobal.generator.Lease(el.copyName(iCopy), el.staticAddress(el.staticIpV4Address, iCopy), el.staticAddress(el.staticIpV6Address, iCopy))
+			if err != nil {
+				monitor.Err = true
+				ErrorCh <- fmt.Errorf("container[%v].Create().network.Lease().error: %v", iCopy, err)
+				return el
+			}
+			el.IPV4Address = append(el.IPV4Address, ipAddress)
+			if ipV6Address != "" {
+				el.IPV6Address = append(el.IPV6Address, ipV6Address)
+			}
 		}
 
 		// map the port container:host[copiesKey]
 		var portConfig = el.mapContainerPorts(iCopy)
 		var volumes = el.mapVolumes(iCopy)
-		var iCopyString = strconv.FormatInt(int64(iCopy), 10)
 
 		var containerNameFormatted string
 		if !re.MatchString(containerName) {
-			containerNameFormatted = containerName + "_" + iCopyString
+			containerNameFormatted = el.copyName(iCopy)
 		}
 
-		config.Image = el.imageName
+		config.Image = el.copyImageName(iCopy)
 
 		// todo: documentar isto
 		if len(el.environment) > iCopy {
@@ -1879,6 +2765,28 @@ func (el *ContainerFromImage) Create(containerName string, copies int) (ref *Con
 			config.Env = nil
 		}
 
+		if el.coverage == true {
+			var coverageVolume mount.Mount
+			if config.Env, coverageVolume, err = el.coverageCopy(iCopy, config.Env); err != nil {
+				monitor.Err = true
+				ErrorCh <- fmt.Errorf("container[%v].Create().coverageCopy().error: %v", iCopy, err)
+				return el
+			}
+			volumes = append(volumes, coverageVolume)
+		}
+
+		if el.clock.library != "" {
+			var clockVolume mount.Mount
+			if clockVolume, err = el.clockCopy(iCopy); err != nil {
+				monitor.Err = true
+				ErrorCh <- fmt.Errorf("container[%v].Create().clockCopy().error: %v", iCopy, err)
+				return el
+			}
+
+			config.Env = append(append(make([]string, 0, len(config.Env)+len(el.clock.environment())), config.Env...), el.clock.environment()...)
+			volumes = append(volumes, el.clock.mount(), clockVolume)
+		}
+
 		// todo: documentar isto
 		if len(el.containerCommand) > iCopy {
 			config.Cmd = el.containerCommand[iCopy]
@@ -1897,47 +2805,179 @@ func (el *ContainerFromImage) Create(containerName string, copies int) (ref *Con
 			config.Shell = nil
 		}
 
-		// todo: documentar isto
-		if len(el.containerHostname) > iCopy {
-			config.Hostname = el.containerHostname[iCopy]
-		} else if len(el.containerHostname) == 1 {
-			config.Hostname = el.containerHostname[0]
-		} else {
-			config.Hostname = containerNameFormatted
-		}
+		// todo: documentar isto
+		if len(el.containerHostname) > iCopy {
+			config.Hostname = el.containerHostname[iCopy]
+		} else if len(el.containerHostname) == 1 {
+			config.Hostname = el.containerHostname[0]
+		} else {
+			config.Hostname = containerNameFormatted
+		}
+
+		// create the container, link container and network, but, don't start the container
+		var warnings []string
+		var id string
+
+		el.manager.DockerSys[iCopy].SetPlatform(el.copyPlatform(iCopy))
+		el.manager.DockerSys[iCopy].SetHostConfig(el.mapHostConfig(iCopy))
+
+		if err = el.volumeNamedCreate(iCopy, config.Image); err != nil {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container[%v].Create().volumeNamedCreate().error: %v", iCopy, err)
+			return el
+		}
+
+		id, warnings, err = el.manager.DockerSys[iCopy].ContainerCreateWithConfig(
+			config,
+			containerNameFormatted,
+			copyValue(el.hostOptions.restartPolicy, iCopy),
+			portConfig,
+			volumes,
+			netConfig,
+		)
+		if err != nil {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container[%v].Create().ContainerCreateWithConfig().error: %v", iCopy, err)
+			return el
+		}
+
+		if err = el.networkConnect(iCopy, id, netExtra); err != nil {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container[%v].Create().networkConnect().error: %v", iCopy, err)
+			return el
+		}
+
+		monitor.AddIpAddress(containerNameFormatted, ipAddress)
+		if ipV6Address != "" {
+			monitor.AddIpV6Address(containerNameFormatted, ipV6Address)
+		}
+
+		// id de todos os containers criados para a função start()
+		el.manager.Id = append(el.manager.Id, id)
+		el.createArgs = append(el.createArgs, containerCreateArgs{
+			config:        *config,
+			name:          containerNameFormatted,
+			restartPolicy: copyValue(el.hostOptions.restartPolicy, iCopy),
+			ports:         portConfig,
+			volumes:       volumes,
+			network:       netConfig,
+			extraNetworks: netExtra,
+		})
+
+		// warnings are not errors, e.g. the kernel does not support swap limit and the limit is discarded
+		if len(warnings) != 0 {
+			log.Printf("container[%v].Create().ContainerCreateWithConfig().warnings: %v", iCopy, strings.Join(warnings, "; "))
+		}
+	}
+
+	// the coverage is collected by End(), or by the cleanup of Test() when the test fails before the end
+	if el.coverage == true {
+		monitor.AddCleanupFunc(el.coverageEnd)
+	}
+
+	return el
+}
+
+// coverageCopy
+//
+// Creates the coverage folder of the copy and returns the environment with GOCOVERDIR and the mount of the folder
+func (el *ContainerFromImage) coverageCopy(iCopy int, env []string) (environment []string, volume mount.Mount, err error) {
+	var dir string
+	if dir, err = el.coverageMakeDir(el.copyName(iCopy)); err != nil {
+		return
+	}
+
+	// copy the list before appending, so the user's list is not changed
+	environment = append(append(make([]string, 0, len(env)+1), env...), "GOCOVERDIR="+kCoverageContainerDir)
+	volume = mount.Mount{
+		Type:   builder.KVolumeMountTypeBindString,
+		Source: dir,
+		Target: kCoverageContainerDir,
+	}
+	el.coverageHostDir = append(el.coverageHostDir, dir)
+	return
+}
+
+// coverageEnd
+//
+// Collects the coverage data once, after the chaos of the container ends
+func (el *ContainerFromImage) coverageEnd() {
+	if el.coverage == false {
+		return
+	}
+
+	el.coverageOnce.Do(el.coverageCollect)
+}
+
+// coverageMakeDir
+//
+// Creates an empty folder, inside the Test() save folder, where one copy of the container writes its coverage data
+func (el *ContainerFromImage) coverageMakeDir(name string) (dir string, err error) {
+	dir, err = filepath.Abs(filepath.Join(testPathGlobal, "coverage", name))
+	if err != nil {
+		err = fmt.Errorf("container.coverageMakeDir().Abs().error: %v", err)
+		return
+	}
+
+	_ = os.RemoveAll(dir)
+	if err = os.MkdirAll(dir, fs.ModePerm); err != nil {
+		err = fmt.Errorf("container.coverageMakeDir().MkdirAll().error: %v", err)
+		return
+	}
+
+	// the container user may not be the same as the host user
+	err = os.Chmod(dir, fs.ModePerm)
+	return
+}
+
+// coverageCollect
+//
+// Stops all copies, so the golang runtime writes the coverage counters, and merges the data of all copies into the
+// `coverage.<container name>.out` profile, inside the Test() save folder
+func (el *ContainerFromImage) coverageCollect() {
+	var err error
+	var inspect types.ContainerJSON
 
-		// create the container, link container and network, but, don't start the container
-		var warnings []string
-		var id string
+	for i := 0; i != el.copies; i += 1 {
+		inspect, err = el.manager.DockerSys[i].ContainerInspect(el.copyId(i))
+		if err != nil || inspect.State == nil {
+			continue
+		}
 
-		id, warnings, err = el.manager.DockerSys[iCopy].ContainerCreateWithConfig(
-			config,
-			containerNameFormatted,
-			builder.KRestartPolicyNo,
-			portConfig,
-			volumes,
-			netConfig,
-		)
-		if err != nil {
-			monitor.Err = true
-			ErrorCh <- fmt.Errorf("container[%v].Create().ContainerCreateWithConfig().error: %v", iCopy, err)
-			return el
+		// containers paused by chaos can't receive the stop signal
+		if inspect.State.Paused == true {
+			_ = el.manager.DockerSys[i].ContainerUnpause(el.copyId(i))
 		}
 
-		monitor.AddIpAddress(containerNameFormatted, ipAddress)
+		if inspect.State.Running == true {
+			if err = el.manager.DockerSys[i].ContainerStop(el.copyId(i)); err != nil {
+				log.Printf("container[%v].coverageCollect().ContainerStop().error: %v", i, err)
+			}
+		}
+	}
 
-		// id de todos os containers criados para a função start()
-		el.manager.Id = append(el.manager.Id, id)
+	var goBinary string
+	if goBinary, err = exec.LookPath("go"); err != nil {
+		log.Printf("container.coverageCollect().LookPath().error: %v", err)
+		return
+	}
 
-		//todo: fazer warnings - não deve ser erro
-		if len(warnings) != 0 {
-			monitor.Err = true
-			ErrorCh <- fmt.Errorf("container[%v].Create().ContainerCreateWithConfig().warnings: %v", iCopy, strings.Join(warnings, "; "))
-			return el
-		}
+	var profile = filepath.Join(testPathGlobal, fmt.Sprintf("coverage.%v.out", el.containerName))
+	var output []byte
+	output, err = exec.Command(
+		goBinary,
+		"tool",
+		"covdata",
+		"textfmt",
+		"-i="+strings.Join(el.coverageHostDir, ","),
+		"-o="+profile,
+	).CombinedOutput()
+	if err != nil {
+		log.Printf("container.coverageCollect().covdata.error: %v: %s", err, output)
+		return
 	}
 
-	return el
+	log.Printf("coverage: %v", profile)
 }
 
 // mapVolumes
@@ -1948,10 +2988,18 @@ func (el *ContainerFromImage) mapVolumes(iCopy int) (volumes []mount.Mount) {
 
 	for k := range el.volumeContainer {
 		volume := mount.Mount{}
-		if len(el.volumeContainer[k]) > iCopy && el.volumeHost[k][iCopy] != "" {
+		if el.volumeType[k] == builder.KVolumeMountTypeVolumeString {
+			volume.Type = builder.KVolumeMountTypeVolumeString
+			volume.Source = el.volumeName(k, iCopy)
+			volume.Target = el.volumeContainer[k]
+			volume.ReadOnly = el.volumeReadOnly[k]
+
+			volumes = append(volumes, volume)
+		} else if len(el.volumeHost[k]) > iCopy && el.volumeHost[k][iCopy] != "" {
 			volume.Type = builder.KVolumeMountTypeBindString
 			volume.Source = el.volumeHost[k][iCopy]
 			volume.Target = el.volumeContainer[k]
+			volume.ReadOnly = el.volumeReadOnly[k]
 
 			volumes = append(volumes, volume)
 		}
@@ -1960,6 +3008,65 @@ func (el *ContainerFromImage) mapVolumes(iCopy int) (volumes []mount.Mount) {
 	return
 }
 
+// mapHostConfig
+//
+// Maps the resources of the copy to the docker host configuration
+func (el *ContainerFromImage) mapHostConfig(iCopy int) (hostConfig dockerContainer.HostConfig) {
+	var r = el.resources
+
+	hostConfig.Resources = dockerContainer.Resources{
+		Memory:               copyValue(r.memory, iCopy),
+		MemorySwap:           copyValue(r.memorySwap, iCopy),
+		CPUShares:            copyValue(r.cpuShares, iCopy),
+		CpusetMems:           copyValue(r.cpuSetMems, iCopy),
+		CpusetCpus:           copyValue(r.cpuSetCpus, iCopy),
+		CPUQuota:             copyValue(r.cpuQuota, iCopy),
+		CPUPeriod:            copyValue(r.cpuPeriod, iCopy),
+		BlkioWeight:          copyValue(r.blkioWeight, iCopy),
+		BlkioDeviceReadBps:   mapBlkioThrottle(r.blkioDeviceReadBps, iCopy),
+		BlkioDeviceWriteBps:  mapBlkioThrottle(r.blkioDeviceWriteBps, iCopy),
+		BlkioDeviceReadIOps:  mapBlkioThrottle(r.blkioDeviceReadIOps, iCopy),
+		BlkioDeviceWriteIOps: mapBlkioThrottle(r.blkioDeviceWriteIOps, iCopy),
+		Ulimits:              r.ulimits,
+	}
+
+	if pidsLimit := copyValue(r.pidsLimit, iCopy); pidsLimit != 0 {
+		hostConfig.Resources.PidsLimit = &pidsLimit
+	}
+
+	if r.oomKillDisable {
+		var oomKillDisable = true
+		hostConfig.Resources.OomKillDisable = &oomKillDisable
+	}
+
+	for _, tmpfs := range r.tmpfs {
+		if hostConfig.Tmpfs == nil {
+			hostConfig.Tmpfs = make(map[string]string)
+		}
+
+		hostConfig.Tmpfs[tmpfs.path] = ""
+		if size := copyValue(tmpfs.size, iCopy); size > 0 {
+			hostConfig.Tmpfs[tmpfs.path] = "size=" + strconv.FormatInt(size, 10)
+		}
+	}
+
+	el.hostOptions.mapHostConfig(&hostConfig, iCopy)
+	return
+}
+
+// mapBlkioThrottle
+//
+// Maps the block IO limits of the copy. Devices without value for the copy are ignored
+func mapBlkioThrottle(list []blkioThrottle, iCopy int) (devices []*blkiodev.ThrottleDevice) {
+	for _, throttle := range list {
+		if rate := copyValue(throttle.rate, iCopy); rate != 0 {
+			devices = append(devices, &blkiodev.ThrottleDevice{Path: throttle.path, Rate: rate})
+		}
+	}
+
+	return
+}
+
 // mapContainerPorts
 //
 // Maps container ports
@@ -1992,7 +3099,9 @@ func (el *ContainerFromImage) imageBuild(imageName string) (err error) {
 			return
 		}
 
-		if el.checkImageExpirationTimeIsValid() {
+		// the image of another revision is rebuilt, even inside the validity time
+		if el.checkImageExpirationTimeIsValid() && el.gitImageMatches() {
+			el.gitCommit = el.imageLabel(kLabelGitCommit)
 			return
 		}
 
@@ -2047,24 +3156,42 @@ func (el *ContainerFromImage) imageBuild(imageName string) (err error) {
 			}
 		}
 
-		_, err = git.PlainClone(tmpDir, false, gitCloneConfig)
+		el.gitCommit, err = el.gitClone(tmpDir, gitCloneConfig)
 		if err != nil {
-			err = fmt.Errorf("container.imageBuild().PlainClone().error: %v", err)
+			err = fmt.Errorf("container.imageBuild().gitClone().error: %v", err)
 			return
 		}
 
-		err = el.replaceFilesBeforeBuild(tmpDir)
+		var contextDir string
+		contextDir, err = el.gitContextDir(tmpDir)
+		if err != nil {
+			err = fmt.Errorf("container.imageBuild().gitContextDir().error: %v", err)
+			return
+		}
+
+		err = el.replaceFilesBeforeBuild(contextDir)
 		if err != nil {
 			err = fmt.Errorf("container.imageBuild().replaceFilesBeforeBuild().error: %v", err)
 			return
 		}
 
 		// fixme: experimental
-		if el.VulnerabilityReport == true {
-			el.vulnerabilityScannerMaker(imageName, tmpDir, "")
+		if el.VulnerabilityReport == true && el.scanner == nil {
+			el.vulnerabilityScannerMaker(imageName, contextDir, "")
 		}
 
-		el.buildPath = tmpDir
+		el.buildPath = contextDir
+
+		if el.manager.ImageBuildOptions.Labels == nil {
+			el.manager.ImageBuildOptions.Labels = make(map[string]string)
+		}
+		el.manager.ImageBuildOptions.Labels[kLabelGitCommit] = el.gitCommit
+		el.manager.ImageBuildOptions.Labels[kLabelGitRef] = el.gitRef
+		err = el.gitReport(imageName)
+		if err != nil {
+			err = fmt.Errorf("container.imageBuild().gitReport().error: %v", err)
+			return
+		}
 
 		var volumes = make([]mount.Mount, 0)
 		err = el.makeDefaultDockerfileForMe(volumes)
@@ -2075,6 +3202,14 @@ func (el *ContainerFromImage) imageBuild(imageName string) (err error) {
 
 		el.autoDockerfile.Prayer()
 
+		var closeSession func()
+		closeSession, err = el.imageBuildSessionStart()
+		if err != nil {
+			err = fmt.Errorf("container.imageBuild().imageBuildSessionStart().error: %v", err)
+			return
+		}
+		defer closeSession()
+
 		var changePointer = make(chan builder.ContainerPullStatusSendToChannel)
 		go el.imageBuildStdOutputToLogOutput(changePointer)
 
@@ -2095,6 +3230,16 @@ func (el *ContainerFromImage) imageBuild(imageName string) (err error) {
 			return
 		}
 
+		err = el.imageHistoryCheck(imageName)
+		if err != nil {
+			err = fmt.Errorf("container.imageBuild().imageHistoryCheck().error: %v", err)
+			return
+		}
+
+		if el.vulnerabilityScanEnabled() {
+			el.vulnerabilityScan(imageName, el.buildPath, imageName)
+		}
+
 		// Construir uma imagem de múltiplas etapas deixa imagens grandes e sem serventia, ocupando espaço no HD.
 		_ = el.manager.DockerSys[0].ImageGarbageCollector()
 
@@ -2124,7 +3269,7 @@ func (el *ContainerFromImage) imageBuild(imageName string) (err error) {
 		}
 
 		// fixme: experimental
-		if el.VulnerabilityReport == true {
+		if el.VulnerabilityReport == true && el.scanner == nil {
 			el.vulnerabilityScannerMaker(imageName, tmpDir, "")
 		}
 
@@ -2139,6 +3284,14 @@ func (el *ContainerFromImage) imageBuild(imageName string) (err error) {
 
 		el.autoDockerfile.Prayer()
 
+		var closeSession func()
+		closeSession, err = el.imageBuildSessionStart()
+		if err != nil {
+			err = fmt.Errorf("container.imageBuild().imageBuildSessionStart().error: %v", err)
+			return
+		}
+		defer closeSession()
+
 		var changePointer = make(chan builder.ContainerPullStatusSendToChannel)
 		go el.imageBuildStdOutputToLogOutput(changePointer)
 
@@ -2159,6 +3312,16 @@ func (el *ContainerFromImage) imageBuild(imageName string) (err error) {
 			return
 		}
 
+		err = el.imageHistoryCheck(imageName)
+		if err != nil {
+			err = fmt.Errorf("container.imageBuild().imageHistoryCheck().error: %v", err)
+			return
+		}
+
+		if el.vulnerabilityScanEnabled() {
+			el.vulnerabilityScan(imageName, el.buildPath, imageName)
+		}
+
 		// Construir uma imagem de múltiplas etapas deixa imagens grandes e sem serventia, ocupando espaço no HD.
 		_ = el.manager.DockerSys[0].ImageGarbageCollector()
 
@@ -2170,9 +3333,74 @@ func (el *ContainerFromImage) imageBuild(imageName string) (err error) {
 		}
 
 		// fixme: experimental
-		if el.VulnerabilityReport == true {
+		if el.VulnerabilityReport == true && el.scanner == nil {
 			el.vulnerabilityScannerMaker(imageName, tmpDir, el.imageName)
 		}
+
+		if el.vulnerabilityScanEnabled() {
+			el.vulnerabilityScan(imageName, "", el.imageName)
+		}
+	}
+
+	return
+}
+
+// imageBuildPlatforms
+//
+// Builds, or downloads, one image for each platform defined in Platforms()
+func (el *ContainerFromImage) imageBuildPlatforms() (err error) {
+	var buildPath = el.buildPath
+	var imageName = el.imageName
+	var imagePlatform = el.manager.ImageBuildOptions.Platform
+
+	defer func() {
+		el.buildPath = buildPath
+		el.imageName = imageName
+		el.manager.ImageBuildOptions.Platform = imagePlatform
+	}()
+
+	for _, platform := range el.platforms {
+		var platformImageName = el.platformImageName(imageName, platform)
+
+		// imageBuild() changes the build path to a temporary folder, removed at the end of the build
+		el.buildPath = buildPath
+		el.imageName = platformImageName
+		el.manager.ImageBuildOptions.Platform = platform
+
+		if el.command != "fromImage" {
+			if err = el.imageBuild(platformImageName); err != nil {
+				err = fmt.Errorf("container.imageBuildPlatforms(%v).error: %v", platform, err)
+				return
+			}
+
+			continue
+		}
+
+		el.imageId, _ = el.manager.DockerSys[0].ImageFindIdByName(platformImageName)
+		if el.imageId != "" {
+			continue
+		}
+
+		// the original tag always points to the last platform downloaded
+		el.imageName = imageName
+		if err = el.imagePullPlatform(platform); err != nil {
+			err = fmt.Errorf("container.imageBuildPlatforms(%v).imagePullPlatform().error: %v", platform, err)
+			return
+		}
+
+		if err = el.manager.DockerSys[0].ImageTag(el.imageName, platformImageName); err != nil {
+			err = fmt.Errorf("container.imageBuildPlatforms(%v).ImageTag().error: %v", platform, err)
+			return
+		}
+
+		// fixme: experimental
+		if el.VulnerabilityReport == true && el.scanner == nil {
+			el.vulnerabilityScannerMaker(platformImageName, "", platformImageName)
+		}
+
+		if el.vulnerabilityScanEnabled() {
+			el.vulnerabilityScan(platformImageName, "", platformImageName)
+		}
 	}
 
 	return
@@ -2195,7 +3423,7 @@ func (el *ContainerFromImage) imageBuildStdOutputToLogOutput(ch chan builder.Con
 				continue
 			}
 
-			log.Printf("%v", stream)
+			log.Printf("%v", monitor.Redact(stream))
 
 			if event.Closed == true {
 				return
@@ -2247,6 +3475,20 @@ func (el *ContainerFromImage) makeDefaultDockerfileForMe(volumes []mount.Mount)
 		}
 	}
 
+	if len(el.buildSecrets) != 0 || el.sshAgentSocket != "" {
+		var secrets = make([]string, 0)
+		for id := range el.buildSecrets {
+			secrets = append(secrets, id)
+		}
+
+		// the key is mounted with the name of the file read by PrivateRepositoryAutoConfig()
+		if el.sshDefaultFileName != "" {
+			el.autoDockerfile.SetDefaultSshFileName(el.sshDefaultFileName)
+		}
+
+		el.autoDockerfile.EnableBuildSecrets(secrets, el.sshAgentSocket != "")
+	}
+
 	dockerfile, err = el.autoDockerfile.MountDefaultDockerfile(
 		el.manager.ImageBuildOptions.BuildArgs,
 		el.portsContainer,
@@ -2396,6 +3638,14 @@ func (el *ContainerFromImage) imagePull() (err error) {
 		return
 	}
 
+	return el.imagePullPlatform(el.manager.ImageBuildOptions.Platform)
+}
+
+// imagePullPlatform
+//
+// Downloads the image for the platform, even if the image exists on the local computer
+func (el *ContainerFromImage) imagePullPlatform(platform string) (err error) {
+
 	// English: make a channel to end goroutine
 	// Português: monta um canal para terminar a goroutine
 	var chProcessEnd = make(chan bool, 1)
@@ -2435,7 +3685,7 @@ func (el *ContainerFromImage) imagePull() (err error) {
 	}()
 
 	// docker pull
-	el.imageId, el.imageName, err = el.manager.DockerSys[0].ImagePull(el.imageName, chStatus)
+	el.imageId, el.imageName, err = el.manager.DockerSys[0].ImagePullWithPlatform(el.imageName, platform, chStatus)
 	if err != nil {
 		err = fmt.Errorf("containerFromImage.Primordial().imagePull().error: %v", err)
 		return
@@ -2452,9 +3702,15 @@ func (el *ContainerFromImage) imagePull() (err error) {
 //	steps and in the first step, copy the id_rsa and known_hosts files to the /root/.ssh folder and the .gitconfig
 //	file to the /root folder.
 //
-//	One way to do this automatically is to use the Dockerfile example below, where the arguments SSH_ID_RSA_FILE
-//	contains the file ~/.ssh/id_rsa, KNOWN_HOSTS_FILE contains the file ~/.ssh/known_hosts and GITCONFIG_FILE
-//	contains the file ~/.gitconfig.
+//	By default, PrivateRepositoryAutoConfig() passes the credentials as BuildKit secrets, with the ids ssh_key,
+//	known_hosts and gitconfig, used by `RUN --mount=type=secret,id=ssh_key,target=/root/.ssh/id_rsa go mod tidy`,
+//	and the credentials never are written in the image layers. The secret ssh_key has the key of ~/.ssh read by
+//	PrivateRepositoryAutoConfig(), mount it with the name of its file, e.g. id_rsa or id_ecdsa. The key id_ecdsa,
+//	when it is defined besides the first one, has its own id, ssh_key_ecdsa.
+//
+//	The Dockerfile example below, where the arguments SSH_ID_RSA_FILE contains the file ~/.ssh/id_rsa,
+//	KNOWN_HOSTS_FILE contains the file ~/.ssh/known_hosts and GITCONFIG_FILE contains the file ~/.gitconfig,
+//	requires GitCredentialsAsBuildArgs() and the credentials are visible in `docker history` of the first stage.
 //
 //	If the ~/.ssh/id_rsa key is password protected, use the SetGitSshPassword() function to set the password.
 //
@@ -2551,6 +3807,8 @@ type DockerfileAuto interface {
 	SetFinalImageName(name string)
 	AddCopyToFinalImage(src, dst string)
 	SetDefaultSshFileName(name string)
+	EnableCoverage()
+	EnableBuildSecrets(secrets []string, sshAgent bool)
 }
 
 // SetImageBuildOptionsSecurityOpt
@@ -2686,10 +3944,26 @@ func (el *ContainerFromImage) AddImageBuildOptionsBuildArgs(key string, value *s
 //	Chamada por SetPrivateRepositoryAutoConfig()
 func (el *ContainerFromImage) addImageBuildOptionsGitCredentials() {
 
+	monitor.AddSecret(el.contentGitConfigFile)
+	monitor.AddSecretLines(el.contentIdRsaFile)
+	monitor.AddSecretLines(el.contentIdEcdsaFile)
+
 	if el.manager.ImageBuildOptions.BuildArgs == nil {
 		el.manager.ImageBuildOptions.BuildArgs = make(map[string]*string)
 	}
 
+	if el.gitPathPrivateRepository != "" {
+		el.manager.ImageBuildOptions.BuildArgs["GIT_PRIVATE_REPO"] = &el.gitPathPrivateRepository
+	}
+
+	if el.gitCredentialsAsBuildArgs == false {
+		el.addBuildSecret(kSecretGitConfig, el.contentGitConfigFile)
+		el.addBuildSecret(kSecretKnownHosts, el.contentKnownHostsFile)
+		el.addBuildSecret(kSecretSshKey, el.contentIdRsaFile)
+		el.addBuildSecret(kSecretSshKeyEcdsa, el.contentIdEcdsaFile)
+		return
+	}
+
 	if el.contentGitConfigFile != "" {
 		el.manager.ImageBuildOptions.BuildArgs["GITCONFIG_FILE"] = &el.contentGitConfigFile
 	}
@@ -2706,8 +3980,71 @@ func (el *ContainerFromImage) addImageBuildOptionsGitCredentials() {
 		el.manager.ImageBuildOptions.BuildArgs["SSH_ID_ECDSA_FILE"] = &el.contentIdEcdsaFile
 	}
 
-	if el.gitPathPrivateRepository != "" {
-		el.manager.ImageBuildOptions.BuildArgs["GIT_PRIVATE_REPO"] = &el.gitPathPrivateRepository
+	return
+}
+
+// addBuildSecret
+//
+// Adds a credential to the list of BuildKit secrets. Empty contents are ignored
+func (el *ContainerFromImage) addBuildSecret(id, content string) {
+	if content == "" {
+		return
+	}
+
+	if el.buildSecrets == nil {
+		el.buildSecrets = make(map[string][]byte)
+	}
+
+	el.buildSecrets[id] = []byte(content)
+}
+
+// imageBuildSessionStart
+//
+// Starts the BuildKit session that provides the secrets and the ssh agent to the image build, when they are defined.
+// The returned function must be called at the end of the build
+func (el *ContainerFromImage) imageBuildSessionStart() (closeSession func(), err error) {
+	closeSession = func() {}
+	if len(el.buildSecrets) == 0 && el.sshAgentSocket == "" {
+		return
+	}
+
+	var sessionId string
+	var sessionClose func() error
+	sessionId, sessionClose, err = el.manager.DockerSys[0].ImageBuildSession(el.buildSecrets, el.sshAgentSocket)
+	if err != nil {
+		err = fmt.Errorf("container.imageBuildSessionStart().ImageBuildSession().error: %v", err)
+		return
+	}
+
+	var version = el.manager.ImageBuildOptions.Version
+	el.manager.ImageBuildOptions.Version = types.BuilderBuildKit
+	el.manager.ImageBuildOptions.SessionID = sessionId
+
+	closeSession = func() {
+		_ = sessionClose()
+		el.manager.ImageBuildOptions.Version = version
+		el.manager.ImageBuildOptions.SessionID = ""
+	}
+	return
+}
+
+// imageHistoryCheck
+//
+// Fails the build, and removes the image, if any credential appears in `docker history` of the image
+func (el *ContainerFromImage) imageHistoryCheck(imageName string) (err error) {
+	var history []image.HistoryResponseItem
+	history, err = el.manager.DockerSys[0].ImageHistory(el.imageId)
+	if err != nil {
+		err = fmt.Errorf("container.imageHistoryCheck().ImageHistory().error: %v", err)
+		return
+	}
+
+	for _, layer := range history {
+		if monitor.ContainsSecret(layer.CreatedBy) || monitor.ContainsSecret(layer.Comment) {
+			_ = el.manager.DockerSys[0].ImageRemove(el.imageId, true, false)
+			err = fmt.Errorf("container.imageHistoryCheck().error: a credential was found in the history of the image %v: %v", imageName, monitor.Redact(layer.CreatedBy))
+			return
+		}
 	}
 
 	return
@@ -2769,45 +4106,214 @@ func (el *ContainerFromImage) Squash(value bool) (ref *ContainerFromImage) {
 		return el
 	}
 
-	el.manager.ImageBuildOptions.Squash = value
-	return el
+	el.manager.ImageBuildOptions.Squash = value
+	return el
+}
+
+// Platform
+//
+// English:
+//
+//	Target platform containers for this service will run on, using the os[/arch[/variant]] syntax.
+//
+//	 Input:
+//	   value: target platform
+//
+// Examples:
+//
+//	osx
+//	windows/amd64
+//	linux/arm64/v8
+//
+// Português:
+//
+//	Especifica a plataforma de container onde o serviço vai rodar, usando a sintaxe
+//	os[/arch[/variant]]
+//
+//	 Entrada:
+//	   value: plataforma de destino
+//
+// Exemplos:
+//
+//	osx
+//	windows/amd64
+//	linux/arm64/v8
+func (el *ContainerFromImage) Platform(value string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.manager.ImageBuildOptions.Platform = value
+	return el
+}
+
+// Platforms
+//
+// English:
+//
+//	Builds, or downloads, the same project for more than one platform and runs the chaos test against all of them.
+//
+//	 Input:
+//	   values: list of platforms, using the os[/arch[/variant]] syntax. e.g. "linux/amd64", "linux/arm64"
+//
+//	 Notes:
+//	   * Create(name, copies) creates `copies` containers for each platform, so Create("app", 2) with two platforms
+//	     creates four containers, `delete_app_linux-amd64_0`, `delete_app_linux-amd64_1`, `delete_app_linux-arm64_2`
+//	     and `delete_app_linux-arm64_3`;
+//	   * Per copy settings, such as EnvironmentVar() and Ports(), follow the same key, from 0 to (copies * platforms) - 1;
+//	   * Each platform receives its own image tag, e.g. `delete_app:latest-linux-arm64`, and its own reports,
+//	     e.g. `stats.delete_app.linux-arm64.2.csv`;
+//	   * Platforms other than the host platform run under QEMU emulation and require binfmt_misc installed in docker.
+//	     e.g. docker run --privileged --rm tonistiigi/binfmt --install all
+//
+// Português:
+//
+//	Constrói, ou baixa, o mesmo projeto para mais de uma plataforma e roda o teste de caos contra todas elas.
+//
+//	 Entrada:
+//	   values: lista de plataformas, usando a sintaxe os[/arch[/variant]]. ex. "linux/amd64", "linux/arm64"
+//
+//	 Notas:
+//	   * Create(name, copies) cria `copies` containers para cada plataforma, por isto, Create("app", 2) com duas
+//	     plataformas cria quatro containers, `delete_app_linux-amd64_0`, `delete_app_linux-amd64_1`,
+//	     `delete_app_linux-arm64_2` e `delete_app_linux-arm64_3`;
+//	   * Configurações por cópia, como EnvironmentVar() e Ports(), seguem a mesma chave, de 0 a
+//	     (copies * plataformas) - 1;
+//	   * Cada plataforma recebe a sua própria tag de imagem, ex. `delete_app:latest-linux-arm64`, e os seus próprios
+//	     relatórios, ex. `stats.delete_app.linux-arm64.2.csv`;
+//	   * Plataformas diferentes da plataforma do hospedeiro rodam sob a emulação QEMU e requerem o binfmt_misc
+//	     instalado no docker. ex. docker run --privileged --rm tonistiigi/binfmt --install all
+func (el *ContainerFromImage) Platforms(values ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.platforms = values
+	return el
+}
+
+// platformTag
+//
+// Converts the platform into a text accepted by docker names and tags. e.g. linux/arm64/v8: linux-arm64-v8
+func (el *ContainerFromImage) platformTag(platform string) (tag string) {
+	return strings.ReplaceAll(platform, "/", "-")
+}
+
+// platformImageName
+//
+// Returns the image name of a platform, with the platform added to the tag. e.g. delete_app:latest and linux/arm64:
+// delete_app:latest-linux-arm64, mongo and linux/arm64: mongo:latest-linux-arm64
+func (el *ContainerFromImage) platformImageName(imageName, platform string) (name string) {
+	if platform == "" {
+		return imageName
+	}
+
+	var repository, tag = imageName, "latest"
+
+	// the digest is replaced by a tag, e.g. mongo@sha256:0123456789abcdef: mongo:0123456789ab-linux-arm64
+	if index := strings.LastIndex(repository, "@"); index != -1 {
+		var digest = repository[index+1:]
+		repository = repository[:index]
+		if colon := strings.Index(digest, ":"); colon != -1 {
+			digest = digest[colon+1:]
+		}
+		if len(digest) > 12 {
+			digest = digest[:12]
+		}
+		tag = digest
+	} else if index = strings.LastIndex(repository, ":"); index > strings.LastIndex(repository, "/") {
+		// the colon of the registry port, e.g. host:5000/img, comes before the last slash
+		repository, tag = repository[:index], repository[index+1:]
+	}
+
+	return repository + ":" + tag + "-" + el.platformTag(platform)
 }
 
-// Platform
-//
-// English:
-//
-//	Target platform containers for this service will run on, using the os[/arch[/variant]] syntax.
+// copyPlatform
 //
-//	 Input:
-//	   value: target platform
+// Returns the platform of the copy, or an empty string when Platforms() was not defined
+func (el *ContainerFromImage) copyPlatform(iCopy int) (platform string) {
+	if len(el.platforms) == 0 || el.copiesPerPlatform == 0 {
+		return
+	}
+
+	return el.platforms[iCopy/el.copiesPerPlatform]
+}
+
+// copyImageName
 //
-// Examples:
+// Returns the image name used by the copy
+func (el *ContainerFromImage) copyImageName(iCopy int) (name string) {
+	return el.platformImageName(el.imageName, el.copyPlatform(iCopy))
+}
+
+// copyId
 //
-//	osx
-//	windows/amd64
-//	linux/arm64/v8
+// Returns the container id of the copy, safe for the threads that run while containerRecreate() changes it
+func (el *ContainerFromImage) copyId(iCopy int) (id string) {
+	el.copyIdMutex.Lock()
+	defer el.copyIdMutex.Unlock()
+
+	return el.manager.Id[iCopy]
+}
+
+// copyIdSet
 //
-// Português:
+// Changes the container id of the copy, after the container was created again
+func (el *ContainerFromImage) copyIdSet(iCopy int, id string) {
+	el.copyIdMutex.Lock()
+	defer el.copyIdMutex.Unlock()
+
+	el.manager.Id[iCopy] = id
+	el.failLogsLastSize[iCopy] = 0
+}
+
+// copyRecreatingSet
 //
-//	Especifica a plataforma de container onde o serviço vai rodar, usando a sintaxe
-//	os[/arch[/variant]]
+// Marks the copy while containerRecreate() removes and creates it again
+func (el *ContainerFromImage) copyRecreatingSet(iCopy int, recreating bool) {
+	el.copyIdMutex.Lock()
+	defer el.copyIdMutex.Unlock()
+
+	if el.copyRecreating == nil {
+		el.copyRecreating = make(map[int]bool)
+	}
+	el.copyRecreating[iCopy] = recreating
+}
+
+// copyRecreated
 //
-//	 Entrada:
-//	   value: plataforma de destino
+// Returns true when the copy is being created again, or was created again after the id was read, so an error of the
+// docker api on the id is expected
+func (el *ContainerFromImage) copyRecreated(iCopy int, id string) (recreated bool) {
+	el.copyIdMutex.Lock()
+	defer el.copyIdMutex.Unlock()
+
+	return el.copyRecreating[iCopy] || el.manager.Id[iCopy] != id
+}
+
+// copyName
 //
-// Exemplos:
+// Returns the container name of the copy. e.g. delete_app_0 or, with platforms, delete_app_linux-arm64_0
+func (el *ContainerFromImage) copyName(iCopy int) (name string) {
+	var platform = el.copyPlatform(iCopy)
+	if platform == "" {
+		return el.containerName + "_" + strconv.FormatInt(int64(iCopy), 10)
+	}
+
+	return el.containerName + "_" + el.platformTag(platform) + "_" + strconv.FormatInt(int64(iCopy), 10)
+}
+
+// copyReportName
 //
-//	osx
-//	windows/amd64
-//	linux/arm64/v8
-func (el *ContainerFromImage) Platform(value string) (ref *ContainerFromImage) {
-	if monitor.Err {
-		return el
+// Returns the name of the copy used in report files. e.g. delete_app.0 or, with platforms, delete_app.linux-arm64.0
+func (el *ContainerFromImage) copyReportName(iCopy int) (name string) {
+	var platform = el.copyPlatform(iCopy)
+	if platform == "" {
+		return el.containerName + "." + strconv.FormatInt(int64(iCopy), 10)
 	}
 
-	el.manager.ImageBuildOptions.Platform = value
-	return el
+	return el.containerName + "." + el.platformTag(platform) + "." + strconv.FormatInt(int64(iCopy), 10)
 }
 
 // NoCache
@@ -2887,6 +4393,8 @@ func (el *ContainerFromImage) NoCache() (ref *ContainerFromImage) {
 //
 //   - Use value * KKiloByte, value * KMegaByte and value * KGigaByte
 //     See https://docs.docker.com/engine/reference/run/#user-memory-constraints
+//   - When `value` receives more than one value, each copy created by Create(copies) receives a different value;
+//     with only one value, all copies receive it. The image build receives the first value.
 //
 // Português:
 //
@@ -2896,12 +4404,18 @@ func (el *ContainerFromImage) NoCache() (ref *ContainerFromImage) {
 //
 //   - Use value * KKiloByte, value * KMegaByte e value * KGigaByte
 //     See https://docs.docker.com/engine/reference/run/#user-memory-constraints
-func (el *ContainerFromImage) MemorySwap(value int64) (ref *ContainerFromImage) {
+//   - Quando `value` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//     com apenas um valor, todas as cópias o recebem. A construção da imagem recebe o primeiro valor.
+func (el *ContainerFromImage) MemorySwap(value ...int64) (ref *ContainerFromImage) {
 	if monitor.Err {
 		return el
 	}
 
-	el.manager.ImageBuildOptions.MemorySwap = value
+	if len(value) != 0 {
+		el.manager.ImageBuildOptions.MemorySwap = value[0]
+	}
+
+	el.resources.memorySwap = value
 
 	//e.addProblem("The SetImageBuildOptionsMemorySwap() function can generate an error when building the image.")
 	return el
@@ -2969,6 +4483,8 @@ func (el *ContainerFromImage) MemorySwap(value int64) (ref *ContainerFromImage)
 //   - If you set this option, the minimum allowed value is 4 * 1024 * 1024 (4 megabyte);
 //   - Use value * KKiloByte, value * KMegaByte and value * KGigaByte
 //     See https://docs.docker.com/engine/reference/run/#user-memory-constraints
+//   - When `value` receives more than one value, each copy created by Create(copies) receives a different value;
+//     with only one value, all copies receive it. The image build receives the first value.
 //
 // Português:
 //
@@ -2982,12 +4498,18 @@ func (el *ContainerFromImage) MemorySwap(value int64) (ref *ContainerFromImage)
 //   - Se você vai usar esta opção, o máximo permitido é 4 * 1024 * 1024 (4 megabyte)
 //   - Use value * KKiloByte, value * KMegaByte e value * KGigaByte
 //     See https://docs.docker.com/engine/reference/run/#user-memory-constraints
-func (el *ContainerFromImage) Memory(value int64) (ref *ContainerFromImage) {
+//   - Quando `value` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//     com apenas um valor, todas as cópias o recebem. A construção da imagem recebe o primeiro valor.
+func (el *ContainerFromImage) Memory(value ...int64) (ref *ContainerFromImage) {
 	if monitor.Err {
 		return el
 	}
 
-	el.manager.ImageBuildOptions.Memory = value
+	if len(value) != 0 {
+		el.manager.ImageBuildOptions.Memory = value[0]
+	}
+
+	el.resources.memory = value
 
 	//e.addProblem("The SetImageBuildOptionsMemory() function can generate an error when building the image.")
 	return el
@@ -3051,12 +4573,17 @@ func (el *ContainerFromImage) IsolationDefault() (ref *ContainerFromImage) {
 //
 // English:
 //
-//	Add hostname mappings at build-time. Use the same values as the docker client --add-host
-//	parameter.
+//	Add hostname mappings at build-time and to the running containers. Use the same values as the docker client
+//	--add-host parameter.
 //
 //	 Input:
 //	   values: hosts to mapping
 //
+//	 Notes:
+//	   * When `values` receives more than one list, each copy created by Create(copies) receives a different list;
+//	     with only one list, all copies receive it. The image build receives the first list.
+//	     The copies after the end of the list receive the docker default.
+//
 // Example:
 //
 //	values = []string{
@@ -3072,12 +4599,17 @@ func (el *ContainerFromImage) IsolationDefault() (ref *ContainerFromImage) {
 //
 // Português:
 //
-//	Adiciona itens ao mapa de hostname durante o processo de construção da imagem. Use os mesmos
-//	valores que em docker client --add-host parameter.
+//	Adiciona itens ao mapa de hostname durante o processo de construção da imagem e nos containers em execução.
+//	Use os mesmos valores que em docker client --add-host parameter.
 //
 //	 Entrada:
 //	   values: hosts para mapeamento
 //
+//	 Notas:
+//	   * Quando `values` recebe mais de uma lista, cada cópia criada por Create(copies) recebe uma lista diferente;
+//	     com apenas uma lista, todas as cópias a recebem. A construção da imagem recebe a primeira lista.
+//	     As cópias após o fim da lista recebem o padrão do docker.
+//
 // Exemplo:
 //
 //	values = []string{
@@ -3090,12 +4622,16 @@ func (el *ContainerFromImage) IsolationDefault() (ref *ContainerFromImage) {
 //
 //	  162.242.195.82 somehost
 //	  50.31.209.229 otherhost
-func (el *ContainerFromImage) ExtraHosts(values []string) (ref *ContainerFromImage) {
+func (el *ContainerFromImage) ExtraHosts(values ...[]string) (ref *ContainerFromImage) {
 	if monitor.Err {
 		return el
 	}
 
-	el.manager.ImageBuildOptions.ExtraHosts = values
+	if len(values) != 0 {
+		el.manager.ImageBuildOptions.ExtraHosts = values[0]
+	}
+
+	el.hostOptions.extraHosts = values
 	return el
 }
 
@@ -3155,6 +4691,11 @@ func (el *ContainerFromImage) CacheFrom(values []string) (ref *ContainerFromImag
 //
 //	It does not guarantee or reserve any specific CPU access.
 //
+//	 Per copy:
+//	   When `value` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it. The image build receives the first value.
+//	   The copies after the end of the list receive the docker default.
+//
 // Português:
 //
 //	Define o compartilhamento de CPU na construção da imagem.
@@ -3173,12 +4714,21 @@ func (el *ContainerFromImage) CacheFrom(values []string) (ref *ContainerFromImag
 //	Ele prioriza os recursos da CPU do container para os ciclos de CPU disponíveis.
 //
 //	Não garante ou reserva nenhum acesso específico à CPU.
-func (el *ContainerFromImage) Shares(value int64) (ref *ContainerFromImage) { //cpu
+//
+//	 Por cópia:
+//	   Quando `value` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem. A construção da imagem recebe o primeiro valor.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) Shares(value ...int64) (ref *ContainerFromImage) { //cpu
 	if monitor.Err {
 		return el
 	}
 
-	el.manager.ImageBuildOptions.CPUShares = value
+	if len(value) != 0 {
+		el.manager.ImageBuildOptions.CPUShares = value[0]
+	}
+
+	el.resources.cpuShares = value
 	return el
 }
 
@@ -3197,6 +4747,11 @@ func (el *ContainerFromImage) Shares(value int64) (ref *ContainerFromImage) { //
 //	If you have four memory nodes on your system (0-3), use --cpuset-mems=0,1 then processes in your
 //	Docker container will only use memory from the first two memory nodes.
 //
+//	 Per copy:
+//	   When `value` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it. The image build receives the first value.
+//	   The copies after the end of the list receive the docker default.
+//
 // Português:
 //
 //	Define memory node (MEMs) (--cpuset-mems)
@@ -3209,12 +4764,21 @@ func (el *ContainerFromImage) Shares(value int64) (ref *ContainerFromImage) { //
 //
 //	Se você tiver quatro nodes de memória em seu sistema (0-3), use --cpuset-mems=0,1 então, os
 //	processos em seu container do Docker usarão apenas a memória dos dois primeiros nodes.
-func (el *ContainerFromImage) Mems(value string) (ref *ContainerFromImage) { //cpu
+//
+//	 Por cópia:
+//	   Quando `value` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem. A construção da imagem recebe o primeiro valor.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) Mems(value ...string) (ref *ContainerFromImage) { //cpu
 	if monitor.Err {
 		return el
 	}
 
-	el.manager.ImageBuildOptions.CPUSetMems = value
+	if len(value) != 0 {
+		el.manager.ImageBuildOptions.CPUSetMems = value[0]
+	}
+
+	el.resources.cpuSetMems = value
 	return el
 }
 
@@ -3235,6 +4799,11 @@ func (el *ContainerFromImage) Mems(value string) (ref *ContainerFromImage) { //c
 //	A valid value might be 0-3 (to use the first, second, third, and fourth CPU) or 1,3 (to use the
 //	second and fourth CPU).
 //
+//	 Per copy:
+//	   When `value` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it. The image build receives the first value.
+//	   The copies after the end of the list receive the docker default.
+//
 // Português:
 //
 //	Limite a quantidade de CPUs ou núcleos específicos que um container pode usar.
@@ -3249,12 +4818,21 @@ func (el *ContainerFromImage) Mems(value string) (ref *ContainerFromImage) { //c
 //
 //	Um valor válido pode ser 0-3 (para usar a primeira, segunda, terceira e quarta CPU) ou 1,3 (para
 //	usar a segunda e a quarta CPU).
-func (el *ContainerFromImage) CPUs(value string) (ref *ContainerFromImage) { //cpu
+//
+//	 Por cópia:
+//	   Quando `value` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem. A construção da imagem recebe o primeiro valor.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) CPUs(value ...string) (ref *ContainerFromImage) { //cpu
 	if monitor.Err {
 		return el
 	}
 
-	el.manager.ImageBuildOptions.CPUSetCPUs = value
+	if len(value) != 0 {
+		el.manager.ImageBuildOptions.CPUSetCPUs = value[0]
+	}
+
+	el.resources.cpuSetCpus = value
 
 	//e.addProblem("The SetImageBuildOptionsCPUSetCPUs() function can generate an error when building the image.")
 	return el
@@ -3280,6 +4858,11 @@ func (el *ContainerFromImage) CPUs(value string) (ref *ContainerFromImage) { //c
 //
 //	It does not guarantee or reserve any specific CPU access.
 //
+//	 Per copy:
+//	   When `value` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it. The image build receives the first value.
+//	   The copies after the end of the list receive the docker default.
+//
 // Português:
 //
 //	Define os ciclos de CPU da máquina hospedeira.
@@ -3297,12 +4880,21 @@ func (el *ContainerFromImage) CPUs(value string) (ref *ContainerFromImage) { //c
 //	prioriza os recursos da CPU do container para os ciclos de CPU disponíveis.
 //
 //	Não garante ou reserva nenhum acesso específico à CPU.
-func (el *ContainerFromImage) Quota(value int64) (ref *ContainerFromImage) { //cpu
+//
+//	 Por cópia:
+//	   Quando `value` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem. A construção da imagem recebe o primeiro valor.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) Quota(value ...int64) (ref *ContainerFromImage) { //cpu
 	if monitor.Err {
 		return el
 	}
 
-	el.manager.ImageBuildOptions.CPUQuota = value
+	if len(value) != 0 {
+		el.manager.ImageBuildOptions.CPUQuota = value[0]
+	}
+
+	el.resources.cpuQuota = value
 
 	//e.addProblem("The SetImageBuildOptionsCPUQuota() function can generate an error when building the image.")
 	return el
@@ -3320,26 +4912,345 @@ func (el *ContainerFromImage) Quota(value int64) (ref *ContainerFromImage) { //c
 //	Defaults to 100000 microseconds (100 milliseconds). Most users do not change this from the
 //	default.
 //
-//	For most use-cases, --cpus is a more convenient alternative.
+//	For most use-cases, --cpus is a more convenient alternative.
+//
+//	 Per copy:
+//	   When `value` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it. The image build receives the first value.
+//	   The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Especifique o período do agendador CFS da CPU, que é usado junto com --cpu-quota.
+//
+//	 Entrada:
+//	   value: período do agendador CFS da CPU
+//
+//	O padrão é 100.000 microssegundos (100 milissegundos). A maioria dos usuários não altera o padrão.
+//
+//	Para a maioria dos casos de uso, --cpus é uma alternativa mais conveniente.
+//
+//	 Por cópia:
+//	   Quando `value` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem. A construção da imagem recebe o primeiro valor.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) Period(value ...int64) (ref *ContainerFromImage) { //cpu
+	if monitor.Err {
+		return el
+	}
+
+	if len(value) != 0 {
+		el.manager.ImageBuildOptions.CPUPeriod = value[0]
+	}
+
+	el.resources.cpuPeriod = value
+
+	//e.addProblem("The SetImageBuildOptionsCPUPeriod() function can generate an error when building the image.")
+	return el
+}
+
+// PidsLimit
+//
+// English:
+//
+//	Limits the number of processes and threads inside the container (--pids-limit)
+//
+//	 Input:
+//	   value: maximum number of processes. Use -1 for unlimited
+//
+//	 Per copy:
+//	   When `value` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it.
+//	   The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Limita a quantidade de processos e threads dentro do container (--pids-limit)
+//
+//	 Entrada:
+//	   value: quantidade máxima de processos. Use -1 para ilimitado
+//
+//	 Por cópia:
+//	   Quando `value` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) PidsLimit(value ...int64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.resources.pidsLimit = value
+	return el
+}
+
+// BlkioWeight
+//
+// English:
+//
+//	Defines the relative weight of the block IO of the container (--blkio-weight)
+//
+//	 Input:
+//	   value: weight between 10 and 1000. Use 0 for the default value
+//
+//	 Per copy:
+//	   When `value` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it.
+//	   The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Define o peso relativo do block IO do container (--blkio-weight)
+//
+//	 Entrada:
+//	   value: peso entre 10 e 1000. Use 0 para o valor padrão
+//
+//	 Por cópia:
+//	   Quando `value` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) BlkioWeight(value ...uint16) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.resources.blkioWeight = value
+	return el
+}
+
+// BlkioDeviceReadBps
+//
+// English:
+//
+//	Limits the read rate, in bytes per second, from a device (--device-read-bps)
+//
+//	 Input:
+//	   device: device path on the host computer. e.g. /dev/sda
+//	   rate: bytes per second. Use value * KKiloByte, value * KMegaByte and value * KGigaByte
+//
+//	 Per copy:
+//	   When `rate` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it.
+//	   The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Limita a taxa de leitura, em bytes por segundo, de um dispositivo (--device-read-bps)
+//
+//	 Entrada:
+//	   device: caminho do dispositivo no computador hospedeiro. ex. /dev/sda
+//	   rate: bytes por segundo. Use value * KKiloByte, value * KMegaByte e value * KGigaByte
+//
+//	 Por cópia:
+//	   Quando `rate` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) BlkioDeviceReadBps(device string, rate ...uint64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.resources.blkioDeviceReadBps = append(el.resources.blkioDeviceReadBps, blkioThrottle{path: device, rate: rate})
+	return el
+}
+
+// BlkioDeviceWriteBps
+//
+// English:
+//
+//	Limits the write rate, in bytes per second, to a device (--device-write-bps)
+//
+//	 Input:
+//	   device: device path on the host computer. e.g. /dev/sda
+//	   rate: bytes per second. Use value * KKiloByte, value * KMegaByte and value * KGigaByte
+//
+//	 Per copy:
+//	   When `rate` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it.
+//	   The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Limita a taxa de escrita, em bytes por segundo, em um dispositivo (--device-write-bps)
+//
+//	 Entrada:
+//	   device: caminho do dispositivo no computador hospedeiro. ex. /dev/sda
+//	   rate: bytes por segundo. Use value * KKiloByte, value * KMegaByte e value * KGigaByte
+//
+//	 Por cópia:
+//	   Quando `rate` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) BlkioDeviceWriteBps(device string, rate ...uint64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.resources.blkioDeviceWriteBps = append(el.resources.blkioDeviceWriteBps, blkioThrottle{path: device, rate: rate})
+	return el
+}
+
+// BlkioDeviceReadIOps
+//
+// English:
+//
+//	Limits the read rate, in IO operations per second, from a device (--device-read-iops)
+//
+//	 Input:
+//	   device: device path on the host computer. e.g. /dev/sda
+//	   rate: IO operations per second
+//
+//	 Per copy:
+//	   When `rate` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it.
+//	   The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Limita a taxa de leitura, em operações de IO por segundo, de um dispositivo (--device-read-iops)
+//
+//	 Entrada:
+//	   device: caminho do dispositivo no computador hospedeiro. ex. /dev/sda
+//	   rate: operações de IO por segundo
+//
+//	 Por cópia:
+//	   Quando `rate` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) BlkioDeviceReadIOps(device string, rate ...uint64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.resources.blkioDeviceReadIOps = append(el.resources.blkioDeviceReadIOps, blkioThrottle{path: device, rate: rate})
+	return el
+}
+
+// BlkioDeviceWriteIOps
+//
+// English:
+//
+//	Limits the write rate, in IO operations per second, to a device (--device-write-iops)
+//
+//	 Input:
+//	   device: device path on the host computer. e.g. /dev/sda
+//	   rate: IO operations per second
+//
+//	 Per copy:
+//	   When `rate` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it.
+//	   The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Limita a taxa de escrita, em operações de IO por segundo, em um dispositivo (--device-write-iops)
+//
+//	 Entrada:
+//	   device: caminho do dispositivo no computador hospedeiro. ex. /dev/sda
+//	   rate: operações de IO por segundo
+//
+//	 Por cópia:
+//	   Quando `rate` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) BlkioDeviceWriteIOps(device string, rate ...uint64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.resources.blkioDeviceWriteIOps = append(el.resources.blkioDeviceWriteIOps, blkioThrottle{path: device, rate: rate})
+	return el
+}
+
+// Ulimit
+//
+// English:
+//
+//	Defines a ulimit of all copies of the container (--ulimit)
+//
+//	 Input:
+//	   name: ulimit name. e.g. nofile, nproc, core, memlock, stack
+//	   soft: soft limit
+//	   hard: hard limit
+//
+// Português:
+//
+//	Define um ulimit de todas as cópias do container (--ulimit)
+//
+//	 Entrada:
+//	   name: nome do ulimit. ex. nofile, nproc, core, memlock, stack
+//	   soft: limite flexível
+//	   hard: limite rígido
+func (el *ContainerFromImage) Ulimit(name string, soft, hard int64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.resources.ulimits = append(el.resources.ulimits, &units.Ulimit{Name: name, Soft: soft, Hard: hard})
+	return el
+}
+
+// OomKillDisable
+//
+// English:
+//
+//	Disables the OOM killer of all copies of the container (--oom-kill-disable)
+//
+//	 Note:
+//	   * Only disable the OOM killer when Memory() is also defined, otherwise the host can run out of memory;
+//	   * cgroup v2 hosts ignore this option and docker returns a warning.
+//
+// Português:
+//
+//	Desabilita o OOM killer de todas as cópias do container (--oom-kill-disable)
+//
+//	 Nota:
+//	   * Só desabilite o OOM killer quando Memory() também for definido, caso contrário, o hospedeiro pode ficar
+//	     sem memória;
+//	   * hospedeiros com cgroup v2 ignoram esta opção e o docker retorna um aviso.
+func (el *ContainerFromImage) OomKillDisable() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.resources.oomKillDisable = true
+	return el
+}
+
+// Tmpfs
+//
+// English:
+//
+//	Mounts a tmpfs, a folder kept in RAM memory, inside the container (--tmpfs)
+//
+//	 Input:
+//	   containerPath: folder path inside the container. e.g. /tmp
+//	   size: maximum size in bytes. Use value * KKiloByte, value * KMegaByte and value * KGigaByte, or 0 for the
+//	     docker default
+//
+//	 Per copy:
+//	   When `size` receives more than one value, each copy created by Create(copies) receives a different value;
+//	   with only one value, all copies receive it.
+//	   The copies after the end of the list receive the docker default.
 //
 // Português:
 //
-//	Especifique o período do agendador CFS da CPU, que é usado junto com --cpu-quota.
+//	Monta um tmpfs, uma pasta mantida na memória RAM, dentro do container (--tmpfs)
 //
 //	 Entrada:
-//	   value: período do agendador CFS da CPU
-//
-//	O padrão é 100.000 microssegundos (100 milissegundos). A maioria dos usuários não altera o padrão.
-//
-//	Para a maioria dos casos de uso, --cpus é uma alternativa mais conveniente.
-func (el *ContainerFromImage) Period(value int64) (ref *ContainerFromImage) { //cpu
+//	   containerPath: caminho da pasta dentro do container. ex. /tmp
+//	   size: tamanho máximo em bytes. Use value * KKiloByte, value * KMegaByte e value * KGigaByte, ou 0 para o
+//	     padrão do docker
+//
+//	 Por cópia:
+//	   Quando `size` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	   com apenas um valor, todas as cópias o recebem.
+//	   As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) Tmpfs(containerPath string, size ...int64) (ref *ContainerFromImage) {
 	if monitor.Err {
 		return el
 	}
 
-	el.manager.ImageBuildOptions.CPUPeriod = value
-
-	//e.addProblem("The SetImageBuildOptionsCPUPeriod() function can generate an error when building the image.")
+	el.resources.tmpfs = append(el.resources.tmpfs, tmpfsMount{path: containerPath, size: size})
 	return el
 }
 
@@ -3373,6 +5284,39 @@ func (el *ContainerFromImage) AutoDockerfileGenerator(autoDockerfile DockerfileA
 	return el
 }
 
+// EnableChaos
+//
+// English:
+//
+//	Enables the chaos test on the copies of the container.
+//
+//	 Input:
+//	   maxStopped: maximum number of copies stopped at the same time. Zero for no limit, or a negative value to
+//	     never stop a copy
+//	   maxPaused: maximum number of copies paused at the same time. Zero for no limit, or a negative value to
+//	     never pause a copy
+//	   maxPausedStoppedSameTime: maximum number of copies stopped or paused at the same time. Zero for no limit
+//
+//	 Note:
+//	   * Negative values for maxStopped and maxPaused limit the chaos to the kinds defined by ChaosPressure(),
+//	     ChaosDiskFault(), ChaosClockSkew(), ChaosDns() and ChaosNetworkPartition();
+//	   * When every kind hits its maximum, the other copies run without chaos until the next cycle.
+//
+// Português:
+//
+//	Habilita o teste de caos nas cópias do container.
+//
+//	 Entrada:
+//	   maxStopped: quantidade máxima de cópias paradas ao mesmo tempo. Zero para sem limite, ou um valor negativo
+//	     para nunca parar uma cópia
+//	   maxPaused: quantidade máxima de cópias pausadas ao mesmo tempo. Zero para sem limite, ou um valor negativo
+//	     para nunca pausar uma cópia
+//	   maxPausedStoppedSameTime: quantidade máxima de cópias paradas ou pausadas ao mesmo tempo. Zero para sem limite
+//
+//	 Nota:
+//	   * Valores negativos em maxStopped e maxPaused limitam o caos aos tipos definidos por ChaosPressure(),
+//	     ChaosDiskFault(), ChaosClockSkew(), ChaosDns() e ChaosNetworkPartition();
+//	   * Quando todos os tipos atingem o máximo, as outras cópias rodam sem caos até o próximo ciclo.
 func (el *ContainerFromImage) EnableChaos(maxStopped, maxPaused, maxPausedStoppedSameTime int) (ref *ContainerFromImage) {
 	if monitor.Err {
 		return el
@@ -3392,25 +5336,33 @@ func (el *ContainerFromImage) EnableChaos(maxStopped, maxPaused, maxPausedStoppe
 //
 // English:
 //
-//	Copies the ssh ~/.ssh/id_rsa file and the ~/.gitconfig file to the SSH_ID_RSA_FILE and
-//	GITCONFIG_FILE variables.
+//	Copies the ssh ~/.ssh/id_rsa file, the ~/.ssh/known_hosts file and the ~/.gitconfig file to the BuildKit secrets
+//	ssh_key, known_hosts and gitconfig.
 //
 //	 Output:
 //	   err: Standard error object
 //
 //	 Notes:
-//	   * For change ssh key file name, use SetSshKeyFileName() function.
+//	   * For change ssh key file name, use SetSshKeyFileName() function;
+//	   * The credentials are redacted from logs and reports, and the build fails if any credential appears in
+//	     `docker history` of the image;
+//	   * Use GitCredentialsAsBuildArgs() to copy the files to the SSH_ID_RSA_FILE, KNOWN_HOSTS_FILE and
+//	     GITCONFIG_FILE build args, as in older versions.
 //
 // Português:
 //
-//	Copia o arquivo ssh ~/.ssh/id_rsa e o arquivo ~/.gitconfig para as variáveis SSH_ID_RSA_FILE e
-//	GITCONFIG_FILE.
+//	Copia o arquivo ssh ~/.ssh/id_rsa, o arquivo ~/.ssh/known_hosts e o arquivo ~/.gitconfig para os segredos do
+//	BuildKit ssh_key, known_hosts e gitconfig.
 //
 //	 Saída:
 //	   err: Objeto de erro padrão
 //
 //	 Notas:
-//	   * Para mudar o nome do arquivo ssh usado como chave, use a função SetSshKeyFileName().
+//	   * Para mudar o nome do arquivo ssh usado como chave, use a função SetSshKeyFileName();
+//	   * As credenciais são removidas dos logs e relatórios, e a construção falha caso alguma credencial apareça no
+//	     `docker history` da imagem;
+//	   * Use GitCredentialsAsBuildArgs() para copiar os arquivos para os build args SSH_ID_RSA_FILE, KNOWN_HOSTS_FILE e
+//	     GITCONFIG_FILE, como nas versões anteriores.
 func (el *ContainerFromImage) PrivateRepositoryAutoConfig() (ref *ContainerFromImage) {
 	if monitor.Err {
 		return el
@@ -3474,6 +5426,85 @@ func (el *ContainerFromImage) PrivateRepositoryAutoConfig() (ref *ContainerFromI
 	return el
 }
 
+// GitCredentialsAsBuildArgs
+//
+// English:
+//
+//	Makes PrivateRepositoryAutoConfig() pass the credentials as the SSH_ID_RSA_FILE, KNOWN_HOSTS_FILE and
+//	GITCONFIG_FILE build args, for projects with their own Dockerfile, written for older versions.
+//
+//	 Notes:
+//	   * Must be called before PrivateRepositoryAutoConfig();
+//	   * Build args are recorded in `docker history`, so only the final stage of a multi-stage build is safe to
+//	     share.
+//
+// Português:
+//
+//	Faz PrivateRepositoryAutoConfig() passar as credenciais como os build args SSH_ID_RSA_FILE, KNOWN_HOSTS_FILE e
+//	GITCONFIG_FILE, para projetos com o próprio Dockerfile, escrito para versões anteriores.
+//
+//	 Notas:
+//	   * Deve ser chamada antes de PrivateRepositoryAutoConfig();
+//	   * Build args são gravados no `docker history`, por isto, apenas o estágio final de uma construção em múltiplos
+//	     estágios é seguro para ser compartilhado.
+func (el *ContainerFromImage) GitCredentialsAsBuildArgs() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.gitCredentialsAsBuildArgs = true
+	return el
+}
+
+// SshAgentForward
+//
+// English:
+//
+//	Forwards the ssh agent of the host computer, defined by $SSH_AUTH_SOCK, to the image build, used by the
+//	Dockerfile with `RUN --mount=type=ssh go mod tidy`, so the private key never leaves the host computer.
+//
+//	 Notes:
+//	   * The file ~/.ssh/known_hosts, when it exists, is passed as the BuildKit secret known_hosts.
+//
+// Português:
+//
+//	Encaminha o agente ssh do computador hospedeiro, definido por $SSH_AUTH_SOCK, para a construção da imagem, usado
+//	pelo Dockerfile com `RUN --mount=type=ssh go mod tidy`, para que a chave privada nunca saia do computador
+//	hospedeiro.
+//
+//	 Notas:
+//	   * O arquivo ~/.ssh/known_hosts, quando existe, é passado como o segredo do BuildKit known_hosts.
+func (el *ContainerFromImage) SshAgentForward() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.sshAgentSocket = os.Getenv("SSH_AUTH_SOCK")
+	if el.sshAgentSocket == "" {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("container.SshAgentForward().error: SSH_AUTH_SOCK is not defined")
+		return el
+	}
+
+	var err error
+	var userData *user.User
+	var fileData []byte
+
+	userData, err = user.Current()
+	if err != nil {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("container.SshAgentForward().Current().error: %v", err)
+		return el
+	}
+
+	fileData, err = os.ReadFile(filepath.Join(userData.HomeDir, ".ssh", "known_hosts"))
+	if err == nil {
+		el.addBuildSecret(kSecretKnownHosts, string(fileData))
+	}
+
+	return el
+}
+
 // GetSshKeyFileName
 //
 // English:
@@ -3615,6 +5646,7 @@ func (el *ContainerFromImage) GitCloneToBuildWithPrivateToken(url, privateToken
 
 	el.gitUrl = url
 	el.gitPrivateToke = privateToken
+	monitor.AddSecret(privateToken)
 	return el
 }
 
@@ -3707,6 +5739,123 @@ func (el *ContainerFromImage) GetGitCloneToBuild() (url string) {
 	return el.gitUrl
 }
 
+// GitRef
+//
+// English:
+//
+//	Defines the branch, tag or commit to be cloned, instead of the default branch of the repository.
+//
+//	 Input:
+//	   value: branch name, tag name or commit SHA. e.g. "main", "v1.2.0", "3f1c2a9"
+//
+//	 Notes:
+//	   * Branches and tags are cloned alone, but a commit SHA needs the complete history of the repository,
+//	     therefore, GitDepth() is ignored when value is a commit.
+//
+// Português:
+//
+//	Define o branch, a tag ou o commit a ser clonado, em vez do branch padrão do repositório.
+//
+//	 Entrada:
+//	   value: nome do branch, nome da tag ou SHA do commit. ex. "main", "v1.2.0", "3f1c2a9"
+//
+//	 Notas:
+//	   * Branches e tags são clonados sozinhos, mas um SHA de commit precisa do histórico completo do repositório,
+//	     por isto, GitDepth() é ignorado quando value é um commit.
+func (el *ContainerFromImage) GitRef(value string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.gitRef = value
+	return el
+}
+
+// GitDepth
+//
+// English:
+//
+//	Makes a shallow clone, with the history truncated to the specified number of commits.
+//
+//	 Input:
+//	   depth: number of commits. Zero clones the full history
+//
+// Português:
+//
+//	Faz um clone raso, com o histórico truncado para o número de commits especificado.
+//
+//	 Entrada:
+//	   depth: número de commits. Zero clona o histórico completo
+func (el *ContainerFromImage) GitDepth(depth int) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.gitDepth = depth
+	return el
+}
+
+// GitSubmodules
+//
+// English:
+//
+//	Clones the git submodules recursively, after clone the repository.
+//
+// Português:
+//
+//	Clona os submódulos do git de forma recursiva, após clonar o repositório.
+func (el *ContainerFromImage) GitSubmodules() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.gitRecurseSubmodules = true
+	return el
+}
+
+// GitSubdir
+//
+// English:
+//
+//	Defines the folder, inside the repository, used as the build context, useful for monorepos.
+//
+//	 Input:
+//	   path: folder relative to the repository root. e.g. "services/payment"
+//
+//	 Notes:
+//	   * The Dockerfile is searched inside this folder.
+//
+// Português:
+//
+//	Define a pasta, dentro do repositório, usada como contexto de construção, útil para monorepos.
+//
+//	 Entrada:
+//	   path: pasta relativa a raiz do repositório. ex. "services/payment"
+//
+//	 Notas:
+//	   * O Dockerfile é procurado dentro desta pasta.
+func (el *ContainerFromImage) GitSubdir(path string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.gitSubdir = path
+	return el
+}
+
+// GetGitCommit
+//
+// English:
+//
+//	Returns the commit SHA used to build the image, after Create().
+//
+// Português:
+//
+//	Retorna o SHA do commit usado para construir a imagem, após Create().
+func (el *ContainerFromImage) GetGitCommit() (commit string) {
+	return el.gitCommit
+}
+
 // GitSshPassword
 //
 // English:
@@ -3773,6 +5922,7 @@ func (el *ContainerFromImage) GitSshPassword(password string) (ref *ContainerFro
 	}
 
 	el.gitPassword = password
+	monitor.AddSecret(password)
 	return el
 }
 
@@ -3792,6 +5942,7 @@ func (el *ContainerFromImage) GitPassword(password string) (ref *ContainerFromIm
 
 	// Archives git password
 	el.gitPassword = password
+	monitor.AddSecret(password)
 	return el
 }
 
@@ -3802,6 +5953,7 @@ func (el *ContainerFromImage) GitPrivateToken(token string) (ref *ContainerFromI
 
 	// Archive private git access token
 	el.gitPrivateToke = token
+	monitor.AddSecret(token)
 	return el
 }
 
@@ -3939,9 +6091,216 @@ func (el *ContainerFromImage) GitCloneToBuildWithPrivateSSHKey(url, privateSSHKe
 	el.gitUrl = url
 	el.gitSshPrivateKeyPath = privateSSHKeyPath
 	el.gitPassword = password
+	monitor.AddSecret(password)
 	return el
 }
 
+// gitClone
+//
+// Clones the repository into dir, following GitRef(), GitDepth() and GitSubmodules(), and returns the commit SHA
+// used
+func (el *ContainerFromImage) gitClone(dir string, options *git.CloneOptions) (commit string, err error) {
+	var repository *git.Repository
+
+	options.Depth = el.gitDepth
+	if el.gitRecurseSubmodules == true {
+		options.RecurseSubmodules = git.DefaultSubmoduleRecursionDepth
+	}
+
+	if el.gitRef != "" {
+		var reference plumbing.ReferenceName
+		reference, err = el.gitFindReference(options)
+		if err != nil {
+			err = fmt.Errorf("container.gitClone().gitFindReference().error: %v", err)
+			return
+		}
+
+		if reference != "" {
+			options.ReferenceName = reference
+			options.SingleBranch = true
+		} else {
+			// a commit can be anywhere in the history
+			options.Depth = 0
+			options.NoCheckout = true
+			options.RecurseSubmodules = git.NoRecurseSubmodules
+		}
+	}
+
+	repository, err = git.PlainClone(dir, false, options)
+	if err != nil {
+		err = fmt.Errorf("container.gitClone().PlainClone().error: %v", err)
+		return
+	}
+
+	if options.NoCheckout == true {
+		err = el.gitCheckoutCommit(repository)
+		if err != nil {
+			err = fmt.Errorf("container.gitClone().gitCheckoutCommit().error: %v", err)
+			return
+		}
+	}
+
+	var head *plumbing.Reference
+	head, err = repository.Head()
+	if err != nil {
+		err = fmt.Errorf("container.gitClone().Head().error: %v", err)
+		return
+	}
+
+	commit = head.Hash().String()
+	return
+}
+
+// gitFindReference
+//
+// Looks for a branch or a tag named as GitRef() on the remote repository. Returns an empty reference when
+// GitRef() is not a branch or a tag
+func (el *ContainerFromImage) gitFindReference(options *git.CloneOptions) (reference plumbing.ReferenceName, err error) {
+	var references []*plumbing.Reference
+	var remote = git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{options.URL}})
+	references, err = remote.List(&git.ListOptions{Auth: options.Auth})
+	if err != nil {
+		err = fmt.Errorf("container.gitFindReference().List().error: %v", err)
+		return
+	}
+
+	var branch = plumbing.NewBranchReferenceName(el.gitRef)
+	var tag = plumbing.NewTagReferenceName(el.gitRef)
+	for _, found := range references {
+		if found.Name() == branch || found.Name() == tag {
+			reference = found.Name()
+			return
+		}
+	}
+
+	return
+}
+
+// gitCheckoutCommit
+//
+// Checks out the commit defined by GitRef() and updates the submodules, when GitSubmodules() is defined
+func (el *ContainerFromImage) gitCheckoutCommit(repository *git.Repository) (err error) {
+	var hash *plumbing.Hash
+	hash, err = repository.ResolveRevision(plumbing.Revision(el.gitRef))
+	if err != nil {
+		err = fmt.Errorf("container.gitCheckoutCommit().ResolveRevision(%v).error: %v", el.gitRef, err)
+		return
+	}
+
+	var worktree *git.Worktree
+	worktree, err = repository.Worktree()
+	if err != nil {
+		err = fmt.Errorf("container.gitCheckoutCommit().Worktree().error: %v", err)
+		return
+	}
+
+	err = worktree.Checkout(&git.CheckoutOptions{Hash: *hash, Force: true})
+	if err != nil {
+		err = fmt.Errorf("container.gitCheckoutCommit().Checkout().error: %v", err)
+		return
+	}
+
+	if el.gitRecurseSubmodules == false {
+		return
+	}
+
+	var submodules git.Submodules
+	submodules, err = worktree.Submodules()
+	if err != nil {
+		err = fmt.Errorf("container.gitCheckoutCommit().Submodules().error: %v", err)
+		return
+	}
+
+	err = submodules.Update(&git.SubmoduleUpdateOptions{Init: true, RecurseSubmodules: git.DefaultSubmoduleRecursionDepth})
+	if err != nil {
+		err = fmt.Errorf("container.gitCheckoutCommit().Update().error: %v", err)
+		return
+	}
+
+	return
+}
+
+// gitContextDir
+//
+// Returns the build context folder, defined by GitSubdir(), inside the cloned repository
+func (el *ContainerFromImage) gitContextDir(dir string) (contextDir string, err error) {
+	if el.gitSubdir == "" {
+		contextDir = dir
+		return
+	}
+
+	contextDir = filepath.Join(dir, filepath.Clean(string(filepath.Separator)+el.gitSubdir))
+
+	var info os.FileInfo
+	info, err = os.Stat(contextDir)
+	if err != nil {
+		err = fmt.Errorf("container.gitContextDir().Stat().error: %v", err)
+		return
+	}
+
+	if !info.IsDir() {
+		err = fmt.Errorf("container.gitContextDir().error: %v is not a folder", el.gitSubdir)
+		return
+	}
+
+	return
+}
+
+// gitImageMatches
+//
+// Returns true when the labels of the image found by checkImageExpirationTimeIsValid() record the revision of
+// GitRef(). A branch or a tag must be the same, a commit SHA must be the prefix of the commit of the image
+func (el *ContainerFromImage) gitImageMatches() (matches bool) {
+	var commit = el.imageLabel(kLabelGitCommit)
+	if commit == "" || el.imageLabel(kLabelGitRef) != el.gitRef {
+		return false
+	}
+
+	if gitCommitRegexp.MatchString(el.gitRef) {
+		return strings.HasPrefix(commit, strings.ToLower(el.gitRef))
+	}
+
+	return true
+}
+
+// gitReport
+//
+// Writes the git revision used to build the image in the report folder
+func (el *ContainerFromImage) gitReport(imageName string) (err error) {
+	var ref = el.gitRef
+	if ref == "" {
+		ref = "default branch"
+	}
+
+	var subdir = el.gitSubdir
+	if subdir == "" {
+		subdir = "/"
+	}
+
+	reportText := "# Build Report\n\n"
+	reportText += fmt.Sprintf("| Image   | Ref   | Commit   | Folder   |\n")
+	reportText += fmt.Sprintf("|---------|-------|----------|----------|\n")
+	reportText += fmt.Sprintf("| %v | %v | %v | %v |\n\n", imageName, ref, el.gitCommit, subdir)
+
+	// the image name can have a registry and a tag, e.g. registry:5000/app:v1, build.registry_5000_app_v1.md
+	var fileName = fmt.Sprintf("build.%v.md", gitReportNameRegexp.ReplaceAllString(imageName, "_"))
+	return os.WriteFile(filepath.Join(testPathGlobal, fileName), []byte(monitor.Redact(reportText)), fs.ModePerm)
+}
+
+// imageLabel
+//
+// Returns the value of a label from the image, or an empty string
+func (el *ContainerFromImage) imageLabel(label string) (value string) {
+	var err error
+	var inspect types.ImageInspect
+	inspect, err = el.manager.DockerSys[0].ImageInspect(el.imageId)
+	if err != nil || inspect.Config == nil {
+		return
+	}
+
+	return inspect.Config.Labels[label]
+}
+
 // gitMakePublicSshKey
 //
 // English:
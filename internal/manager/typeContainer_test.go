@@ -1,9 +1,18 @@
 package manager
 
 import (
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/helmutkemper/chaos/internal/builder"
+	"github.com/helmutkemper/chaos/internal/monitor"
 	"github.com/helmutkemper/chaos/internal/standalone"
+	"io/fs"
 	"log"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -102,3 +111,619 @@ func TestContainerFromImage_Primordial(t *testing.T) {
 //
 //
 //
+
+// gitTestRepository
+//
+// Creates a bare repository with two commits, the tag v1.0.0 and the branch release on the first commit. The first
+// commit has services/app/Dockerfile and the last one adds services/app/main.go
+func gitTestRepository(t *testing.T) (bareDir string, first, last plumbing.Hash) {
+	var err error
+	var workDir = t.TempDir()
+	bareDir = t.TempDir()
+
+	var repository *git.Repository
+	repository, err = git.PlainInit(workDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var worktree *git.Worktree
+	worktree, err = repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var commit = func(file, content string) (hash plumbing.Hash) {
+		err = os.MkdirAll(filepath.Dir(filepath.Join(workDir, file)), fs.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(filepath.Join(workDir, file), []byte(content), fs.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = worktree.Add(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hash, err = worktree.Commit(content, &git.CommitOptions{
+			Author: &object.Signature{Name: "chaos", Email: "chaos@example.com", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	first = commit("services/app/Dockerfile", "FROM scratch")
+	_, err = repository.CreateTag("v1.0.0", first, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last = commit("services/app/main.go", "package main")
+
+	var bare *git.Repository
+	bare, err = git.PlainClone(bareDir, true, &git.CloneOptions{URL: workDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = bare.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("release"), first))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestContainerFromImage_gitClone(t *testing.T) {
+	var err error
+	var bareDir, first, last = gitTestRepository(t)
+
+	var tests = []struct {
+		ref    string
+		depth  int
+		commit plumbing.Hash
+		file   string
+		exists bool
+	}{
+		{ref: "", depth: 1, commit: last, file: "main.go", exists: true},
+		{ref: "master", depth: 1, commit: last, file: "main.go", exists: true},
+		{ref: "v1.0.0", depth: 1, commit: first, file: "main.go", exists: false},
+		{ref: "release", depth: 1, commit: first, file: "main.go", exists: false},
+		{ref: first.String(), depth: 1, commit: first, file: "main.go", exists: false},
+		{ref: first.String()[:7], depth: 1, commit: first, file: "main.go", exists: false},
+		{ref: first.String()[:7], depth: 0, commit: first, file: "Dockerfile", exists: true},
+	}
+
+	for _, test := range tests {
+		var dir = t.TempDir()
+		var container = &ContainerFromImage{}
+		container.gitRef = test.ref
+		container.gitDepth = test.depth
+		container.gitSubdir = "services/app"
+
+		var sha string
+		sha, err = container.gitClone(dir, &git.CloneOptions{URL: "file://" + bareDir})
+		if err != nil {
+			t.Fatalf("ref %q: %v", test.ref, err)
+		}
+
+		if sha != test.commit.String() {
+			t.Errorf("ref %q: commit %v, expected %v", test.ref, sha, test.commit)
+		}
+
+		var contextDir string
+		contextDir, err = container.gitContextDir(dir)
+		if err != nil {
+			t.Fatalf("ref %q: %v", test.ref, err)
+		}
+
+		_, err = os.Stat(filepath.Join(contextDir, test.file))
+		if (err == nil) != test.exists {
+			t.Errorf("ref %q: file %v exists: %v, expected %v", test.ref, test.file, err == nil, test.exists)
+		}
+	}
+
+}
+
+func TestContainerFromImage_gitFindReference(t *testing.T) {
+	var bareDir, first, _ = gitTestRepository(t)
+
+	var tests = []struct {
+		ref       string
+		reference plumbing.ReferenceName
+	}{
+		{ref: "master", reference: plumbing.NewBranchReferenceName("master")},
+		{ref: "release", reference: plumbing.NewBranchReferenceName("release")},
+		{ref: "v1.0.0", reference: plumbing.NewTagReferenceName("v1.0.0")},
+		{ref: first.String(), reference: ""},
+		{ref: first.String()[:7], reference: ""},
+		{ref: "unknown", reference: ""},
+	}
+
+	for _, test := range tests {
+		var container = &ContainerFromImage{}
+		container.gitRef = test.ref
+
+		reference, err := container.gitFindReference(&git.CloneOptions{URL: "file://" + bareDir})
+		if err != nil {
+			t.Fatalf("ref %q: %v", test.ref, err)
+		}
+
+		if reference != test.reference {
+			t.Errorf("ref %q: reference %q, expected %q", test.ref, reference, test.reference)
+		}
+	}
+
+	var container = &ContainerFromImage{}
+	container.gitRef = "master"
+	if _, err := container.gitFindReference(&git.CloneOptions{URL: "file://" + filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Errorf("gitFindReference must fail when the repository does not exist")
+	}
+}
+
+func TestContainerFromImage_gitCheckoutCommit(t *testing.T) {
+	var bareDir, first, last = gitTestRepository(t)
+
+	var tests = []struct {
+		ref    string
+		commit plumbing.Hash
+		exists bool
+		err    bool
+	}{
+		{ref: first.String(), commit: first, exists: false},
+		{ref: first.String()[:7], commit: first, exists: false},
+		{ref: last.String(), commit: last, exists: true},
+		{ref: "0000000", err: true},
+	}
+
+	for _, test := range tests {
+		var dir = t.TempDir()
+		repository, err := git.PlainClone(dir, false, &git.CloneOptions{URL: "file://" + bareDir, NoCheckout: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var container = &ContainerFromImage{}
+		container.gitRef = test.ref
+		err = container.gitCheckoutCommit(repository)
+		if test.err {
+			if err == nil {
+				t.Errorf("ref %q: gitCheckoutCommit must fail", test.ref)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("ref %q: %v", test.ref, err)
+		}
+
+		head, err := repository.Head()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if head.Hash() != test.commit {
+			t.Errorf("ref %q: commit %v, expected %v", test.ref, head.Hash(), test.commit)
+		}
+
+		if _, err = os.Stat(filepath.Join(dir, "services", "app", "Dockerfile")); err != nil {
+			t.Errorf("ref %q: the files of the commit must be checked out: %v", test.ref, err)
+		}
+
+		_, err = os.Stat(filepath.Join(dir, "services", "app", "main.go"))
+		if (err == nil) != test.exists {
+			t.Errorf("ref %q: main.go exists: %v, expected %v", test.ref, err == nil, test.exists)
+		}
+	}
+}
+
+func TestContainerFromImage_gitContextDir(t *testing.T) {
+	// the folder outside exists next to the repository, and must not be reached by the subdir
+	var parent = t.TempDir()
+	var dir = filepath.Join(parent, "repository")
+	for _, folder := range []string{filepath.Join(dir, "services", "app"), filepath.Join(parent, "outside")} {
+		if err := os.MkdirAll(folder, fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "services", "main.go"), []byte("package main"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		subdir     string
+		contextDir string
+		err        bool
+	}{
+		{subdir: "", contextDir: dir},
+		{subdir: "services/app", contextDir: filepath.Join(dir, "services", "app")},
+		{subdir: "/services/app/", contextDir: filepath.Join(dir, "services", "app")},
+		{subdir: "services/../services/app", contextDir: filepath.Join(dir, "services", "app")},
+		{subdir: "../outside", err: true},
+		{subdir: "../../etc", err: true},
+		{subdir: "services/main.go", err: true},
+		{subdir: "missing", err: true},
+	}
+
+	for _, test := range tests {
+		var container = &ContainerFromImage{}
+		container.gitSubdir = test.subdir
+
+		contextDir, err := container.gitContextDir(dir)
+		if test.err {
+			if err == nil {
+				t.Errorf("subdir %q: gitContextDir must fail, context %v", test.subdir, contextDir)
+			}
+			continue
+		}
+
+		if err != nil || contextDir != test.contextDir {
+			t.Errorf("subdir %q: context %v, expected %v: %v", test.subdir, contextDir, test.contextDir, err)
+		}
+	}
+}
+
+// monitorErrReset
+//
+// Clears the error flag left by the previous tests, the configuration methods do nothing while it is set
+func monitorErrReset(t *testing.T) {
+	var err = monitor.Err
+	monitor.Err = false
+	t.Cleanup(func() {
+		monitor.Err = err
+	})
+}
+
+func TestContainerFromImage_mapHostConfig(t *testing.T) {
+	monitorErrReset(t)
+
+	var el = new(ContainerFromImage)
+	el.manager = new(Manager)
+	el.Memory(64*1024*1024, 128*1024*1024).
+		PidsLimit(100).
+		BlkioDeviceWriteBps("/dev/sda", 0, 1024*1024).
+		Ulimit("nofile", 1024, 2048).
+		OomKillDisable().
+		Tmpfs("/tmp", 16*1024*1024)
+
+	if el.manager.ImageBuildOptions.Memory != 64*1024*1024 {
+		t.Errorf("the image build must receive the first value: %v", el.manager.ImageBuildOptions.Memory)
+	}
+
+	var first = el.mapHostConfig(0)
+	var second = el.mapHostConfig(1)
+	var third = el.mapHostConfig(2)
+
+	if first.Memory != 64*1024*1024 || second.Memory != 128*1024*1024 || third.Memory != 0 {
+		t.Errorf("memory per copy: %v, %v, %v", first.Memory, second.Memory, third.Memory)
+	}
+
+	if first.PidsLimit == nil || *first.PidsLimit != 100 || third.PidsLimit == nil || *third.PidsLimit != 100 {
+		t.Errorf("one value must be applied to all copies")
+	}
+
+	if len(first.BlkioDeviceWriteBps) != 0 || len(second.BlkioDeviceWriteBps) != 1 || second.BlkioDeviceWriteBps[0].Rate != 1024*1024 {
+		t.Errorf("block IO limit per copy: %v, %v", first.BlkioDeviceWriteBps, second.BlkioDeviceWriteBps)
+	}
+
+	if len(third.Ulimits) != 1 || third.OomKillDisable == nil || !*third.OomKillDisable {
+		t.Errorf("ulimits and oom kill disable must be applied to all copies")
+	}
+
+	if third.Tmpfs["/tmp"] != "size=16777216" {
+		t.Errorf("tmpfs: %v", third.Tmpfs)
+	}
+}
+
+func TestContainerFromImage_mapVolumes(t *testing.T) {
+	monitorErrReset(t)
+
+	var el = new(ContainerFromImage)
+	el.manager = new(Manager)
+	el.containerName = "delete_mongo"
+	el.Volumes("/config", "/tmp/a").
+		VolumesReadOnly("/certs", "/tmp/b", "/tmp/c").
+		VolumeNamed("/data/db", "data", false).
+		VolumeSeed("/data/db", "/tmp/seed")
+
+	var second = el.mapVolumes(1)
+	if len(second) != 2 {
+		t.Fatalf("the copy without host path must receive only the named volume and the read only path: %+v", second)
+	}
+
+	if second[0].Source != "/tmp/c" || !second[0].ReadOnly {
+		t.Errorf("read only bind: %+v", second[0])
+	}
+
+	if second[1].Type != "volume" || second[1].Source != "delete_mongo_1_data" || second[1].ReadOnly {
+		t.Errorf("named volume: %+v", second[1])
+	}
+
+	if el.volumeSeed[2] != "/tmp/seed" {
+		t.Errorf("seed: %v", el.volumeSeed)
+	}
+}
+
+func TestContainerFromImage_platformImageName(t *testing.T) {
+	var container = new(ContainerFromImage)
+	var tests = []struct {
+		imageName string
+		platform  string
+		expected  string
+	}{
+		{"delete_app:latest", "linux/arm64", "delete_app:latest-linux-arm64"},
+		{"mongo", "linux/arm64", "mongo:latest-linux-arm64"},
+		{"mongo:6.0", "linux/arm/v7", "mongo:6.0-linux-arm-v7"},
+		{"host:5000/img", "linux/amd64", "host:5000/img:latest-linux-amd64"},
+		{"host:5000/img:1.2", "linux/amd64", "host:5000/img:1.2-linux-amd64"},
+		{"mongo@sha256:0123456789abcdef0123", "linux/arm64", "mongo:0123456789ab-linux-arm64"},
+		{"mongo", "", "mongo"},
+	}
+
+	for _, test := range tests {
+		if name := container.platformImageName(test.imageName, test.platform); name != test.expected {
+			t.Errorf("platformImageName(%v, %v): expected %v, got %v", test.imageName, test.platform, test.expected, name)
+		}
+	}
+}
+
+func TestContainerFromImage_gitReport(t *testing.T) {
+	var path = testPathGlobal
+	testPathGlobal = t.TempDir()
+	t.Cleanup(func() {
+		testPathGlobal = path
+	})
+
+	var container = new(ContainerFromImage)
+	container.gitRef = "v1.2.0"
+	container.gitCommit = "3f1c2a9"
+	if err := container.gitReport("registry:5000/team/app:v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(testPathGlobal, "build.registry_5000_team_app_v1.md")); err != nil {
+		t.Errorf("the name of the report must not have folders: %v", err)
+	}
+}
+
+func TestContainerFromImage_chaosTimeline(t *testing.T) {
+	var path = testPathGlobal
+	testPathGlobal = t.TempDir()
+	t.Cleanup(func() {
+		testPathGlobal = path
+	})
+
+	var el = new(ContainerFromImage)
+	el.containerName = "delete_mongo"
+
+	var done = make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i != 100; i += 1 {
+			el.chaosTimelineAdd(ChaosEvent{Time: time.Now(), Container: "delete_mongo_0", Action: "pause()"})
+		}
+	}()
+
+	for i := 0; i != 100; i += 1 {
+		_ = el.GetChaosTimeline()
+	}
+	<-done
+
+	var timeline = el.GetChaosTimeline()
+	timeline[0].Action = "changed"
+	if len(timeline) != 100 || el.GetChaosTimeline()[0].Action != "pause()" {
+		t.Errorf("GetChaosTimeline() must return a copy of the timeline")
+	}
+
+	el.chaosTimelineWrite()
+	data, err := os.ReadFile(filepath.Join(testPathGlobal, "chaos.delete_mongo.csv"))
+	if err != nil || strings.Count(string(data), "\n") != 101 {
+		t.Errorf("unexpected chaos.delete_mongo.csv: %v", err)
+	}
+}
+
+func TestContainerFromImage_chaosMountActionsList(t *testing.T) {
+	var tests = []struct {
+		name       string
+		maxStopped int
+		maxPaused  int
+		sameTime   int
+		want       map[string]int
+	}{
+		{name: "every kind capped", maxStopped: 1, maxPaused: 1, want: map[string]int{"stop": 1, "pause": 1, "pressure": 1}},
+		{name: "stop and pause capped together", maxStopped: 1, maxPaused: 1, sameTime: 1, want: map[string]int{"stop+pause": 1, "pressure": 1}},
+		{name: "limited to the pressure", maxStopped: -1, maxPaused: -1, want: map[string]int{"pressure": 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var el = new(ContainerFromImage)
+			el.manager = new(Manager)
+			el.copies = 4
+			el.manager.Id = make([]string, el.copies)
+			el.manager.DockerSys = make([]*builder.DockerSystem, el.copies)
+			el.manager.Chaos = make([]Chaos, el.copies)
+			el.ChaosMaxStopped = test.maxStopped
+			el.ChaosMaxPaused = test.maxPaused
+			el.ChaosMaxPausedStoppedSameTime = test.sameTime
+			el.pressure.cpuMin = 0.1
+			el.pressure.cpuMax = 0.5
+			el.pressure.maxPressured = 1
+
+			var done = make(chan struct{})
+			go func() {
+				defer close(done)
+				el.chaosMountActionsList()
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("chaosMountActionsList() does not return when every kind hits its maximum")
+			}
+
+			var got = make(map[string]int)
+			for iCopy := 0; iCopy != el.copies; iCopy += 1 {
+				switch el.manager.Chaos[iCopy].Type {
+				case "":
+				case "throttle", "stress":
+					got["pressure"] += 1
+				case "stop", "pause":
+					if test.sameTime != 0 {
+						got["stop+pause"] += 1
+					} else {
+						got[el.manager.Chaos[iCopy].Type] += 1
+					}
+				default:
+					got[el.manager.Chaos[iCopy].Type] += 1
+				}
+			}
+
+			if len(got) != len(test.want) {
+				t.Fatalf("unexpected chaos: %v, want %v", got, test.want)
+			}
+			for kind, count := range test.want {
+				if got[kind] != count {
+					t.Errorf("unexpected chaos: %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestChaosActionsRemove(t *testing.T) {
+	var list = chaosActionsRemove([]string{"stop", "pause", "throttle", "stress"}, "throttle", "stress")
+	if strings.Join(list, ",") != "stop,pause" {
+		t.Errorf("unexpected list: %v", list)
+	}
+
+	if list = chaosActionsRemove(list, "stop", "pause"); len(list) != 0 {
+		t.Errorf("unexpected list: %v", list)
+	}
+}
+
+func TestContainerFromImage_coverageCopy(t *testing.T) {
+	var path = testPathGlobal
+	testPathGlobal = t.TempDir()
+	t.Cleanup(func() {
+		testPathGlobal = path
+	})
+
+	// the data of a previous test is removed
+	var stale = filepath.Join(testPathGlobal, "coverage", "delete_app_0", "covmeta.old")
+	if err := os.MkdirAll(filepath.Dir(stale), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("old"), fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	var el = new(ContainerFromImage)
+	el.containerName = "delete_app"
+
+	var env = make([]string, 1, 2)
+	env[0] = "MODE=test"
+	environment, volume, err := el.coverageCopy(0, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the spare capacity of the list of the user is not used
+	if strings.Join(environment, " ") != "MODE=test GOCOVERDIR="+kCoverageContainerDir || env[:2][1] != "" {
+		t.Errorf("unexpected environment: %v, the list of the user must not change: %v", environment, env[:2])
+	}
+
+	var dir = filepath.Join(testPathGlobal, "coverage", "delete_app_0")
+	if volume.Type != "bind" || volume.Source != dir || volume.Target != kCoverageContainerDir {
+		t.Errorf("unexpected volume: %+v", volume)
+	}
+
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("the folder must be empty: %v, %v", entries, err)
+	}
+
+	if len(el.coverageHostDir) != 1 || el.coverageHostDir[0] != dir {
+		t.Errorf("unexpected folders: %v", el.coverageHostDir)
+	}
+}
+
+func TestContainerFromImage_coverageEnd(t *testing.T) {
+	var goBinary, err = exec.LookPath("go")
+	if err != nil {
+		t.Skipf("go: %v", err)
+	}
+
+	var path = testPathGlobal
+	testPathGlobal = t.TempDir()
+	t.Cleanup(func() {
+		testPathGlobal = path
+	})
+
+	// program built with -cover, as by the Dockerfile of EnableCoverage()
+	var src = t.TempDir()
+	var files = map[string]string{
+		"go.mod":  "module cover\n\ngo 1.20\n",
+		"main.go": "package main\n\nfunc main() {\n\tprintln(\"ok\")\n}\n",
+	}
+	for name, data := range files {
+		if err = os.WriteFile(filepath.Join(src, name), []byte(data), fs.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var build = exec.Command(goBinary, "build", "-cover", "-o", "main", ".")
+	build.Dir = src
+	build.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=")
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Skipf("go build -cover: %v: %s", err, output)
+	}
+
+	var el = new(ContainerFromImage)
+	el.containerName = "delete_app"
+	el.coverage = true
+	el.manager = &Manager{DoneCh: make(chan struct{}, 1)}
+
+	// each copy writes in its own folder
+	for i := 0; i != 2; i += 1 {
+		var environment []string
+		if environment, _, err = el.coverageCopy(i, os.Environ()); err != nil {
+			t.Fatal(err)
+		}
+
+		var run = exec.Command(filepath.Join(src, "main"))
+		run.Env = append(environment, "GOCOVERDIR="+el.coverageHostDir[i])
+		if output, err := run.CombinedOutput(); err != nil {
+			t.Fatalf("main: %v: %s", err, output)
+		}
+	}
+
+	// without chaos, End() collects the coverage before the test ends
+	el.End()
+	select {
+	case <-el.manager.DoneCh:
+	default:
+		t.Errorf("End() must signal the end of the container")
+	}
+
+	var profile = filepath.Join(testPathGlobal, "coverage.delete_app.out")
+	data, err := os.ReadFile(profile)
+	if err != nil || !strings.HasPrefix(string(data), "mode: set") || !strings.Contains(string(data), "cover/main.go") {
+		t.Fatalf("unexpected profile: %s, %v", data, err)
+	}
+
+	// the cleanup of Test() doesn't collect it again
+	if err = os.Remove(profile); err != nil {
+		t.Fatal(err)
+	}
+	el.coverageEnd()
+	if _, err = os.Stat(profile); !os.IsNotExist(err) {
+		t.Errorf("the coverage must be collected once: %v", err)
+	}
+}
@@ -0,0 +1,427 @@
+package manager
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"github.com/helmutkemper/chaos/internal/standalone"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions of the forensics, shown in the report
+const (
+	kForensicsCommit     = "commit"
+	kForensicsCopy       = "copy"
+	kForensicsExec       = "exec"
+	kForensicsGoroutines = "goroutines"
+)
+
+// kLabelForensics identifies the images of ForensicsCommit(), with the session as value. The images don't have the
+// session labels, so they are kept by the garbage collector
+const kLabelForensics = "chaos.forensics"
+
+// kForensicsExecTimeout is the maximum time of each command of ForensicsExec()
+const kForensicsExecTimeout = time.Minute
+
+// kForensicsGoroutinesTimeout is the maximum time for the container to write the goroutine dump and stop
+const kForensicsGoroutinesTimeout = 10 * time.Second
+
+// Containers with forensics, executed once in the cleanup of Test() when a fail flag was found
+var forensicsGlobal = new(forensicsRegistry)
+
+// forensicsCommand
+//
+// Diagnostic command of ForensicsExec()
+type forensicsCommand struct {
+	name    string
+	command []string
+}
+
+// ForensicsCommit
+//
+// English:
+//
+//	When a fail flag was found, in the cleanup of Test(), saves the state of each copy as an image, like docker commit,
+//	tagged as chaos-forensics/<name>:<session>, e.g. chaos-forensics/delete_mongo.0:20221120T131500-a1b2c3d4.
+//
+//	 Notes:
+//	   * The image is kept after the test, remove it with docker rmi;
+//	   * Run the image with docker run --rm -it --entrypoint sh chaos-forensics/delete_mongo.0:<session>.
+//
+// Português:
+//
+//	Quando uma flag de falha foi encontrada, na limpeza de Test(), salva o estado de cada cópia como uma imagem, como o
+//	docker commit, com a tag chaos-forensics/<nome>:<sessão>,
+//	ex. chaos-forensics/delete_mongo.0:20221120T131500-a1b2c3d4.
+//
+//	 Notas:
+//	   * A imagem é mantida depois do teste, remova-a com docker rmi;
+//	   * Execute a imagem com docker run --rm -it --entrypoint sh chaos-forensics/delete_mongo.0:<sessão>.
+func (el *ContainerFromImage) ForensicsCommit() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.forensicsCommit = true
+	return el
+}
+
+// ForensicsCopy
+//
+// English:
+//
+//	When a fail flag was found, in the cleanup of Test(), copies the files and folders of each copy to the save folder
+//	of Test(), in forensics/<name>/files, keeping the path of the container.
+//
+//	 Input:
+//	   paths: absolute paths inside the container, e.g. /data/db
+//
+//	 Notes:
+//	   * Only folders and regular files are copied, links are ignored.
+//
+// Português:
+//
+//	Quando uma flag de falha foi encontrada, na limpeza de Test(), copia os arquivos e pastas de cada cópia para a pasta
+//	de Test(), em forensics/<nome>/files, mantendo o caminho do container.
+//
+//	 Entrada:
+//	   paths: caminhos absolutos dentro do container, ex. /data/db
+//
+//	 Notas:
+//	   * Apenas pastas e arquivos regulares são copiados, links são ignorados.
+func (el *ContainerFromImage) ForensicsCopy(paths ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	for _, containerPath := range paths {
+		if !path.IsAbs(containerPath) {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container.ForensicsCopy().error: the path %v must be absolute", containerPath)
+			return el
+		}
+	}
+
+	el.forensicsPaths = append(el.forensicsPaths, paths...)
+	return el
+}
+
+// ForensicsExec
+//
+// English:
+//
+//	When a fail flag was found, in the cleanup of Test(), runs a diagnostic command inside each copy and saves the exit
+//	code and the outputs in forensics/<name>/exec.<command name>.txt, in the save folder of Test().
+//
+//	 Input:
+//	   name: name of the file, e.g. rs.status
+//	   command: command and arguments, e.g. "mongosh", "--quiet", "--eval", "rs.status()"
+//
+//	 Notes:
+//	   * The command is stopped after one minute;
+//	   * The secrets are redacted from the outputs.
+//
+// Português:
+//
+//	Quando uma flag de falha foi encontrada, na limpeza de Test(), executa um comando de diagnóstico dentro de cada
+//	cópia e salva o código de saída e as saídas em forensics/<nome>/exec.<nome do comando>.txt, na pasta de Test().
+//
+//	 Entrada:
+//	   name: nome do arquivo, ex. rs.status
+//	   command: comando e argumentos, ex. "mongosh", "--quiet", "--eval", "rs.status()"
+//
+//	 Notas:
+//	   * O comando é interrompido depois de um minuto;
+//	   * Os segredos são ocultados das saídas.
+func (el *ContainerFromImage) ForensicsExec(name string, command ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	if name == "" || strings.ContainsAny(name, `/\`) || len(command) == 0 {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("container.ForensicsExec().error: %v", "the name must be a valid file name and the command must not be empty")
+		return el
+	}
+
+	el.forensicsExec = append(el.forensicsExec, forensicsCommand{name: name, command: command})
+	return el
+}
+
+// ForensicsGoroutineDump
+//
+// English:
+//
+//	When a fail flag was found, in the cleanup of Test(), sends SIGQUIT to the main process of each copy and saves the
+//	goroutine dump of the Go runtime in forensics/<name>/goroutines.txt, in the save folder of Test().
+//
+//	 Notes:
+//	   * The Go runtime exits after the dump, so the container stops and the test must already be failing;
+//	   * Runs after the other forensics actions, which need the container running.
+//
+// Português:
+//
+//	Quando uma flag de falha foi encontrada, na limpeza de Test(), envia SIGQUIT para o processo principal de cada cópia
+//	e salva o dump das goroutines do runtime Go em forensics/<nome>/goroutines.txt, na pasta de Test().
+//
+//	 Notas:
+//	   * O runtime Go termina depois do dump, então o container para e o teste já deve estar falhando;
+//	   * Executado depois das outras ações forenses, que precisam do container rodando.
+func (el *ContainerFromImage) ForensicsGoroutineDump() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.forensicsGoroutines = true
+	return el
+}
+
+// forensicsEnabled
+//
+// Returns true when at least one forensics action was defined
+func (el *ContainerFromImage) forensicsEnabled() (enabled bool) {
+	return el.forensicsCommit || len(el.forensicsPaths) != 0 || len(el.forensicsExec) != 0 || el.forensicsGoroutines
+}
+
+// forensicsRegistry
+//
+// Containers with forensics of the test
+type forensicsRegistry struct {
+	mutex      sync.Mutex
+	containers []*ContainerFromImage
+	failed     bool
+	done       bool
+}
+
+// add
+//
+// Registers the container, called by Start()
+func (el *forensicsRegistry) add(container *ContainerFromImage) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	el.containers = append(el.containers, container)
+}
+
+// fail
+//
+// Marks the test as failed by a fail flag, called by failToLog()
+func (el *forensicsRegistry) fail() {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	el.failed = true
+}
+
+// run
+//
+// Executes the forensics of all containers once, after a fail flag, the state of the other containers also explains
+// the failure
+func (el *forensicsRegistry) run() {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	if !el.failed || el.done {
+		return
+	}
+	el.done = true
+
+	for _, container := range el.containers {
+		container.forensics()
+	}
+}
+
+// reset
+//
+// Removes the containers at the end of the test
+func (el *forensicsRegistry) reset() {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	el.containers = nil
+	el.failed = false
+	el.done = false
+}
+
+// forensics
+//
+// Executes the forensics actions in each copy and records them in the report
+func (el *ContainerFromImage) forensics() {
+	for i := 0; i != el.copies; i += 1 {
+		var labels = el.metricsLabels(i)
+		var record = func(action, detail, file string, err error) {
+			reportGlobal.forensics(labels, reportForensics{time: time.Now(), action: action, detail: detail, file: file, err: err})
+		}
+
+		var dir = filepath.Join(testPathGlobal, "forensics", el.copyReportName(i))
+		if err := os.MkdirAll(dir, fs.ModePerm); err != nil {
+			record(kForensicsCopy, dir, "", err)
+			continue
+		}
+
+		if el.forensicsCommit {
+			var reference = fmt.Sprintf("chaos-forensics/%v:%v", strings.ToLower(el.copyReportName(i)), standalone.SessionId())
+			var _, err = el.manager.DockerSys[i].ContainerCommit(el.copyId(i), reference, map[string]string{kLabelForensics: standalone.SessionId()})
+			record(kForensicsCommit, reference, "", err)
+		}
+
+		for _, containerPath := range el.forensicsPaths {
+			var destination = filepath.Join(dir, "files", filepath.FromSlash(path.Dir(containerPath)))
+			record(kForensicsCopy, containerPath, filepath.Join(dir, "files", filepath.FromSlash(containerPath)), el.forensicsCopy(i, containerPath, destination))
+		}
+
+		for _, command := range el.forensicsExec {
+			var file = filepath.Join(dir, "exec."+command.name+".txt")
+			record(kForensicsExec, strings.Join(command.command, " "), file, el.forensicsExecCommand(i, command.command, file))
+		}
+
+		if el.forensicsGoroutines {
+			var file = filepath.Join(dir, "goroutines.txt")
+			record(kForensicsGoroutines, "SIGQUIT", file, el.forensicsGoroutineDump(i, file))
+		}
+	}
+}
+
+// forensicsCopy
+//
+// Copies the path of the copy into the destination folder
+func (el *ContainerFromImage) forensicsCopy(iCopy int, containerPath, destination string) (err error) {
+	var reader io.ReadCloser
+	if reader, _, err = el.manager.DockerSys[iCopy].ContainerCopyFrom(el.copyId(iCopy), containerPath); err != nil {
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	return forensicsUntar(reader, destination)
+}
+
+// forensicsUntar
+//
+// Extracts the folders and regular files of the tar stream, keeping the names inside the destination folder
+func forensicsUntar(reader io.Reader, destination string) (err error) {
+	var archive = tar.NewReader(reader)
+	for {
+		var header *tar.Header
+		if header, err = archive.Next(); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return
+		}
+
+		// the name is cleaned as an absolute path, so ../ can't leave the destination
+		var target = filepath.Join(destination, filepath.FromSlash(path.Clean("/"+header.Name)))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, fs.ModePerm)
+		case tar.TypeReg:
+			err = forensicsUntarFile(archive, target)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// forensicsUntarFile
+//
+// Writes the current file of the tar stream
+func forensicsUntarFile(archive io.Reader, target string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(target), fs.ModePerm); err != nil {
+		return
+	}
+
+	var file *os.File
+	if file, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fs.ModePerm); err != nil {
+		return
+	}
+
+	if _, err = io.Copy(file, archive); err != nil {
+		_ = file.Close()
+		return
+	}
+
+	return file.Close()
+}
+
+// forensicsExecCommand
+//
+// Runs the command in the copy and saves the exit code and the outputs in the file
+func (el *ContainerFromImage) forensicsExecCommand(iCopy int, command []string, file string) (err error) {
+	var exitCode int
+	var stdOutput, stdError []byte
+	exitCode, stdOutput, stdError, err = el.manager.DockerSys[iCopy].ContainerExecOutput(el.copyId(iCopy), command, kForensicsExecTimeout)
+
+	var text = fmt.Sprintf("command: %v\nexit code: %v\n\nstdout:\n%s\n\nstderr:\n%s\n", strings.Join(command, " "), exitCode, stdOutput, stdError)
+	if errWrite := os.WriteFile(file, []byte(monitor.Redact(text)), fs.ModePerm); errWrite != nil && err == nil {
+		err = errWrite
+	}
+
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("exit code %v", exitCode)
+	}
+
+	return
+}
+
+// forensicsGoroutineDump
+//
+// Sends SIGQUIT to the copy and saves the log lines written after the signal, the goroutine dump of the Go runtime
+func (el *ContainerFromImage) forensicsGoroutineDump(iCopy int, file string) (err error) {
+	var dockerSys = el.manager.DockerSys[iCopy]
+	var id = el.copyId(iCopy)
+
+	var signal = time.Now()
+	if err = dockerSys.ContainerKill(id, "SIGQUIT"); err != nil {
+		return
+	}
+
+	// the runtime exits after the dump, the logs written until the timeout are saved anyway
+	var errWait = dockerSys.ContainerWaitStatusNotRunning(id, kForensicsGoroutinesTimeout)
+
+	var logs []byte
+	if logs, err = dockerSys.ContainerLogs(id); err != nil {
+		return
+	}
+
+	var dump = forensicsLogsSince(logs, signal)
+	if err = os.WriteFile(file, []byte(monitor.Redact(dump)), fs.ModePerm); err != nil {
+		return
+	}
+
+	if errWait != nil {
+		return fmt.Errorf("the container didn't stop after SIGQUIT: %v", errWait)
+	}
+
+	return
+}
+
+// forensicsLogsSince
+//
+// Returns the text of the log lines written since the time. The lines without timestamp follow the previous line
+func forensicsLogsSince(logs []byte, since time.Time) (text string) {
+	var builder strings.Builder
+	var previous time.Time
+	for _, line := range logLines(logs) {
+		if !line.time.IsZero() {
+			previous = line.time
+		}
+
+		if !previous.IsZero() && !previous.Before(since) {
+			builder.WriteString(line.text + "\n")
+		}
+	}
+
+	return builder.String()
+}
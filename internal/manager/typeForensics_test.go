@@ -0,0 +1,119 @@
+package manager
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForensicsUntar(t *testing.T) {
+	var buffer bytes.Buffer
+	var archive = tar.NewWriter(&buffer)
+	var add = func(header *tar.Header, data string) {
+		header.Size = int64(len(data))
+		header.Mode = 0644
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add(&tar.Header{Name: "db/", Typeflag: tar.TypeDir}, "")
+	add(&tar.Header{Name: "db/journal/wt.log", Typeflag: tar.TypeReg}, "journal")
+	add(&tar.Header{Name: "../../escape.txt", Typeflag: tar.TypeReg}, "escape")
+	add(&tar.Header{Name: "db/link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}, "")
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var dir = t.TempDir()
+	var destination = filepath.Join(dir, "files", "data")
+	if err := forensicsUntar(&buffer, destination); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(destination, "db", "journal", "wt.log")); err != nil || string(data) != "journal" {
+		t.Errorf("unexpected file: %s, %v", data, err)
+	}
+
+	// the name is kept inside the destination
+	if _, err := os.Stat(filepath.Join(destination, "escape.txt")); err != nil {
+		t.Errorf("the name with ../ must be extracted inside the destination: %v", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(destination, "db", "link")); !os.IsNotExist(err) {
+		t.Errorf("links must be ignored: %v", err)
+	}
+}
+
+func TestForensicsLogsSince(t *testing.T) {
+	var start = time.Date(2022, 11, 20, 13, 15, 0, 0, time.UTC)
+	var line = func(offset time.Duration, text string) string {
+		return start.Add(offset).Format(time.RFC3339Nano) + " " + text + "\n"
+	}
+
+	var logs = line(0, "ready") + line(2*time.Second, "SIGQUIT: quit") + "goroutine 1 [running]:\n" + line(3*time.Second, "exit status 2")
+	if text := forensicsLogsSince([]byte(logs), start.Add(time.Second)); text != "SIGQUIT: quit\ngoroutine 1 [running]:\nexit status 2\n" {
+		t.Errorf("unexpected dump: %q", text)
+	}
+}
+
+func TestForensicsRegistry(t *testing.T) {
+	var registry = new(forensicsRegistry)
+	registry.add(&ContainerFromImage{})
+
+	registry.run()
+	if registry.done {
+		t.Errorf("the forensics must run only after a fail flag")
+	}
+
+	registry.fail()
+	registry.run()
+	if !registry.done {
+		t.Errorf("the forensics must be marked as done")
+	}
+
+	registry.reset()
+	if registry.done || registry.failed || len(registry.containers) != 0 {
+		t.Errorf("the registry must be empty after the reset: %+v", registry)
+	}
+}
+
+func TestReportForensics(t *testing.T) {
+	var registry = new(reportRegistry)
+	var labels = metricsLabels{container: "delete_mongo", iCopy: 0}
+	registry.register(labels, "delete_mongo_0", nil)
+
+	var dir = t.TempDir()
+	registry.forensics(labels, reportForensics{
+		time:   time.Now(),
+		action: kForensicsExec,
+		detail: "mongosh --quiet --eval rs.status()",
+		file:   filepath.Join(dir, "forensics", "delete_mongo.0", "exec.rs.status.txt"),
+	})
+
+	var copies = registry.snapshot()
+	if len(copies) != 1 || len(copies[0].forensics) != 1 || copies[0].forensics[0].action != kForensicsExec {
+		t.Fatalf("unexpected forensics: %+v", copies)
+	}
+
+	if err := registry.write(dir, "TestMongo", true); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "report.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the link is relative to report.html
+	if !strings.Contains(string(data), "<h3>Forensics</h3>") || !strings.Contains(string(data), `href="forensics/delete_mongo.0/exec.rs.status.txt"`) {
+		t.Errorf("unexpected report: %s", data)
+	}
+}
@@ -0,0 +1,562 @@
+package manager
+
+import (
+	"fmt"
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/helmutkemper/chaos/internal/builder"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"strings"
+)
+
+// containerHostOptions
+//
+// Host configuration applied to the running containers. When a list has only one value, all copies receive it,
+// otherwise, the key is the index from Create(copies), see copyValue()
+type containerHostOptions struct {
+	restartPolicy  []builder.RestartPolicy
+	capAdd         [][]string
+	capDrop        [][]string
+	privileged     []bool
+	readonlyRootfs []bool
+	securityOpt    [][]string
+	sysctls        []map[string]string
+	devices        [][]dockerContainer.DeviceMapping
+	dns            [][]string
+	dnsSearch      [][]string
+	extraHosts     [][]string
+	logDriver      string
+	logOptions     []map[string]string
+	init           []bool
+	shmSize        []int64
+}
+
+// mapHostConfig
+//
+// Copies the host configuration of the copy to hostConfig
+func (el containerHostOptions) mapHostConfig(hostConfig *dockerContainer.HostConfig, iCopy int) {
+	hostConfig.CapAdd = copyValue(el.capAdd, iCopy)
+	hostConfig.CapDrop = copyValue(el.capDrop, iCopy)
+	hostConfig.Privileged = copyValue(el.privileged, iCopy)
+	hostConfig.ReadonlyRootfs = copyValue(el.readonlyRootfs, iCopy)
+	hostConfig.SecurityOpt = copyValue(el.securityOpt, iCopy)
+	hostConfig.Sysctls = copyValue(el.sysctls, iCopy)
+	hostConfig.Resources.Devices = copyValue(el.devices, iCopy)
+	hostConfig.DNS = copyValue(el.dns, iCopy)
+	hostConfig.DNSSearch = copyValue(el.dnsSearch, iCopy)
+	hostConfig.ExtraHosts = copyValue(el.extraHosts, iCopy)
+	hostConfig.ShmSize = copyValue(el.shmSize, iCopy)
+
+	if el.logDriver != "" {
+		hostConfig.LogConfig = dockerContainer.LogConfig{
+			Type:   el.logDriver,
+			Config: copyValue(el.logOptions, iCopy),
+		}
+	}
+
+	if len(el.init) != 0 {
+		var init = copyValue(el.init, iCopy)
+		hostConfig.Init = &init
+	}
+}
+
+// parseDevice
+//
+// Converts the docker client --device format, host[:container][:permissions], into a device mapping
+func parseDevice(value string) (device dockerContainer.DeviceMapping, err error) {
+	var part = strings.Split(value, ":")
+	if value == "" || len(part) > 3 {
+		err = fmt.Errorf("invalid device: %v", value)
+		return
+	}
+
+	device.PathOnHost = part[0]
+	device.PathInContainer = part[0]
+	device.CgroupPermissions = "rwm"
+
+	switch len(part) {
+	case 2:
+		if isDevicePermissions(part[1]) {
+			device.CgroupPermissions = part[1]
+		} else {
+			device.PathInContainer = part[1]
+		}
+	case 3:
+		if !isDevicePermissions(part[2]) {
+			err = fmt.Errorf("invalid device permissions: %v", value)
+			return
+		}
+
+		device.PathInContainer = part[1]
+		device.CgroupPermissions = part[2]
+	}
+
+	return
+}
+
+// isDevicePermissions
+//
+// Reports whether the text is a valid cgroup permission, a combination of r, w and m
+func isDevicePermissions(value string) (valid bool) {
+	if value == "" || len(value) > 3 {
+		return false
+	}
+
+	return strings.Trim(value, "rwm") == ""
+}
+
+// RestartPolicy
+//
+// English:
+//
+//	Defines the restart policy of the container (--restart)
+//
+//	 Input:
+//	   policy: restart policy, e.g. factory.NewRestartPolicyOnFailure() (Default: no)
+//
+//	 Notes:
+//	   * When `policy` receives more than one value, each copy created by Create(copies) receives a different value;
+//	     with only one value, all copies receive it. The copies after the end of the list receive the docker default;
+//	   * Containers stopped by the chaos test are not restarted by docker, only containers that end by themselves.
+//
+// Português:
+//
+//	Define a política de reinício do container (--restart)
+//
+//	 Entrada:
+//	   policy: política de reinício, ex. factory.NewRestartPolicyOnFailure() (Padrão: no)
+//
+//	 Notas:
+//	   * Quando `policy` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	     com apenas um valor, todas as cópias o recebem. As cópias após o fim da lista recebem o padrão do docker;
+//	   * Containers parados pelo teste de caos não são reiniciados pelo docker, apenas containers que terminam
+//	     sozinhos.
+func (el *ContainerFromImage) RestartPolicy(policy ...builder.RestartPolicy) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.hostOptions.restartPolicy = policy
+	return el
+}
+
+// CapAdd
+//
+// English:
+//
+//	Adds Linux capabilities to the container (--cap-add)
+//
+//	 Input:
+//	   capabilities: list of capabilities per copy. e.g. []string{"NET_ADMIN", "SYS_TIME"}
+//
+//	 Notes:
+//	   * When `capabilities` receives more than one list, each copy created by Create(copies) receives a different
+//	     list; with only one list, all copies receive it.
+//	     The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Adiciona capacidades Linux ao container (--cap-add)
+//
+//	 Entrada:
+//	   capabilities: lista de capacidades por cópia. ex. []string{"NET_ADMIN", "SYS_TIME"}
+//
+//	 Notas:
+//	   * Quando `capabilities` recebe mais de uma lista, cada cópia criada por Create(copies) recebe uma lista
+//	     diferente; com apenas uma lista, todas as cópias a recebem.
+//	     As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) CapAdd(capabilities ...[]string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.hostOptions.capAdd = capabilities
+	return el
+}
+
+// CapDrop
+//
+// English:
+//
+//	Removes Linux capabilities from the container (--cap-drop)
+//
+//	 Input:
+//	   capabilities: list of capabilities per copy. e.g. []string{"ALL"}
+//
+//	 Notes:
+//	   * When `capabilities` receives more than one list, each copy created by Create(copies) receives a different
+//	     list; with only one list, all copies receive it.
+//	     The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Remove capacidades Linux do container (--cap-drop)
+//
+//	 Entrada:
+//	   capabilities: lista de capacidades por cópia. ex. []string{"ALL"}
+//
+//	 Notas:
+//	   * Quando `capabilities` recebe mais de uma lista, cada cópia criada por Create(copies) recebe uma lista
+//	     diferente; com apenas uma lista, todas as cópias a recebem.
+//	     As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) CapDrop(capabilities ...[]string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.hostOptions.capDrop = capabilities
+	return el
+}
+
+// Privileged
+//
+// English:
+//
+//	Gives extended privileges to the container (--privileged)
+//
+//	 Input:
+//	   privileged: flag per copy. Without values, all copies are privileged; with only one value, all copies
+//	     receive it. The copies after the end of the list are not privileged
+//
+// Português:
+//
+//	Dá privilégios estendidos ao container (--privileged)
+//
+//	 Entrada:
+//	   privileged: flag por cópia. Sem valores, todas as cópias são privilegiadas; com apenas um valor, todas as
+//	     cópias o recebem. As cópias após o fim da lista não são privilegiadas
+func (el *ContainerFromImage) Privileged(privileged ...bool) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	if len(privileged) == 0 {
+		privileged = []bool{true}
+	}
+
+	el.hostOptions.privileged = privileged
+	return el
+}
+
+// ReadonlyRootfs
+//
+// English:
+//
+//	Mounts the root filesystem of the container as read only (--read-only)
+//
+//	 Input:
+//	   readonly: flag per copy. Without values, all copies are read only; with only one value, all copies
+//	     receive it. The copies after the end of the list are not read only
+//
+//	 Note:
+//	   * Use Tmpfs() or Volumes() for the folders where the project writes.
+//
+// Português:
+//
+//	Monta o sistema de arquivos raiz do container como somente leitura (--read-only)
+//
+//	 Entrada:
+//	   readonly: flag por cópia. Sem valores, todas as cópias são somente leitura; com apenas um valor, todas as
+//	     cópias o recebem. As cópias após o fim da lista não são somente leitura
+//
+//	 Nota:
+//	   * Use Tmpfs() ou Volumes() para as pastas onde o projeto escreve.
+func (el *ContainerFromImage) ReadonlyRootfs(readonly ...bool) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	if len(readonly) == 0 {
+		readonly = []bool{true}
+	}
+
+	el.hostOptions.readonlyRootfs = readonly
+	return el
+}
+
+// SecurityOpt
+//
+// English:
+//
+//	Defines the security options of the container (--security-opt)
+//
+//	 Input:
+//	   options: list of options per copy. e.g. []string{"no-new-privileges", "seccomp=unconfined"}
+//
+//	 Notes:
+//	   * When `options` receives more than one list, each copy created by Create(copies) receives a different list;
+//	     with only one list, all copies receive it.
+//	     The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Define as opções de segurança do container (--security-opt)
+//
+//	 Entrada:
+//	   options: lista de opções por cópia. ex. []string{"no-new-privileges", "seccomp=unconfined"}
+//
+//	 Notas:
+//	   * Quando `options` recebe mais de uma lista, cada cópia criada por Create(copies) recebe uma lista diferente;
+//	     com apenas uma lista, todas as cópias a recebem.
+//	     As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) SecurityOpt(options ...[]string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.hostOptions.securityOpt = options
+	return el
+}
+
+// Sysctls
+//
+// English:
+//
+//	Defines namespaced kernel parameters of the container (--sysctl)
+//
+//	 Input:
+//	   sysctls: parameters per copy. e.g. map[string]string{"net.ipv4.tcp_keepalive_time": "60"}
+//
+//	 Notes:
+//	   * When `sysctls` receives more than one map, each copy created by Create(copies) receives a different map;
+//	     with only one map, all copies receive it.
+//	     The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Define parâmetros do kernel, com namespace, do container (--sysctl)
+//
+//	 Entrada:
+//	   sysctls: parâmetros por cópia. ex. map[string]string{"net.ipv4.tcp_keepalive_time": "60"}
+//
+//	 Notas:
+//	   * Quando `sysctls` recebe mais de um mapa, cada cópia criada por Create(copies) recebe um mapa diferente;
+//	     com apenas um mapa, todas as cópias o recebem.
+//	     As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) Sysctls(sysctls ...map[string]string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.hostOptions.sysctls = sysctls
+	return el
+}
+
+// Devices
+//
+// English:
+//
+//	Adds host devices to the container (--device)
+//
+//	 Input:
+//	   devices: list of devices per copy, in the format host[:container][:permissions]. e.g. []string{"/dev/fuse"}
+//
+//	 Notes:
+//	   * When `devices` receives more than one list, each copy created by Create(copies) receives a different list;
+//	     with only one list, all copies receive it.
+//	     The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Adiciona dispositivos do hospedeiro ao container (--device)
+//
+//	 Entrada:
+//	   devices: lista de dispositivos por cópia, no formato host[:container][:permissões]. ex. []string{"/dev/fuse"}
+//
+//	 Notas:
+//	   * Quando `devices` recebe mais de uma lista, cada cópia criada por Create(copies) recebe uma lista diferente;
+//	     com apenas uma lista, todas as cópias a recebem.
+//	     As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) Devices(devices ...[]string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	var err error
+	el.hostOptions.devices = make([][]dockerContainer.DeviceMapping, len(devices))
+	for iCopy := range devices {
+		for _, value := range devices[iCopy] {
+			var device dockerContainer.DeviceMapping
+			if device, err = parseDevice(value); err != nil {
+				monitor.Err = true
+				ErrorCh <- fmt.Errorf("containerFromImage.Devices().error: %v", err)
+				return el
+			}
+
+			el.hostOptions.devices[iCopy] = append(el.hostOptions.devices[iCopy], device)
+		}
+	}
+
+	return el
+}
+
+// Dns
+//
+// English:
+//
+//	Defines the DNS servers of the container (--dns)
+//
+//	 Input:
+//	   servers: list of servers per copy. e.g. []string{"1.1.1.1", "8.8.8.8"}
+//
+//	 Notes:
+//	   * When `servers` receives more than one list, each copy created by Create(copies) receives a different list;
+//	     with only one list, all copies receive it.
+//	     The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Define os servidores DNS do container (--dns)
+//
+//	 Entrada:
+//	   servers: lista de servidores por cópia. ex. []string{"1.1.1.1", "8.8.8.8"}
+//
+//	 Notas:
+//	   * Quando `servers` recebe mais de uma lista, cada cópia criada por Create(copies) recebe uma lista diferente;
+//	     com apenas uma lista, todas as cópias a recebem.
+//	     As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) Dns(servers ...[]string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.hostOptions.dns = servers
+	return el
+}
+
+// DnsSearch
+//
+// English:
+//
+//	Defines the DNS search domains of the container (--dns-search)
+//
+//	 Input:
+//	   domains: list of domains per copy. e.g. []string{"example.com"}
+//
+//	 Notes:
+//	   * When `domains` receives more than one list, each copy created by Create(copies) receives a different list;
+//	     with only one list, all copies receive it.
+//	     The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Define os domínios de busca DNS do container (--dns-search)
+//
+//	 Entrada:
+//	   domains: lista de domínios por cópia. ex. []string{"example.com"}
+//
+//	 Notas:
+//	   * Quando `domains` recebe mais de uma lista, cada cópia criada por Create(copies) recebe uma lista diferente;
+//	     com apenas uma lista, todas as cópias a recebem.
+//	     As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) DnsSearch(domains ...[]string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.hostOptions.dnsSearch = domains
+	return el
+}
+
+// LogDriver
+//
+// English:
+//
+//	Defines the log driver of the container (--log-driver and --log-opt)
+//
+//	 Input:
+//	   driver: log driver. e.g. json-file, local, journald
+//	   options: options per copy. e.g. map[string]string{"max-size": "10m"}
+//
+//	 Notes:
+//	   * The failure flags and the report read the container logs, so use a driver that supports `docker logs`;
+//	   * When `options` receives more than one map, each copy created by Create(copies) receives a different map;
+//	     with only one map, all copies receive it.
+//	     The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Define o driver de log do container (--log-driver e --log-opt)
+//
+//	 Entrada:
+//	   driver: driver de log. ex. json-file, local, journald
+//	   options: opções por cópia. ex. map[string]string{"max-size": "10m"}
+//
+//	 Notas:
+//	   * Os flags de falha e o relatório leem os logs do container, por isto, use um driver com suporte a
+//	     `docker logs`;
+//	   * Quando `options` recebe mais de um mapa, cada cópia criada por Create(copies) recebe um mapa diferente;
+//	     com apenas um mapa, todas as cópias o recebem.
+//	     As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) LogDriver(driver string, options ...map[string]string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.hostOptions.logDriver = driver
+	el.hostOptions.logOptions = options
+	return el
+}
+
+// Init
+//
+// English:
+//
+//	Runs an init process inside the container, that forwards signals and reaps processes (--init)
+//
+//	 Input:
+//	   init: flag per copy. Without values, all copies run the init process; with only one value, all copies
+//	     receive it. The copies after the end of the list do not run the init process
+//
+// Português:
+//
+//	Roda um processo init dentro do container, que repassa sinais e recolhe processos (--init)
+//
+//	 Entrada:
+//	   init: flag por cópia. Sem valores, todas as cópias rodam o processo init; com apenas um valor, todas as
+//	     cópias o recebem. As cópias após o fim da lista não rodam o processo init
+func (el *ContainerFromImage) Init(init ...bool) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	if len(init) == 0 {
+		init = []bool{true}
+	}
+
+	el.hostOptions.init = init
+	return el
+}
+
+// ShmSize
+//
+// English:
+//
+//	Defines the size of /dev/shm (--shm-size)
+//
+//	 Input:
+//	   size: size in bytes per copy. Use value * KKiloByte, value * KMegaByte and value * KGigaByte
+//
+//	 Notes:
+//	   * When `size` receives more than one value, each copy created by Create(copies) receives a different value;
+//	     with only one value, all copies receive it.
+//	     The copies after the end of the list receive the docker default.
+//
+// Português:
+//
+//	Define o tamanho de /dev/shm (--shm-size)
+//
+//	 Entrada:
+//	   size: tamanho em bytes por cópia. Use value * KKiloByte, value * KMegaByte e value * KGigaByte
+//
+//	 Notas:
+//	   * Quando `size` recebe mais de um valor, cada cópia criada por Create(copies) recebe um valor diferente;
+//	     com apenas um valor, todas as cópias o recebem.
+//	     As cópias após o fim da lista recebem o padrão do docker.
+func (el *ContainerFromImage) ShmSize(size ...int64) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.hostOptions.shmSize = size
+	return el
+}
@@ -0,0 +1,103 @@
+package manager
+
+import (
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/helmutkemper/chaos/internal/builder"
+	"testing"
+)
+
+func TestParseDevice(t *testing.T) {
+	var list = []struct {
+		value    string
+		expected dockerContainer.DeviceMapping
+		err      bool
+	}{
+		{value: "/dev/fuse", expected: dockerContainer.DeviceMapping{PathOnHost: "/dev/fuse", PathInContainer: "/dev/fuse", CgroupPermissions: "rwm"}},
+		{value: "/dev/sda:/dev/xvda", expected: dockerContainer.DeviceMapping{PathOnHost: "/dev/sda", PathInContainer: "/dev/xvda", CgroupPermissions: "rwm"}},
+		{value: "/dev/sda:r", expected: dockerContainer.DeviceMapping{PathOnHost: "/dev/sda", PathInContainer: "/dev/sda", CgroupPermissions: "r"}},
+		{value: "/dev/sda:/dev/xvda:rw", expected: dockerContainer.DeviceMapping{PathOnHost: "/dev/sda", PathInContainer: "/dev/xvda", CgroupPermissions: "rw"}},
+		{value: "/dev/sda:/dev/xvda:x", err: true},
+		{value: "", err: true},
+	}
+
+	for _, test := range list {
+		var device, err = parseDevice(test.value)
+		if test.err != (err != nil) {
+			t.Errorf("%v: error: %v", test.value, err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if device != test.expected {
+			t.Errorf("%v: %+v", test.value, device)
+		}
+	}
+}
+
+func TestContainerFromImage_hostOptions(t *testing.T) {
+	monitorErrReset(t)
+
+	var el = new(ContainerFromImage)
+	el.manager = new(Manager)
+	el.RestartPolicy(builder.KRestartPolicyOnFailure).
+		CapAdd([]string{"NET_ADMIN"}, []string{"SYS_TIME"}).
+		Privileged().
+		ExtraHosts([]string{"somehost:162.242.195.82"}).
+		LogDriver("local", map[string]string{"max-size": "10m"}).
+		Init(false, true).
+		Devices([]string{"/dev/fuse"})
+
+	if copyValue(el.hostOptions.restartPolicy, 2) != builder.KRestartPolicyOnFailure {
+		t.Errorf("one restart policy must be applied to all copies")
+	}
+
+	if len(el.manager.ImageBuildOptions.ExtraHosts) != 1 {
+		t.Errorf("the image build must receive the first list of hosts")
+	}
+
+	var first = el.mapHostConfig(0)
+	var second = el.mapHostConfig(1)
+
+	if len(first.CapAdd) != 1 || len(second.CapAdd) != 1 || first.CapAdd[0] != "NET_ADMIN" || second.CapAdd[0] != "SYS_TIME" {
+		t.Errorf("capabilities per copy: %v, %v", first.CapAdd, second.CapAdd)
+	}
+
+	if !first.Privileged || !second.Privileged {
+		t.Errorf("Privileged() without values must be applied to all copies")
+	}
+
+	if len(second.ExtraHosts) != 1 || second.LogConfig.Type != "local" || second.LogConfig.Config["max-size"] != "10m" {
+		t.Errorf("extra hosts: %v, log: %+v", second.ExtraHosts, second.LogConfig)
+	}
+
+	if first.Init == nil || *first.Init || second.Init == nil || !*second.Init {
+		t.Errorf("init per copy")
+	}
+
+	if len(second.Devices) != 1 || second.Devices[0].PathInContainer != "/dev/fuse" {
+		t.Errorf("devices: %+v", second.Devices)
+	}
+}
+
+func TestCopyValue(t *testing.T) {
+	var list = []struct {
+		values   []int64
+		iCopy    int
+		expected int64
+	}{
+		{values: nil, iCopy: 0, expected: 0},
+		{values: []int64{5}, iCopy: 0, expected: 5},
+		{values: []int64{5}, iCopy: 3, expected: 5},
+		{values: []int64{5, 6}, iCopy: 1, expected: 6},
+		{values: []int64{5, 6}, iCopy: 2, expected: 0},
+	}
+
+	for _, item := range list {
+		if value := copyValue(item.values, item.iCopy); value != item.expected {
+			t.Errorf("copyValue(%v, %v): %v, expected %v", item.values, item.iCopy, value, item.expected)
+		}
+	}
+}
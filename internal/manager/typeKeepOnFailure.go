@@ -0,0 +1,179 @@
+package manager
+
+import (
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+	"github.com/helmutkemper/chaos/internal/standalone"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KKeepOnFailureEnv enables KeepOnFailure() without changing the code, e.g. CHAOS_KEEP_ON_FAILURE=true go test ./...
+const KKeepOnFailureEnv = "CHAOS_KEEP_ON_FAILURE"
+
+// kCleanCommand removes the Docker elements kept by KeepOnFailure()
+const kCleanCommand = "go run github.com/helmutkemper/chaos/cmd/chaos clean --session"
+
+// kKeepVolume is the prefix of the name of the volume which marks the session kept with the label KLabelKeep. The
+// labels of the containers and networks can't be changed after the creation
+const kKeepVolume = "chaos-keep-"
+
+// Keeps the Docker elements of a failed test, defined by KeepOnFailure()
+var keepOnFailureGlobal bool
+
+// KeepOnFailure
+//
+// English:
+//
+//	Keeps the containers, networks and volumes when the test fails, for debugging, instead of removing them in the
+//	cleanup of Test().
+//
+//	 Notes:
+//	   * Also enabled by the environment variable CHAOS_KEEP_ON_FAILURE=true;
+//	   * The containers left paused or stopped by the chaos are unpaused or restarted;
+//	   * The ip addresses, host ports and docker exec commands of each copy are printed in the log of the test;
+//	   * The reaper started by Reaper() is removed, so it doesn't remove the Docker elements kept;
+//	   * The session is marked by a volume with the label chaos.keep=true, so the next tests don't remove it;
+//	   * Remove the Docker elements with `chaos clean --session <id>`, printed in the log of the test.
+//
+// Português:
+//
+//	Mantém os containers, redes e volumes quando o teste falha, para depuração, em vez de removê-los na limpeza de
+//	Test().
+//
+//	 Notas:
+//	   * Também habilitado pela variável de ambiente CHAOS_KEEP_ON_FAILURE=true;
+//	   * Os containers deixados pausados ou parados pelo caos são despausados ou reiniciados;
+//	   * Os endereços ip, as portas do hospedeiro e os comandos docker exec de cada cópia são impressos no log do teste;
+//	   * O reaper iniciado por Reaper() é removido, para que ele não remova os elementos Docker mantidos;
+//	   * A sessão é marcada por um volume com o rótulo chaos.keep=true, para que os próximos testes não a removam;
+//	   * Remova os elementos Docker com `chaos clean --session <id>`, impresso no log do teste.
+func (el *Primordial) KeepOnFailure() (ref *Primordial) {
+	keepOnFailureGlobal = true
+	return el
+}
+
+// keepOnFailure
+//
+// Returns true when KeepOnFailure() was called or the environment variable is true
+func keepOnFailure() (keep bool) {
+	if keepOnFailureGlobal {
+		return true
+	}
+
+	keep, _ = strconv.ParseBool(os.Getenv(KKeepOnFailureEnv))
+	return
+}
+
+// keepEnvironment
+//
+// Marks the session as kept, unpauses or restarts the containers of the session left by the chaos, removes the reaper
+// and returns the connection information of each container
+func (el *Primordial) keepEnvironment() (info string, err error) {
+	var dockerSys = el.manager.DockerSys[0]
+	var session = standalone.SessionId()
+
+	// the labels of the session are added by the docker system
+	if _, err = dockerSys.VolumeCreate(map[string]string{standalone.KLabelKeep: "true"}, kKeepVolume+session); err != nil {
+		return
+	}
+
+	var list []types.Container
+	if list, err = dockerSys.ContainerListAll(); err != nil {
+		return
+	}
+
+	var containers = make([]types.ContainerJSON, 0)
+	for _, data := range list {
+		if data.Labels[standalone.KLabelSession] != session {
+			continue
+		}
+
+		switch data.State {
+		case "paused":
+			err = dockerSys.ContainerUnpause(data.ID)
+		case "exited", "created":
+			err = dockerSys.ContainerStart(data.ID)
+		}
+		if err != nil {
+			return
+		}
+
+		var inspect types.ContainerJSON
+		if inspect, err = dockerSys.ContainerInspect(data.ID); err != nil {
+			return
+		}
+		containers = append(containers, inspect)
+	}
+
+	if err = reaperStop(); err != nil {
+		return
+	}
+
+	return keepInfo(containers, session), nil
+}
+
+// keepInfo
+//
+// Names, states, ip addresses, host ports and commands of the containers kept
+func keepInfo(containers []types.ContainerJSON, session string) (info string) {
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+
+	var builder strings.Builder
+	_, _ = fmt.Fprintf(&builder, "keep on failure: the containers of the session %v were kept\n", session)
+
+	for _, data := range containers {
+		var name = strings.TrimPrefix(data.Name, "/")
+
+		var state = "unknown"
+		if data.State != nil {
+			state = data.State.Status
+		}
+		_, _ = fmt.Fprintf(&builder, "%v (%v)\n", name, state)
+
+		if data.NetworkSettings != nil {
+			var networks = make([]string, 0, len(data.NetworkSettings.Networks))
+			for network := range data.NetworkSettings.Networks {
+				networks = append(networks, network)
+			}
+			sort.Strings(networks)
+
+			for _, network := range networks {
+				var endpoint = data.NetworkSettings.Networks[network]
+				if endpoint == nil || endpoint.IPAddress == "" {
+					continue
+				}
+
+				var address = endpoint.IPAddress
+				if endpoint.GlobalIPv6Address != "" {
+					address += ", " + endpoint.GlobalIPv6Address
+				}
+				_, _ = fmt.Fprintf(&builder, "  ip: %v (%v)\n", address, network)
+			}
+
+			var ports = make([]string, 0, len(data.NetworkSettings.Ports))
+			for port := range data.NetworkSettings.Ports {
+				ports = append(ports, string(port))
+			}
+			sort.Strings(ports)
+
+			for _, port := range ports {
+				for _, binding := range data.NetworkSettings.Ports[nat.Port(port)] {
+					_, _ = fmt.Fprintf(&builder, "  port: %v -> %v\n", port, net.JoinHostPort(binding.HostIP, binding.HostPort))
+				}
+			}
+		}
+
+		_, _ = fmt.Fprintf(&builder, "  shell: docker exec -it %v sh\n", name)
+		_, _ = fmt.Fprintf(&builder, "  logs: docker logs -f %v\n", name)
+	}
+
+	_, _ = fmt.Fprintf(&builder, "remove: %v %v\n", kCleanCommand, session)
+	return builder.String()
+}
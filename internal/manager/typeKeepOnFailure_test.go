@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"strings"
+	"testing"
+)
+
+func TestKeepInfo(t *testing.T) {
+	var containers = []types.ContainerJSON{
+		{
+			ContainerJSONBase: &types.ContainerJSONBase{Name: "/delete_mongo_1", State: &types.ContainerState{Status: "running"}},
+		},
+		{
+			ContainerJSONBase: &types.ContainerJSONBase{Name: "/delete_mongo_0", State: &types.ContainerState{Status: "running"}},
+			NetworkSettings: &types.NetworkSettings{
+				NetworkSettingsBase: types.NetworkSettingsBase{
+					Ports: nat.PortMap{"27017/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "27017"}}},
+				},
+				Networks: map[string]*network.EndpointSettings{
+					"delete_chaos_network": {IPAddress: "10.0.0.2"},
+				},
+			},
+		},
+	}
+
+	var info = keepInfo(containers, "20221120T131500-3f2a9c1d")
+	for _, text := range []string{
+		"session 20221120T131500-3f2a9c1d were kept",
+		"delete_mongo_0 (running)\n  ip: 10.0.0.2 (delete_chaos_network)\n  port: 27017/tcp -> 0.0.0.0:27017\n  shell: docker exec -it delete_mongo_0 sh",
+		"docker logs -f delete_mongo_1",
+		"remove: " + kCleanCommand + " 20221120T131500-3f2a9c1d",
+	} {
+		if !strings.Contains(info, text) {
+			t.Errorf("%q not found: %v", text, info)
+		}
+	}
+
+	if strings.Index(info, "delete_mongo_0") > strings.Index(info, "delete_mongo_1") {
+		t.Errorf("the containers must be sorted by name: %v", info)
+	}
+}
+
+func TestKeepOnFailure(t *testing.T) {
+	var keep = keepOnFailureGlobal
+	defer func() { keepOnFailureGlobal = keep }()
+
+	keepOnFailureGlobal = false
+	t.Setenv(KKeepOnFailureEnv, "")
+	if keepOnFailure() {
+		t.Errorf("keep on failure must be disabled by default")
+	}
+
+	t.Setenv(KKeepOnFailureEnv, "true")
+	if !keepOnFailure() {
+		t.Errorf("keep on failure must be enabled by the environment variable")
+	}
+
+	t.Setenv(KKeepOnFailureEnv, "")
+	new(Primordial).KeepOnFailure()
+	if !keepOnFailure() {
+		t.Errorf("keep on failure must be enabled by KeepOnFailure()")
+	}
+}
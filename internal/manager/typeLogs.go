@@ -0,0 +1,387 @@
+package manager
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Rotation of the files of SaveLogs()
+const (
+	kLogsMaxSizeDefault  = 10 * 1024 * 1024
+	kLogsMaxFilesDefault = 5
+)
+
+// kLogsFollowRetry is the interval between the attempts to follow the logs of a stopped container
+const kLogsFollowRetry = time.Second
+
+// SaveLogs
+//
+// English:
+//
+//	Streams the logs of each copy to disk during the test, logs.<name>.stdout.log and logs.<name>.stderr.log, so the
+//	logs are kept even if the test binary panics or is killed.
+//
+//	 Input:
+//	   path: directory of the files
+//
+//	 Notes:
+//	   * Each line keeps the timestamp of docker, e.g. 2022-11-20T13:15:00.270355545Z waiting for connections;
+//	   * The terminal colors are removed, unless LogsKeepAnsi() is used, and the secrets are redacted;
+//	   * The files are rotated by size, defined by LogsRotation(), and the rotated files are compressed with gzip,
+//	     e.g. logs.<name>.stdout.log.1.gz;
+//	   * Containers with Tty() have only the stdout file, because docker doesn't separate the streams of a tty;
+//	   * After a chaos stop, the logs are followed again when the container restarts.
+//
+// Português:
+//
+//	Grava os logs de cada cópia no disco durante o teste, logs.<nome>.stdout.log e logs.<nome>.stderr.log, para que os
+//	logs sejam mantidos mesmo se o binário do teste entrar em pânico ou for morto.
+//
+//	 Entrada:
+//	   path: diretório dos arquivos
+//
+//	 Notas:
+//	   * Cada linha mantém a marcação de tempo do docker, ex. 2022-11-20T13:15:00.270355545Z waiting for connections;
+//	   * As cores do terminal são removidas, a menos que LogsKeepAnsi() seja usado, e os segredos são ocultados;
+//	   * Os arquivos são rotacionados por tamanho, definido por LogsRotation(), e os arquivos rotacionados são
+//	     comprimidos com gzip, ex. logs.<nome>.stdout.log.1.gz;
+//	   * Containers com Tty() têm apenas o arquivo stdout, porque o docker não separa as saídas de um tty;
+//	   * Depois de uma parada do caos, os logs são acompanhados novamente quando o container reinicia.
+func (el *ContainerFromImage) SaveLogs(path string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	if err := os.MkdirAll(path, fs.ModePerm); err != nil {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("container.SaveLogs().MkdirAll().error: %v", err)
+		return el
+	}
+
+	el.logsPath = path
+	return el
+}
+
+// LogsRotation
+//
+// English:
+//
+//	Defines the rotation of the files saved by SaveLogs().
+//
+//	 Input:
+//	   maxSize: maximum size of the file, in bytes, before the rotation (Default: 10MB). Zero disables the rotation
+//	   maxFiles: number of compressed files kept (Default: 5)
+//
+// Português:
+//
+//	Define a rotação dos arquivos salvos por SaveLogs().
+//
+//	 Entrada:
+//	   maxSize: tamanho máximo do arquivo, em bytes, antes da rotação (Padrão: 10MB). Zero desabilita a rotação
+//	   maxFiles: quantidade de arquivos comprimidos mantidos (Padrão: 5)
+func (el *ContainerFromImage) LogsRotation(maxSize int64, maxFiles int) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	if maxSize < 0 || maxFiles < 1 {
+		monitor.Err = true
+		ErrorCh <- fmt.Errorf("container.LogsRotation().error: %v", "maxSize must be positive or zero and maxFiles must be greater than zero")
+		return el
+	}
+
+	el.logsMaxSize = maxSize
+	el.logsMaxFiles = maxFiles
+	el.logsRotation = true
+	return el
+}
+
+// LogsKeepAnsi
+//
+// English:
+//
+//	Keeps the raw escape sequences of the terminal, e.g. colors, in the files saved by SaveLogs()
+//
+// Português:
+//
+//	Mantém as sequências de escape do terminal, ex. cores, nos arquivos salvos por SaveLogs()
+func (el *ContainerFromImage) LogsKeepAnsi() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.logsKeepAnsi = true
+	return el
+}
+
+// logsFile
+//
+// File of SaveLogs(), rotated by size and compressed with gzip
+type logsFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	size int64
+}
+
+// logsFileOpen
+//
+// Creates the file, removing the files of a previous test
+func logsFileOpen(path string, maxSize int64, maxFiles int) (file *logsFile, err error) {
+	file = &logsFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	for i := 1; i <= maxFiles; i += 1 {
+		_ = os.Remove(file.rotated(i))
+	}
+
+	file.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fs.ModePerm)
+	return
+}
+
+// rotated
+//
+// Name of the compressed file, where 1 is the newest
+func (el *logsFile) rotated(index int) (path string) {
+	return fmt.Sprintf("%v.%v.gz", el.path, index)
+}
+
+// Write
+//
+// Writes the lines, rotating the file before it exceeds the maximum size
+func (el *logsFile) Write(data []byte) (n int, err error) {
+	if el.maxSize > 0 && el.size > 0 && el.size+int64(len(data)) > el.maxSize {
+		if err = el.rotate(); err != nil {
+			return
+		}
+	}
+
+	n, err = el.file.Write(data)
+	el.size += int64(n)
+	return
+}
+
+// rotate
+//
+// Compresses the file as .1.gz, shifting the previous compressed files and removing the oldest
+func (el *logsFile) rotate() (err error) {
+	if err = el.file.Close(); err != nil {
+		return
+	}
+
+	_ = os.Remove(el.rotated(el.maxFiles))
+	for i := el.maxFiles - 1; i > 0; i -= 1 {
+		_ = os.Rename(el.rotated(i), el.rotated(i+1))
+	}
+
+	if err = logsGzip(el.path, el.rotated(1)); err != nil {
+		return
+	}
+
+	el.size = 0
+	el.file, err = os.OpenFile(el.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fs.ModePerm)
+	return
+}
+
+// Close
+//
+// Closes the file
+func (el *logsFile) Close() (err error) {
+	return el.file.Close()
+}
+
+// logsGzip
+//
+// Compresses the source file into the destination file
+func logsGzip(source, destination string) (err error) {
+	var data []byte
+	if data, err = os.ReadFile(source); err != nil {
+		return
+	}
+
+	var buffer bytes.Buffer
+	var writer = gzip.NewWriter(&buffer)
+	if _, err = writer.Write(data); err != nil {
+		return
+	}
+
+	if err = writer.Close(); err != nil {
+		return
+	}
+
+	return os.WriteFile(destination, buffer.Bytes(), fs.ModePerm)
+}
+
+// logsWriter
+//
+// Splits the stream of one output into lines, removes the terminal colors and the secrets, and writes the lines with
+// the timestamp of docker
+type logsWriter struct {
+	output   io.Writer
+	keepAnsi bool
+
+	// Time of the last line of stdout and stderr, the start of the next follow
+	last *time.Time
+
+	buffer []byte
+}
+
+// Write
+//
+// Writes the complete lines and keeps the incomplete line in the buffer
+func (el *logsWriter) Write(data []byte) (n int, err error) {
+	el.buffer = append(el.buffer, data...)
+	for {
+		var index = bytes.IndexByte(el.buffer, '\n')
+		if index == -1 {
+			break
+		}
+
+		if err = el.line(string(el.buffer[:index])); err != nil {
+			return
+		}
+		el.buffer = el.buffer[index+1:]
+	}
+
+	return len(data), nil
+}
+
+// flush
+//
+// Writes the incomplete line, at the end of the stream
+func (el *logsWriter) flush() (err error) {
+	if len(el.buffer) == 0 {
+		return
+	}
+
+	err = el.line(string(el.buffer))
+	el.buffer = nil
+	return
+}
+
+// line
+//
+// Writes one line, keeping the timestamp of docker as received
+func (el *logsWriter) line(line string) (err error) {
+	line = strings.TrimRight(line, "\r")
+	var parsed = logLineParse(line)
+
+	var text = monitor.Redact(parsed.text)
+	if !el.keepAnsi {
+		text = timelineText(parsed.text)
+	}
+
+	if !parsed.time.IsZero() {
+		text = line[:len(line)-len(parsed.text)] + text
+		if parsed.time.After(*el.last) {
+			*el.last = parsed.time
+		}
+	}
+
+	_, err = el.output.Write([]byte(text + "\n"))
+	return
+}
+
+// logsThread
+//
+// Streams the logs of each copy to the files of SaveLogs()
+func (el *ContainerFromImage) logsThread() {
+	if el.logsPath == "" {
+		return
+	}
+
+	var maxSize, maxFiles = int64(kLogsMaxSizeDefault), kLogsMaxFilesDefault
+	if el.logsRotation {
+		maxSize, maxFiles = el.logsMaxSize, el.logsMaxFiles
+	}
+
+	for i := 0; i != el.copies; i += 1 {
+		var base = filepath.Join(el.logsPath, fmt.Sprintf("logs.%v", el.copyReportName(i)))
+
+		stdout, err := logsFileOpen(base+".stdout.log", maxSize, maxFiles)
+		if err != nil {
+			monitor.Err = true
+			ErrorCh <- fmt.Errorf("container[%v].logsThread().OpenFile().error: %v", i, err)
+			return
+		}
+
+		// docker doesn't separate the streams of a tty
+		var stderr *logsFile
+		if !el.manager.DockerSys[0].Config.Tty {
+			if stderr, err = logsFileOpen(base+".stderr.log", maxSize, maxFiles); err != nil {
+				_ = stdout.Close()
+				monitor.Err = true
+				ErrorCh <- fmt.Errorf("container[%v].logsThread().OpenFile().error: %v", i, err)
+				return
+			}
+		}
+
+		go el.logsFollow(i, stdout, stderr)
+	}
+}
+
+// logsFollow
+//
+// Follows the logs of one copy until the container is removed. After the container stops, the logs are followed again
+// from the last line, so the lines of a restart are not lost nor duplicated
+func (el *ContainerFromImage) logsFollow(iCopy int, stdout, stderr *logsFile) {
+	var last time.Time
+	var stdoutWriter = &logsWriter{output: stdout, keepAnsi: el.logsKeepAnsi, last: &last}
+	var stderrWriter = &logsWriter{output: stderr, keepAnsi: el.logsKeepAnsi, last: &last}
+
+	defer func() {
+		_ = stdout.Close()
+		if stderr != nil {
+			_ = stderr.Close()
+		}
+	}()
+
+	for {
+		var since time.Time
+		if !last.IsZero() {
+			since = last.Add(time.Nanosecond)
+		}
+
+		// the id is read at each attempt, so the logs of the container created again by containerRecreate() are followed
+		var id = el.copyId(iCopy)
+		reader, err := el.manager.DockerSys[iCopy].ContainerLogsFollow(id, since)
+		if err != nil {
+			// the container was removed, or it is being created again
+			time.Sleep(kLogsFollowRetry)
+			if el.copyId(iCopy) != id {
+				continue
+			}
+			return
+		}
+
+		if stderr == nil {
+			_, err = io.Copy(stdoutWriter, reader)
+		} else {
+			_, err = stdcopy.StdCopy(stdoutWriter, stderrWriter, reader)
+		}
+		_ = reader.Close()
+
+		if err != nil {
+			log.Printf("container[%v].logsFollow().error: %v", iCopy, err)
+		}
+
+		if err = stdoutWriter.flush(); err == nil && stderr != nil {
+			err = stderrWriter.flush()
+		}
+		if err != nil {
+			log.Printf("container[%v].logsFollow().flush().error: %v", iCopy, err)
+		}
+
+		time.Sleep(kLogsFollowRetry)
+	}
+}
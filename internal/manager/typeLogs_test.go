@@ -0,0 +1,91 @@
+package manager
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogsWriter(t *testing.T) {
+	var start = time.Date(2022, 11, 20, 13, 15, 0, 0, time.UTC)
+	var timestamp = "2022-11-20T13:15:00.100000000Z"
+
+	var last time.Time
+	var output bytes.Buffer
+	var writer = &logsWriter{output: &output, last: &last}
+
+	_, _ = writer.Write([]byte(timestamp + " \x1b[32mwaiting\x1b[0m for\r\n" + timestamp))
+	_, _ = writer.Write([]byte(" connections\nwithout time\npartial"))
+
+	if !last.Equal(start.Add(100 * time.Millisecond)) {
+		t.Errorf("unexpected time of the last line: %v", last)
+	}
+
+	// the timestamp of docker must be kept as received, with the zeros
+	var expected = timestamp + " waiting for\n" + timestamp + " connections\nwithout time\n"
+	if output.String() != expected {
+		t.Errorf("unexpected output: %q", output.String())
+	}
+
+	if err := writer.flush(); err != nil || !strings.HasSuffix(output.String(), "\npartial\n") {
+		t.Errorf("the incomplete line must be written by flush(): %q, %v", output.String(), err)
+	}
+
+	output.Reset()
+	writer = &logsWriter{output: &output, last: &last, keepAnsi: true}
+	_, _ = writer.Write([]byte(timestamp + " \x1b[32mwaiting\x1b[0m\n"))
+	if output.String() != timestamp+" \x1b[32mwaiting\x1b[0m\n" {
+		t.Errorf("the escape sequences must be kept: %q", output.String())
+	}
+}
+
+func TestLogsFile(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "logs.db.0.stdout.log")
+
+	var file, err = logsFileOpen(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err = file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "fourth\n" {
+		t.Errorf("unexpected current file: %q", data)
+	}
+
+	// only maxFiles compressed files are kept, the newest is .1.gz
+	for index, expected := range map[int]string{1: "third\n", 2: "second\n"} {
+		var compressed, err = os.Open(file.rotated(index))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reader, err := gzip.NewReader(compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, _ := io.ReadAll(reader)
+		_ = compressed.Close()
+		if string(data) != expected {
+			t.Errorf("unexpected file %v: %q", index, data)
+		}
+	}
+
+	if _, err = os.Stat(file.rotated(3)); err == nil {
+		t.Errorf("the oldest file must be removed")
+	}
+}
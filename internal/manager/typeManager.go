@@ -5,6 +5,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/helmutkemper/chaos/internal/builder"
 	"github.com/helmutkemper/chaos/internal/monitor"
+	"github.com/helmutkemper/chaos/internal/standalone"
 	"strings"
 	"time"
 )
@@ -42,6 +43,21 @@ type chaosConfig struct {
 	restartProbability         float64
 	restartChangeIpProbability float64
 	restartLimit               int
+
+	minimumTimeUnderPressure time.Duration
+	maximumTimeUnderPressure time.Duration
+
+	minimumTimeDiskFault time.Duration
+	maximumTimeDiskFault time.Duration
+
+	minimumTimeClockSkew time.Duration
+	maximumTimeClockSkew time.Duration
+
+	minimumTimeDnsFault time.Duration
+	maximumTimeDnsFault time.Duration
+
+	minimumTimePartition time.Duration
+	maximumTimePartition time.Duration
 }
 
 type Chaos struct {
@@ -52,6 +68,9 @@ type Chaos struct {
 type Manager struct {
 	network *dockerNetwork
 
+	// All networks created by NetworkCreate(), in order of creation
+	networks []*dockerNetwork
+
 	TickerStats       *time.Ticker
 	TickerFail        *time.Ticker
 	Id                []string
@@ -76,6 +95,9 @@ func (el *Manager) New() {
 	el.DockerSys = make([]*builder.DockerSystem, 1)
 	el.DockerSys[0] = new(builder.DockerSystem)
 
+	// every docker element created by the manager belongs to the session, removed by GarbageCollector()
+	el.DockerSys[0].SetLabels(standalone.SessionLabels())
+
 	el.DoneCh = make(chan struct{})
 	el.FailCh = make(chan string)
 
@@ -88,6 +110,21 @@ func (el *Manager) New() {
 	el.ChaosConfig.maximumTimeToUnpause = 90 * time.Second
 	el.ChaosConfig.minimumTimeToUnpause = 30 * time.Second
 
+	el.ChaosConfig.maximumTimeUnderPressure = 90 * time.Second
+	el.ChaosConfig.minimumTimeUnderPressure = 30 * time.Second
+
+	el.ChaosConfig.maximumTimeDiskFault = 90 * time.Second
+	el.ChaosConfig.minimumTimeDiskFault = 30 * time.Second
+
+	el.ChaosConfig.maximumTimeClockSkew = 90 * time.Second
+	el.ChaosConfig.minimumTimeClockSkew = 30 * time.Second
+
+	el.ChaosConfig.maximumTimeDnsFault = 90 * time.Second
+	el.ChaosConfig.minimumTimeDnsFault = 30 * time.Second
+
+	el.ChaosConfig.maximumTimePartition = 90 * time.Second
+	el.ChaosConfig.minimumTimePartition = 30 * time.Second
+
 	el.addMonitor()
 
 	err = el.DockerSys[0].Init()
@@ -119,11 +156,13 @@ func (el *Manager) Primordial() (primordial *Primordial) {
 //	  name: network name
 //	  subnet: subnet value. eg. 10.0.0.0/16
 //	  gateway: gateway value. eg. "10.0.0.1
+//	  subnet6: IPv6 subnet value, or "" for an IPv4 only network. eg. fd00:c4a0::/64
+//	  gateway6: IPv6 gateway value. eg. fd00:c4a0::1
 //
 //	Notes:
-//	  * If there is already a network with the same name and the same configuration, nothing will be done;
+//	  * If there is already a network with the same name and the same configuration, the network is reused;
 //	  * If a network with the same name and different configuration already exists, the network will be deleted, and a new network created.
-func (el *Manager) networkCreate(name, subnet, gateway string) (networkId string, err error) {
+func (el *Manager) networkCreate(name, subnet, gateway, subnet6, gateway6 string) (networkId string, err error) {
 	el.network = new(dockerNetwork)
 	el.network.networkName = name
 
@@ -135,38 +174,82 @@ func (el *Manager) networkCreate(name, subnet, gateway string) (networkId string
 
 	for _, networkData := range networkList {
 		if networkData.Name == name {
-			el.network.networkID = networkData.ID
-
 			var data types.NetworkResource
 			if data, err = el.DockerSys[0].NetworkInspect(networkData.ID); err != nil {
 				err = fmt.Errorf("network.NetworkCreate().NetworkInspect().error: %v", err)
 				return
 			}
-			if data.IPAM.Config[0].Subnet != subnet || data.IPAM.Config[0].Gateway != gateway {
+
+			if !networkConfigMatch(data, subnet, gateway, subnet6, gateway6) {
 				if err = el.DockerSys[0].NetworkRemove(networkData.ID); err != nil {
 					err = fmt.Errorf("network.NetworkCreate().NetworkRemove().error: %v", err)
 					return
 				}
-
-				break
 			}
 
-			el.network.generator = el.DockerSys[0].NetworkGetGenerator(name)
-			return
+			// NetworkCreateDualStack() reuses the network with the same configuration
+			break
 		}
 	}
 
-	if el.network.networkID, el.network.generator, err = el.DockerSys[0].NetworkCreate(name, builder.KNetworkDriveBridge, "local", subnet, gateway); err != nil {
+	if el.network.networkID, el.network.generator, err = el.DockerSys[0].NetworkCreateDualStack(name, builder.KNetworkDriveBridge, "local", subnet, gateway, subnet6, gateway6); err != nil {
 		err = fmt.Errorf("network.NetworkCreate().NetworkCreate().error: %v", err)
 		return
 	}
 
 	networkManagerGlobal = el.network
+	el.networkAdd(el.network)
 
 	networkId = el.network.networkID
 	return
 }
 
+// networkAdd
+//
+// Adds the network to the list of networks, replacing the network with the same name
+func (el *Manager) networkAdd(network *dockerNetwork) {
+	for k := range el.networks {
+		if el.networks[k].networkName == network.networkName {
+			el.networks[k] = network
+			return
+		}
+	}
+
+	el.networks = append(el.networks, network)
+}
+
+// networkByName
+//
+// Returns the network created by NetworkCreate(), with or without the prefix `delete_` in the name, or nil
+func (el *Manager) networkByName(name string) (network *dockerNetwork) {
+	for _, network = range el.networks {
+		if network.networkName == name || network.networkName == "delete_"+name {
+			return
+		}
+	}
+
+	return nil
+}
+
+// networkConfigMatch
+//
+// Returns true when the network has the subnets and gateways, and IPv6 is enabled only when the IPv6 subnet is defined
+func networkConfigMatch(data types.NetworkResource, subnet, gateway, subnet6, gateway6 string) (match bool) {
+	if !builder.NetworkIpamMatch(data.IPAM.Config, subnet, gateway) {
+		return false
+	}
+
+	if data.EnableIPv6 != (subnet6 != "") {
+		return false
+	}
+
+	if subnet6 != "" && !builder.NetworkIpamMatch(data.IPAM.Config, subnet6, gateway6) {
+		return false
+	}
+
+	return true
+}
+
 func (el *Manager) ContainerFromImage(imageName string) (containerFromImage *ContainerFromImage) {
 	containerFromImage = new(ContainerFromImage)
 	containerFromImage.manager = el
@@ -0,0 +1,342 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics of all containers, exported by Primordial.Metrics(). Nil when the export is disabled
+var metricsGlobal *metricsRegistry
+
+// metricsLabels
+//
+// Labels of the metrics of one copy
+type metricsLabels struct {
+	container string
+	iCopy     int
+	image     string
+}
+
+// key
+//
+// Order of the copies in the export
+func (el metricsLabels) key() (key string) {
+	return el.container + "\x00" + fmt.Sprintf("%08d", el.iCopy)
+}
+
+// metricsCopy
+//
+// Last values of one copy
+type metricsCopy struct {
+	labels metricsLabels
+
+	// Last sample of the statistics, nil before the first sample
+	stats      *types.StatsJSON
+	cpuPercent float64
+
+	// Executed chaos actions, where key is the action, e.g. pause and unpause
+	chaosActions map[string]uint64
+
+	failFlagMatches uint64
+	ready           bool
+}
+
+// metricsRegistry
+//
+// Metrics of all copies, written by the threads of the containers and read by the http server
+type metricsRegistry struct {
+	mutex  sync.Mutex
+	copies map[string]*metricsCopy
+
+	server   *http.Server
+	listener net.Listener
+}
+
+// metricsFamily
+//
+// Metric with its samples, in the order of the export
+type metricsFamily struct {
+	name    string
+	kind    string
+	help    string
+	samples []metricsSample
+}
+
+// metricsSample
+//
+// One value of a metric
+type metricsSample struct {
+	labels []string
+	value  string
+}
+
+// get
+//
+// Returns the values of the copy, created on the first call
+func (el *metricsRegistry) get(labels metricsLabels) (values *metricsCopy) {
+	if el.copies == nil {
+		el.copies = make(map[string]*metricsCopy)
+	}
+
+	var found bool
+	if values, found = el.copies[labels.key()]; !found {
+		values = &metricsCopy{labels: labels, chaosActions: make(map[string]uint64)}
+		el.copies[labels.key()] = values
+	}
+
+	return
+}
+
+// stats
+//
+// Saves the last sample of the statistics of the copy
+func (el *metricsRegistry) stats(labels metricsLabels, stats types.StatsJSON, cpuPercent float64) {
+	if el == nil {
+		return
+	}
+
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	var values = el.get(labels)
+	values.stats = &stats
+	values.cpuPercent = cpuPercent
+}
+
+// chaosAction
+//
+// Counts one chaos action executed in the copy. The action is the display of the chaos without arguments, e.g.
+// partition(delete_backend) is counted as partition
+func (el *metricsRegistry) chaosAction(labels metricsLabels, display string) {
+	if el == nil {
+		return
+	}
+
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	var action = strings.SplitN(display, "(", 2)[0]
+	el.get(labels).chaosActions[action] += 1
+}
+
+// failFlag
+//
+// Counts one fail flag found in the standard output of the copy
+func (el *metricsRegistry) failFlag(labels metricsLabels) {
+	if el == nil {
+		return
+	}
+
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	el.get(labels).failFlagMatches += 1
+}
+
+// ready
+//
+// Defines the readiness of the copy, true after the start and WaitForFlag()
+func (el *metricsRegistry) ready(labels metricsLabels, ready bool) {
+	if el == nil {
+		return
+	}
+
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	el.get(labels).ready = ready
+}
+
+// families
+//
+// Metrics of all copies, in the order of the copies
+func (el *metricsRegistry) families() (families []*metricsFamily) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	var keys = make([]string, 0, len(el.copies))
+	for key := range el.copies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var list = make(map[string]*metricsFamily)
+	var add = func(name, kind, help string, values *metricsCopy, value string, labels ...string) {
+		var family, found = list[name]
+		if !found {
+			family = &metricsFamily{name: name, kind: kind, help: help}
+			list[name] = family
+			families = append(families, family)
+		}
+
+		labels = append([]string{"container", values.labels.container, "copy", strconv.Itoa(values.labels.iCopy), "image", values.labels.image}, labels...)
+		family.samples = append(family.samples, metricsSample{labels: labels, value: value})
+	}
+
+	var formatUint = func(value uint64) string { return strconv.FormatUint(value, 10) }
+
+	for _, key := range keys {
+		var values = el.copies[key]
+
+		var ready = "0"
+		if values.ready {
+			ready = "1"
+		}
+		add("chaos_container_ready", "gauge", "Readiness of the container, 1 after the start and WaitForFlag().", values, ready)
+		add("chaos_fail_flag_matches", "counter", "Fail flags found in the standard output of the container.", values, formatUint(values.failFlagMatches))
+
+		var actions = make([]string, 0, len(values.chaosActions))
+		for action := range values.chaosActions {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+		for _, action := range actions {
+			add("chaos_actions", "counter", "Chaos actions executed in the container.", values, formatUint(values.chaosActions[action]), "action", action)
+		}
+
+		var stats = values.stats
+		if stats == nil {
+			continue
+		}
+
+		add("chaos_container_last_sample_timestamp_seconds", "gauge", "Time of the last sample of the statistics.", values, strconv.FormatFloat(float64(stats.Read.UnixNano())/float64(time.Second), 'f', 3, 64))
+		add("chaos_container_cpu_percent", "gauge", "Cpu usage between the last two samples, where 100 is one cpu.", values, strconv.FormatFloat(values.cpuPercent, 'f', 2, 64))
+		add("chaos_container_cpu_online", "gauge", "Number of cpus available to the container.", values, formatUint(uint64(stats.CPUStats.OnlineCPUs)))
+		add("chaos_container_cpu_throttled_periods", "counter", "Periods in which the container was throttled.", values, formatUint(stats.CPUStats.ThrottlingData.ThrottledPeriods))
+		add("chaos_container_memory_usage_bytes", "gauge", "Memory usage of the container.", values, formatUint(stats.MemoryStats.Usage))
+		add("chaos_container_memory_working_set_bytes", "gauge", "Memory usage of the container without the inactive page cache.", values, formatUint(statsWorkingSet(stats.MemoryStats)))
+		add("chaos_container_memory_limit_bytes", "gauge", "Memory limit of the container.", values, formatUint(stats.MemoryStats.Limit))
+		add("chaos_container_pids", "gauge", "Number of processes and threads of the container.", values, formatUint(stats.PidsStats.Current))
+
+		var readBytes, writeBytes = blkioTotal(stats.BlkioStats.IoServiceBytesRecursive)
+		add("chaos_container_blkio_read_bytes", "counter", "Bytes read from the block devices.", values, formatUint(readBytes))
+		add("chaos_container_blkio_write_bytes", "counter", "Bytes written to the block devices.", values, formatUint(writeBytes))
+
+		var interfaces = make([]string, 0, len(stats.Networks))
+		for name := range stats.Networks {
+			interfaces = append(interfaces, name)
+		}
+		sort.Strings(interfaces)
+		for _, name := range interfaces {
+			var network = stats.Networks[name]
+			add("chaos_container_network_receive_bytes", "counter", "Bytes received by the network interface.", values, formatUint(network.RxBytes), "interface", name)
+			add("chaos_container_network_receive_errors", "counter", "Receive errors of the network interface.", values, formatUint(network.RxErrors), "interface", name)
+			add("chaos_container_network_receive_dropped", "counter", "Received packets dropped by the network interface.", values, formatUint(network.RxDropped), "interface", name)
+			add("chaos_container_network_transmit_bytes", "counter", "Bytes transmitted by the network interface.", values, formatUint(network.TxBytes), "interface", name)
+			add("chaos_container_network_transmit_errors", "counter", "Transmit errors of the network interface.", values, formatUint(network.TxErrors), "interface", name)
+			add("chaos_container_network_transmit_dropped", "counter", "Transmitted packets dropped by the network interface.", values, formatUint(network.TxDropped), "interface", name)
+		}
+	}
+
+	return
+}
+
+// write
+//
+// Writes the metrics in the Prometheus text format or, with openMetrics, in the OpenMetrics format
+func (el *metricsRegistry) write(w io.Writer, openMetrics bool) (err error) {
+	var buffer bytes.Buffer
+	for _, family := range el.families() {
+		// Prometheus uses the suffix _total in the name of the family, OpenMetrics only in the samples
+		var sampleName = family.name
+		var familyName = family.name
+		if family.kind == "counter" {
+			sampleName += "_total"
+			if !openMetrics {
+				familyName = sampleName
+			}
+		}
+
+		_, _ = fmt.Fprintf(&buffer, "# HELP %v %v\n", familyName, family.help)
+		_, _ = fmt.Fprintf(&buffer, "# TYPE %v %v\n", familyName, family.kind)
+		for _, sample := range family.samples {
+			buffer.WriteString(sampleName)
+			buffer.WriteString("{")
+			for k := 0; k < len(sample.labels); k += 2 {
+				if k != 0 {
+					buffer.WriteString(",")
+				}
+				_, _ = fmt.Fprintf(&buffer, "%v=\"%v\"", sample.labels[k], metricsEscape(sample.labels[k+1]))
+			}
+			buffer.WriteString("} ")
+			buffer.WriteString(sample.value)
+			buffer.WriteString("\n")
+		}
+	}
+
+	if openMetrics {
+		buffer.WriteString("# EOF\n")
+	}
+
+	_, err = w.Write(buffer.Bytes())
+	return
+}
+
+// metricsEscape
+//
+// Escapes the value of a label
+func metricsEscape(value string) (escaped string) {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// ServeHTTP
+//
+// Exports the metrics, in the OpenMetrics format when requested by the Accept header
+func (el *metricsRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var openMetrics = strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	}
+
+	_ = el.write(w, openMetrics)
+}
+
+// listen
+//
+// Starts the http server of the metrics on the address
+func (el *metricsRegistry) listen(address string) (err error) {
+	if el.listener, err = net.Listen("tcp", address); err != nil {
+		return
+	}
+
+	var mux = http.NewServeMux()
+	mux.Handle("/metrics", el)
+
+	el.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = el.server.Serve(el.listener)
+	}()
+
+	return
+}
+
+// close
+//
+// Stops the http server of the metrics
+func (el *metricsRegistry) close() {
+	if el.server != nil {
+		_ = el.server.Close()
+	}
+}
+
+// metricsLabels
+//
+// Labels of the metrics of the copy
+func (el *ContainerFromImage) metricsLabels(iCopy int) (labels metricsLabels) {
+	return metricsLabels{
+		container: el.containerName,
+		iCopy:     iCopy,
+		image:     el.copyImageName(iCopy),
+	}
+}
@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"bytes"
+	"github.com/docker/docker/api/types"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetricsRegistry_write(t *testing.T) {
+	var registry = new(metricsRegistry)
+	var labels = metricsLabels{container: "delete_db", iCopy: 1, image: "mongo:6.0.6"}
+
+	var stats types.StatsJSON
+	stats.MemoryStats.Usage = 1000
+	stats.MemoryStats.Stats = map[string]uint64{"inactive_file": 200}
+	stats.Networks = map[string]types.NetworkStats{"eth0": {RxBytes: 42}}
+
+	registry.stats(labels, stats, 12.5)
+	registry.ready(labels, true)
+	registry.chaosAction(labels, "pause()")
+	registry.chaosAction(labels, "partition(delete_backend)")
+	registry.chaosAction(labels, "pause()")
+	registry.failFlag(labels)
+
+	var buffer bytes.Buffer
+	if err := registry.write(&buffer, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var copyLabels = `container="delete_db",copy="1",image="mongo:6.0.6"`
+	for _, line := range []string{
+		"# TYPE chaos_actions_total counter",
+		"chaos_container_ready{" + copyLabels + "} 1",
+		"chaos_fail_flag_matches_total{" + copyLabels + "} 1",
+		"chaos_actions_total{" + copyLabels + `,action="partition"} 1`,
+		"chaos_actions_total{" + copyLabels + `,action="pause"} 2`,
+		"chaos_container_cpu_percent{" + copyLabels + "} 12.50",
+		"chaos_container_memory_working_set_bytes{" + copyLabels + "} 800",
+		"chaos_container_network_receive_bytes_total{" + copyLabels + `,interface="eth0"} 42`,
+	} {
+		if !strings.Contains(buffer.String(), line+"\n") {
+			t.Errorf("line not found: %v", line)
+		}
+	}
+
+	if strings.Contains(buffer.String(), "# EOF") {
+		t.Errorf("the Prometheus format must not have # EOF")
+	}
+
+	buffer.Reset()
+	if err := registry.write(&buffer, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(buffer.String(), "# EOF\n") {
+		t.Errorf("the OpenMetrics format must end with # EOF")
+	}
+
+	if !strings.Contains(buffer.String(), "# TYPE chaos_actions counter\n") {
+		t.Errorf("the OpenMetrics family must not have the suffix _total")
+	}
+}
+
+func TestMetricsRegistry_nil(t *testing.T) {
+	var registry *metricsRegistry
+	var labels = metricsLabels{container: "delete_db"}
+
+	registry.stats(labels, types.StatsJSON{}, 0)
+	registry.ready(labels, true)
+	registry.chaosAction(labels, "pause()")
+	registry.failFlag(labels)
+}
+
+func TestMetricsRegistry_listen(t *testing.T) {
+	var registry = new(metricsRegistry)
+	if err := registry.listen("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer registry.close()
+
+	registry.ready(metricsLabels{container: `delete_"db"`}, true)
+
+	var request, err = http.NewRequest(http.MethodGet, "http://"+registry.listener.Addr().String()+"/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+
+	var response *http.Response
+	if response, err = http.DefaultClient.Do(request); err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	if !strings.HasPrefix(response.Header.Get("Content-Type"), "application/openmetrics-text") {
+		t.Errorf("unexpected content type: %v", response.Header.Get("Content-Type"))
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(response.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(body), `chaos_container_ready{container="delete_\"db\"",copy="0",image=""} 1`) {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
@@ -0,0 +1,280 @@
+package manager
+
+import (
+	"fmt"
+	networkTypes "github.com/docker/docker/api/types/network"
+	"github.com/helmutkemper/chaos/internal/monitor"
+)
+
+// containerNetworkEndpoint
+//
+// Connection of one copy to one network
+type containerNetworkEndpoint struct {
+	networkID   string
+	networkName string
+	settings    *networkTypes.EndpointSettings
+}
+
+// containerNetworkAddress
+//
+// Addresses of one copy in one network
+type containerNetworkAddress struct {
+	networkName string
+	ipV4Address string
+	ipV6Address string
+}
+
+// Networks
+//
+// English:
+//
+//	Connects each copy to the networks, in the order of the list, with addresses generated by each network.
+//
+//	 Input:
+//	   names: names of the networks created by NetworkCreate(), with or without the prefix `delete_`
+//
+//	 Notes:
+//	   * Each copy is reachable, in all its networks, by its name and by the container name, e.g. delete_db_0 and
+//	     delete_db;
+//	   * IPV4Address and IPV6Address keep the addresses of the first network. GetNetworkIpAddress() returns the
+//	     addresses of the other networks;
+//	   * Without Networks(), the copies are connected to the last network created.
+//
+// Português:
+//
+//	Conecta cada cópia às redes, na ordem da lista, com endereços gerados por cada rede.
+//
+//	 Entrada:
+//	   names: nomes das redes criadas por NetworkCreate(), com ou sem o prefixo `delete_`
+//
+//	 Notas:
+//	   * Cada cópia é alcançável, em todas as suas redes, pelo seu nome e pelo nome do container, ex. delete_db_0 e
+//	     delete_db;
+//	   * IPV4Address e IPV6Address guardam os endereços da primeira rede. GetNetworkIpAddress() retorna os endereços
+//	     das demais redes;
+//	   * Sem Networks(), as cópias são conectadas à última rede criada.
+func (el *ContainerFromImage) Networks(names ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.networks = names
+	return el
+}
+
+// StaticIpAddress
+//
+// English:
+//
+//	Defines the IPv4 address of each copy, instead of the next free address of the network.
+//
+//	 Input:
+//	   addresses: one address per copy, in the order of the copies. Use "" for the next free address
+//
+//	 Notes:
+//	   * With Networks(), the address belongs to the first network;
+//	   * The address must be inside the subnet and not in use by another container.
+//
+// Português:
+//
+//	Define o endereço IPv4 de cada cópia, no lugar do próximo endereço livre da rede.
+//
+//	 Entrada:
+//	   addresses: um endereço por cópia, na ordem das cópias. Use "" para o próximo endereço livre
+//
+//	 Notas:
+//	   * Com Networks(), o endereço pertence à primeira rede;
+//	   * O endereço deve estar dentro da subnet e não estar em uso por outro container.
+func (el *ContainerFromImage) StaticIpAddress(addresses ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.staticIpV4Address = addresses
+	return el
+}
+
+// StaticIpV6Address
+//
+// English:
+//
+//	Defines the IPv6 address of each copy, instead of the next free address of the dual-stack network.
+//
+//	 Input:
+//	   addresses: one address per copy, in the order of the copies. Use "" for the next free address
+//
+//	 Notes:
+//	   * With Networks(), the address belongs to the first network;
+//	   * The address must be inside the subnet and not in use by another container.
+//
+// Português:
+//
+//	Define o endereço IPv6 de cada cópia, no lugar do próximo endereço livre da rede dual-stack.
+//
+//	 Entrada:
+//	   addresses: um endereço por cópia, na ordem das cópias. Use "" para o próximo endereço livre
+//
+//	 Notas:
+//	   * Com Networks(), o endereço pertence à primeira rede;
+//	   * O endereço deve estar dentro da subnet e não estar em uso por outro container.
+func (el *ContainerFromImage) StaticIpV6Address(addresses ...string) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.staticIpV6Address = addresses
+	return el
+}
+
+// staticAddress
+//
+// Static address of the copy, or "" for the next free address. Unlike copyValue(), one address is not shared by all
+// copies
+func (el *ContainerFromImage) staticAddress(addresses []string, iCopy int) (address string) {
+	if iCopy < len(addresses) {
+		return addresses[iCopy]
+	}
+
+	return ""
+}
+
+// networkRelease
+//
+// Releases the addresses of the removed copy in all its networks
+func (el *ContainerFromImage) networkRelease(iCopy int) {
+	if len(el.networks) == 0 {
+		if networkManagerGlobal != nil {
+			networkManagerGlobal.generator.Release(el.copyName(iCopy))
+		}
+		return
+	}
+
+	for _, name := range el.networks {
+		if network := el.manager.networkByName(name); network != nil {
+			network.generator.Release(el.copyName(iCopy))
+		}
+	}
+}
+
+// GetNetworkIpAddress
+//
+// English:
+//
+//	Returns the addresses of one copy in one network.
+//
+//	 Input:
+//	   network: name of the network, with or without the prefix `delete_`
+//	   iCopy: index of the copy, from zero to copies - 1
+//
+//	 Output:
+//	   ipV4Address: IPv4 address of the copy
+//	   ipV6Address: IPv6 address of the copy, or "" when the network is not dual-stack
+//
+// Português:
+//
+//	Retorna os endereços de uma cópia em uma rede.
+//
+//	 Entrada:
+//	   network: nome da rede, com ou sem o prefixo `delete_`
+//	   iCopy: índice da cópia, de zero até copies - 1
+//
+//	 Saída:
+//	   ipV4Address: endereço IPv4 da cópia
+//	   ipV6Address: endereço IPv6 da cópia, ou "" quando a rede não é dual-stack
+func (el *ContainerFromImage) GetNetworkIpAddress(network string, iCopy int) (ipV4Address, ipV6Address string) {
+	if iCopy < 0 || iCopy >= len(el.networkAddress) {
+		return
+	}
+
+	for _, address := range el.networkAddress[iCopy] {
+		if address.networkName == network || address.networkName == "delete_"+network {
+			return address.ipV4Address, address.ipV6Address
+		}
+	}
+
+	return
+}
+
+// networkEndpoints
+//
+// Generates the addresses of the copy in each network defined by Networks(). The first network is used to create the
+// container, the others are connected before the start
+func (el *ContainerFromImage) networkEndpoints(iCopy int) (netConfig *networkTypes.NetworkingConfig, extra []containerNetworkEndpoint, addresses []containerNetworkAddress, err error) {
+	var aliases = []string{el.copyName(iCopy), el.containerName}
+
+	for k, name := range el.networks {
+		var network = el.manager.networkByName(name)
+		if network == nil {
+			err = fmt.Errorf("network %v not found. Use NetworkCreate() first", name)
+			return
+		}
+
+		// the static addresses belong to the first network
+		var staticIpV4Address, staticIpV6Address string
+		if k == 0 {
+			staticIpV4Address = el.staticAddress(el.staticIpV4Address, iCopy)
+			staticIpV6Address = el.staticAddress(el.staticIpV6Address, iCopy)
+		}
+
+		var ipV4Address, ipV6Address string
+		var config *networkTypes.NetworkingConfig
+		if ipV4Address, ipV6Address, config, err = network.generator.Lease(el.copyName(iCopy), staticIpV4Address, staticIpV6Address); err != nil {
+			err = fmt.Errorf("network %v: %v", network.networkName, err)
+			return
+		}
+
+		var settings = config.EndpointsConfig[network.networkName]
+		settings.Aliases = aliases
+
+		addresses = append(addresses, containerNetworkAddress{
+			networkName: network.networkName,
+			ipV4Address: ipV4Address,
+			ipV6Address: ipV6Address,
+		})
+
+		if k == 0 {
+			netConfig = config
+			continue
+		}
+
+		extra = append(extra, containerNetworkEndpoint{
+			networkID:   network.networkID,
+			networkName: network.networkName,
+			settings:    settings,
+		})
+	}
+
+	return
+}
+
+// networkConnect
+//
+// Connects the copy to the networks after the first one
+func (el *ContainerFromImage) networkConnect(iCopy int, id string, extra []containerNetworkEndpoint) (err error) {
+	for _, endpoint := range extra {
+		if err = el.manager.DockerSys[iCopy].NetworkConnect(endpoint.networkID, id, endpoint.settings); err != nil {
+			err = fmt.Errorf("network %v: %v", endpoint.networkName, err)
+			return
+		}
+	}
+
+	return
+}
+
+// copyEndpoints
+//
+// All network connections of the copy, the network used on creation first
+func (el *ContainerFromImage) copyEndpoints(iCopy int) (endpoints []containerNetworkEndpoint) {
+	var args = el.createArgs[iCopy]
+	if args.network != nil {
+		for name, settings := range args.network.EndpointsConfig {
+			endpoints = append(endpoints, containerNetworkEndpoint{
+				networkID:   settings.NetworkID,
+				networkName: name,
+				settings:    settings,
+			})
+		}
+	}
+
+	return append(endpoints, args.extraNetworks...)
+}
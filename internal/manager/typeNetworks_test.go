@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"github.com/helmutkemper/chaos/internal/builder"
+	"testing"
+)
+
+func TestContainerFromImage_networkEndpoints(t *testing.T) {
+	monitorErrReset(t)
+
+	var manager = new(Manager)
+	for _, name := range []string{"delete_frontend", "delete_backend"} {
+		var generator = new(builder.NextNetworkAutoConfiguration)
+		generator.Init("id_"+name, name, "10.0.0.1", "10.0.0.0/16")
+		manager.networkAdd(&dockerNetwork{generator: generator, networkID: "id_" + name, networkName: name})
+	}
+
+	var container = manager.ContainerFromImage("nats:latest").Networks("frontend", "delete_backend")
+	container.containerName = "delete_nats"
+
+	var netConfig, extra, addresses, err = container.networkEndpoints(0)
+	if err != nil {
+		t.Fatalf("networkEndpoints().error: %v", err)
+	}
+
+	var settings = netConfig.EndpointsConfig["delete_frontend"]
+	if settings == nil || settings.IPAddress != "10.0.0.2" || len(settings.Aliases) != 2 || settings.Aliases[1] != "delete_nats" {
+		t.Errorf("unexpected first network: %+v", settings)
+	}
+
+	if len(extra) != 1 || extra[0].networkID != "id_delete_backend" || extra[0].settings.IPAddress != "10.0.0.2" {
+		t.Errorf("unexpected extra networks: %+v", extra)
+	}
+
+	container.networkAddress = [][]containerNetworkAddress{addresses}
+	if ip, _ := container.GetNetworkIpAddress("backend", 0); ip != "10.0.0.2" {
+		t.Errorf("unexpected address: %v", ip)
+	}
+
+	container.Networks("replication")
+	if _, _, _, err = container.networkEndpoints(0); err == nil {
+		t.Errorf("a network not created must fail")
+	}
+}
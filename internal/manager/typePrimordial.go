@@ -3,9 +3,11 @@ package manager
 import (
 	"bytes"
 	"fmt"
+	"github.com/helmutkemper/chaos/internal/builder"
 	"github.com/helmutkemper/chaos/internal/monitor"
 	"github.com/helmutkemper/chaos/internal/standalone"
 	"io/fs"
+	"log"
 	"os"
 	"path"
 	"path/filepath"
@@ -16,8 +18,20 @@ import (
 
 var networkManagerGlobal *dockerNetwork
 
+// Folder defined in Test() to save the test artifacts
+var testPathGlobal = "./"
+
+// Name of the test defined in Test()
+var testNameGlobal = "chaos"
+
+// Start of the test defined in Test(), zero when the folder of the artifacts was not defined
+var testStartGlobal time.Time
+
 type Primordial struct {
 	manager *Manager
+
+	// Result of the last MonitorResult(), completed by the cleanup of Test()
+	result *Result
 }
 
 func (el *Primordial) getLogs(id string) (log []byte, err error) {
@@ -51,6 +65,22 @@ func (el *Primordial) getLogs(id string) (log []byte, err error) {
 	return
 }
 
+// Test
+//
+// Prepares the test cleanup, which saves the artifacts of the test in the folder and removes the docker elements
+//
+//	Input:
+//	  t: test of the containers
+//	  pathToSave: folder of the artifacts, e.g. the logs of the containers and report.html
+//	  names: additional list of terms to be deleted by GarbageCollector()
+//
+//	Notes:
+//	  * report.html combines, in one file viewable offline, the chaos timeline, the statistics charts of
+//	    SaveStatistics(), the colored logs with the chaos actions between the lines, the fail flags, the readiness
+//	    timings and the vulnerability summary;
+//	  * timeline.log merges the log lines and chaos actions of all containers on one clock, with the gaps defined by
+//	    TimelineGap();
+//	  * With KeepOnFailure(), the docker elements of a failed test are kept instead of removed.
 func (el *Primordial) Test(t *testing.T, pathToSave string, names ...string) (ref *Primordial) {
 	var log []byte
 
@@ -60,7 +90,31 @@ func (el *Primordial) Test(t *testing.T, pathToSave string, names ...string) (re
 		return el
 	}
 
+	testPathGlobal = pathToSave
+	testNameGlobal = t.Name()
+	testStartGlobal = time.Now()
+
 	t.Cleanup(func() {
+		defer func() {
+			testStartGlobal = time.Time{}
+		}()
+
+		// Saves the state of the containers after a fail flag, before the cleanup functions and the report
+		forensicsGlobal.run()
+
+		// Runs the functions that depend on the containers before removing them, e.g. coverage collection
+		monitor.CleanupAll()
+
+		// Merges the logs and chaos actions of all containers before the report empties the registry
+		if err := el.Timeline().write(pathToSave, timelineGapGlobal); err != nil {
+			ErrorCh <- fmt.Errorf("primordial.Test().timeline.error: %v", err)
+		}
+
+		// Combines the chaos actions, statistics, logs, fail flags and forensics of all containers before removing them
+		if err := reportGlobal.write(pathToSave, t.Name(), t.Failed()); err != nil {
+			ErrorCh <- fmt.Errorf("primordial.Test().report.error: %v", err)
+		}
+		forensicsGlobal.reset()
 
 		// Saves contents of containers before deleting
 		containers, err := el.manager.DockerSys[0].ContainerListAll()
@@ -74,7 +128,7 @@ func (el *Primordial) Test(t *testing.T, pathToSave string, names ...string) (re
 				continue
 			}
 
-			if strings.Contains(container.Names[0], "delete") {
+			if container.Labels[standalone.KLabelSession] == standalone.SessionId() {
 				log, err = el.getLogs(container.ID)
 				if err != nil {
 					ErrorCh <- fmt.Errorf("primordial.Test().error: %v", err)
@@ -88,7 +142,7 @@ func (el *Primordial) Test(t *testing.T, pathToSave string, names ...string) (re
 				}
 
 				pathData := path.Join(pathAbs, container.Names[0]+".log")
-				err = os.WriteFile(pathData, log, fs.ModePerm)
+				err = os.WriteFile(pathData, []byte(monitor.Redact(string(log))), fs.ModePerm)
 				if err != nil {
 					ErrorCh <- fmt.Errorf("primordial.Test().error: %v", err)
 					return
@@ -104,11 +158,29 @@ func (el *Primordial) Test(t *testing.T, pathToSave string, names ...string) (re
 					}
 
 					pathData := path.Join(pathToSave, container.Names[0]+".log")
-					err = os.WriteFile(pathData, log, fs.ModePerm)
+					err = os.WriteFile(pathData, []byte(monitor.Redact(string(log))), fs.ModePerm)
 				}
 			}
 		}
 
+		// The logs and report.html are artifacts of the result
+		if el.result != nil {
+			el.result.Artifacts = resultArtifacts(pathToSave, testStartGlobal)
+			if err = el.result.write(pathToSave); err != nil {
+				ErrorCh <- fmt.Errorf("primordial.Test().result.error: %v", err)
+			}
+		}
+
+		// Keeps the containers of the failed test for debugging, instead of removing them
+		if keepOnFailure() && (t.Failed() || el.result != nil && !el.result.Pass) {
+			var info string
+			if info, err = el.keepEnvironment(); err != nil {
+				ErrorCh <- fmt.Errorf("primordial.Test().keepOnFailure.error: %v", err)
+			}
+			t.Log(info)
+			return
+		}
+
 		el.GarbageCollector(names...)
 	})
 
@@ -125,6 +197,8 @@ func (el *Primordial) Test(t *testing.T, pathToSave string, names ...string) (re
 //	  gateway: gateway value. eg. "10.0.0.1
 //
 //	Notes:
+//	  * Call NetworkCreate() once per network to create several networks, e.g. frontend, backend and replication. The
+//	    containers are connected to the last network created, or to the networks defined by Networks();
 //	  * If there is already a network with the same name and the same configuration, nothing will be done;
 //	  * If a network with the same name and different configuration already exists, the network will be deleted and a new network created.
 func (el *Primordial) NetworkCreate(name, subnet, gateway string) (ref *Primordial) {
@@ -134,7 +208,7 @@ func (el *Primordial) NetworkCreate(name, subnet, gateway string) (ref *Primordi
 		name = "delete_" + name
 	}
 
-	if _, err = el.manager.networkCreate(name, subnet, gateway); err != nil {
+	if _, err = el.manager.networkCreate(name, subnet, gateway, "", ""); err != nil {
 		ErrorCh <- fmt.Errorf("primordial.NetworkCreate().error: %v", err)
 		return el
 	}
@@ -142,14 +216,77 @@ func (el *Primordial) NetworkCreate(name, subnet, gateway string) (ref *Primordi
 	return el
 }
 
+// NetworkCreateDualStack
+//
+// Create a dual-stack docker network, IPv4 and IPv6, to be used in the chaos test
+//
+//	Input:
+//	  name: network name
+//	  subnet: subnet value. eg. 10.0.0.0/16
+//	  gateway: gateway value. eg. 10.0.0.1
+//	  subnet6: IPv6 subnet value. eg. fd00:c4a0::/64
+//	  gateway6: IPv6 gateway value. eg. fd00:c4a0::1
+//
+//	Notes:
+//	  * Each copy receives one IPv4 and one IPv6 address, both registered in the monitor;
+//	  * If there is already a network with the same name and the same configuration, nothing will be done;
+//	  * If a network with the same name and different configuration already exists, the network will be deleted and a new network created.
+func (el *Primordial) NetworkCreateDualStack(name, subnet, gateway, subnet6, gateway6 string) (ref *Primordial) {
+	var err error
+
+	if !strings.Contains(name, "delete") {
+		name = "delete_" + name
+	}
+
+	if _, err = el.manager.networkCreate(name, subnet, gateway, subnet6, gateway6); err != nil {
+		ErrorCh <- fmt.Errorf("primordial.NetworkCreateDualStack().error: %v", err)
+		return el
+	}
+
+	return el
+}
+
 // Monitor
 //
 // Monitors the test for errors while waiting for the test to end.
 //
 //	Notes:
 //	  * When the test timer ends, Monitor() waits for all test pipelines to finish, hooking up all containers at the
-//	    end of the test
+//	    end of the test;
+//	  * Same as MonitorResult(duration).Pass.
 func (el *Primordial) Monitor(duration time.Duration) (pass bool) {
+	return el.MonitorResult(duration).Pass
+}
+
+// MonitorResult
+//
+// English:
+//
+//	Monitors the test for errors while waiting for the test to end, and returns the outcome of the test.
+//
+//	 Output:
+//	   result: status (pass, fail or error), reason, container and copy of the fail flag, chaos actions executed,
+//	     duration and artifacts of the test
+//
+//	 Notes:
+//	   * The result is saved as result.json and junit.xml in the folder defined by Test(), and saved again by the
+//	     cleanup of Test() with the logs and report.html in the artifacts. Without Test(), nothing is saved;
+//	   * The artifacts are the files written in the folder during the test.
+//
+// Português:
+//
+//	Monitora o teste em busca de erros enquanto espera o fim do teste, e retorna o resultado do teste.
+//
+//	 Saída:
+//	   result: status (pass, fail ou error), motivo, container e cópia do fail flag, ações de caos executadas, duração
+//	     e artefatos do teste
+//
+//	 Notas:
+//	   * O resultado é salvo como result.json e junit.xml na pasta definida por Test(), e salvo de novo pela limpeza de
+//	     Test() com os logs e o report.html nos artefatos. Sem Test(), nada é salvo;
+//	   * Os artefatos são os arquivos escritos na pasta durante o teste.
+func (el *Primordial) MonitorResult(duration time.Duration) (result Result) {
+	var start = time.Now()
 	var timer = time.NewTimer(duration)
 	go func() {
 		select {
@@ -159,7 +296,124 @@ func (el *Primordial) Monitor(duration time.Duration) (pass bool) {
 		monitor.EndAll()
 	}()
 
-	return monitor.Monitor()
+	var status, reason = monitor.MonitorStatus()
+	result = resultMake(testNameGlobal, status, reason, start, time.Now())
+	el.result = &result
+
+	// without Test(), there is no folder for the artifacts
+	if testStartGlobal.IsZero() {
+		return
+	}
+
+	result.Artifacts = resultArtifacts(testPathGlobal, testStartGlobal)
+	if err := result.write(testPathGlobal); err != nil {
+		log.Printf("primordial.MonitorResult().error: %v", err)
+	}
+
+	return
+}
+
+// Metrics
+//
+// English:
+//
+//	Starts a local http endpoint, http://<address>/metrics, that exports the statistics, the chaos actions, the fail
+//	flags found and the readiness of every container, live, in the Prometheus text format, or in the OpenMetrics format
+//	when requested by the Accept header.
+//
+//	 Input:
+//	   address: address of the http server, e.g. 127.0.0.1:9100. Use 127.0.0.1:0 for a free port
+//
+//	 Notes:
+//	   * The metrics have the labels container, copy and image;
+//	   * The statistics are sampled in the interval defined by StatsInterval(), even without SaveStatistics();
+//	   * Call Metrics() before Start(), and GetMetricsAddress() returns the address in use;
+//	   * The endpoint is stopped by the cleanup of Test().
+//
+// Português:
+//
+//	Inicia um endpoint http local, http://<address>/metrics, que exporta as estatísticas, as ações de caos, os fail
+//	flags encontrados e a prontidão de todos os containers, ao vivo, no formato texto do Prometheus, ou no formato
+//	OpenMetrics quando pedido pelo cabeçalho Accept.
+//
+//	 Entrada:
+//	   address: endereço do servidor http, ex. 127.0.0.1:9100. Use 127.0.0.1:0 para uma porta livre
+//
+//	 Notas:
+//	   * As métricas têm os labels container, copy e image;
+//	   * As estatísticas são amostradas no intervalo definido por StatsInterval(), mesmo sem SaveStatistics();
+//	   * Chame Metrics() antes de Start(), e GetMetricsAddress() retorna o endereço em uso;
+//	   * O endpoint é parado pela limpeza de Test().
+func (el *Primordial) Metrics(address string) (ref *Primordial) {
+	if metricsGlobal != nil {
+		metricsGlobal.close()
+	}
+
+	var registry = new(metricsRegistry)
+	if err := registry.listen(address); err != nil {
+		ErrorCh <- fmt.Errorf("primordial.Metrics().error: %v", err)
+		return el
+	}
+
+	metricsGlobal = registry
+	monitor.AddCleanupFunc(registry.close)
+	return el
+}
+
+// GetMetricsAddress
+//
+// English:
+//
+//	Returns the address of the metrics endpoint started by Metrics(), or "" when the endpoint is disabled
+//
+// Português:
+//
+//	Retorna o endereço do endpoint de métricas iniciado por Metrics(), ou "" quando o endpoint está desabilitado
+func (el *Primordial) GetMetricsAddress() (address string) {
+	if metricsGlobal == nil || metricsGlobal.listener == nil {
+		return
+	}
+
+	return metricsGlobal.listener.Addr().String()
+}
+
+// Timeline
+//
+// English:
+//
+//	Returns the log lines and chaos actions of all containers in the order of time, on one clock.
+//
+//	 Notes:
+//	   * Use Filter() to select a container, a copy or a regular expression, and Gaps() to find the periods in which
+//	     a container logged nothing;
+//	   * The text of the log lines has no terminal colors and the secrets are redacted;
+//	   * Call before the cleanup of Test(), which saves the timeline as timeline.log.
+//
+// Português:
+//
+//	Retorna as linhas de log e as ações de caos de todos os containers na ordem do tempo, em um único relógio.
+//
+//	 Notas:
+//	   * Use Filter() para selecionar um container, uma cópia ou uma expressão regular, e Gaps() para encontrar os
+//	     períodos nos quais um container não gerou log;
+//	   * O texto das linhas de log não tem as cores do terminal e os segredos são ocultados;
+//	   * Chame antes da limpeza de Test(), que salva a linha do tempo como timeline.log.
+func (el *Primordial) Timeline() (timeline *Timeline) {
+	return timelineMake(reportGlobal.snapshot(), time.Now())
+}
+
+// TimelineGap
+//
+// English:
+//
+//	Defines the minimum time without logs highlighted as a gap in timeline.log. Default: 30 seconds
+//
+// Português:
+//
+//	Define o tempo mínimo sem log destacado como intervalo em timeline.log. Padrão: 30 segundos
+func (el *Primordial) TimelineGap(minimum time.Duration) (ref *Primordial) {
+	timelineGapGlobal = minimum
+	return el
 }
 
 // Done
@@ -182,19 +436,66 @@ func (el *Primordial) GetLastError() (err error) {
 
 // GarbageCollector
 //
-// Deletes all Docker elements with `delete` in the name.
+// Deletes all Docker elements created by this test process, labelled with the session of GetSessionId().
 //
 //	Input:
-//	  names: additional list of terms to be deleted
+//	  names: additional list of terms to be deleted, only in Docker elements created by this project
 //
 //	Example:
-//	  GarbageCollector("mongo") will delete any docker element with the term `mongo` contained in the name, which
-//	  includes the image named `mongo:6.0.6`, container named `mongodb` and network name `mongodb_network`
+//	  GarbageCollector("mongo") will also delete the Docker elements of this project, of any session, with the term
+//	  `mongo` contained in the name, e.g. the container named `delete_mongodb_0` and the network `mongodb_network`
+//
+//	Notes:
+//	  * Docker elements without the label `chaos.project`, e.g. pulled images and containers of other projects, are
+//	    never deleted.
 func (el *Primordial) GarbageCollector(names ...string) (ref *Primordial) {
 	standalone.GarbageCollector(names...)
 	return el
 }
 
+// GarbageCollectorDryRun
+//
+// English:
+//
+//	Returns the Docker elements which GarbageCollector() would delete, without deleting them
+//
+//	 Input:
+//	   names: additional list of terms, the same of GarbageCollector()
+//
+// Português:
+//
+//	Retorna os elementos Docker que GarbageCollector() apagaria, sem apagá-los
+//
+//	 Entrada:
+//	   names: lista adicional de termos, a mesma de GarbageCollector()
+func (el *Primordial) GarbageCollectorDryRun(names ...string) (resources []builder.Resource) {
+	var err error
+	if resources, err = standalone.GarbageCollectorDryRun(names...); err != nil {
+		ErrorCh <- fmt.Errorf("primordial.GarbageCollectorDryRun().error: %v", err)
+	}
+
+	return
+}
+
+// GetSessionId
+//
+// English:
+//
+//	Returns the id of the session, the label `chaos.session` of all Docker elements created by this test process.
+//
+//	 Notes:
+//	   * The session can be defined by the environment variable CHAOS_SESSION.
+//
+// Português:
+//
+//	Retorna o id da sessão, o rótulo `chaos.session` de todos os elementos Docker criados por este processo de teste.
+//
+//	 Notas:
+//	   * A sessão pode ser definida pela variável de ambiente CHAOS_SESSION.
+func (el *Primordial) GetSessionId() (id string) {
+	return standalone.SessionId()
+}
+
 //
 //
 //
@@ -0,0 +1,205 @@
+package manager
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/helmutkemper/chaos/internal/builder"
+	"github.com/helmutkemper/chaos/internal/reaper"
+	"github.com/helmutkemper/chaos/internal/standalone"
+	"net"
+	"os"
+	"time"
+)
+
+// kLabelReaper has the session of the reaper container. The reaper doesn't have the labels of the session, so it is
+// not removed by itself nor by GarbageCollector()
+const kLabelReaper = "chaos.reaper"
+
+// kReaperSocket is the socket of the docker daemon mounted in the reaper
+const kReaperSocket = "/var/run/docker.sock"
+
+// kReaperTimeout is the time waiting for the connection with the reaper
+const kReaperTimeout = 30 * time.Second
+
+// Connection with the reaper started by Reaper(), kept open until the end of the test process
+var reaperConnGlobal net.Conn
+
+// Id of the reaper container, removed by reaperStop()
+var reaperIdGlobal string
+
+// Reaper
+//
+// English:
+//
+//	Starts the reaper, a sidecar container which holds a socket connection with the test process and removes all
+//	Docker elements of the session of GetSessionId() when the connection drops, e.g. go test was killed by timeout,
+//	Ctrl-C or out of memory, and t.Cleanup() never ran.
+//
+//	 Notes:
+//	   * Call Reaper() first, right after NewPrimordial();
+//	   * The image chaos-reaper is built from the local sources, with the go compiler of the host computer, when it
+//	     doesn't exist;
+//	   * The reaper waits 10 seconds for a new connection before removing the Docker elements, and removes itself;
+//	   * The reaper mounts the docker socket, /var/run/docker.sock.
+//
+// Português:
+//
+//	Inicia o reaper, um container sidecar que mantém uma conexão de socket com o processo de teste e remove todos os
+//	elementos Docker da sessão de GetSessionId() quando a conexão cai, ex. go test foi morto por timeout, Ctrl-C ou
+//	falta de memória, e t.Cleanup() nunca rodou.
+//
+//	 Notas:
+//	   * Chame Reaper() primeiro, logo depois de NewPrimordial();
+//	   * A imagem chaos-reaper é construída a partir dos fontes locais, com o compilador go do computador hospedeiro,
+//	     quando ela não existe;
+//	   * O reaper espera 10 segundos por uma nova conexão antes de remover os elementos Docker, e remove a si mesmo;
+//	   * O reaper monta o socket do docker, /var/run/docker.sock.
+func (el *Primordial) Reaper() (ref *Primordial) {
+	if reaperConnGlobal != nil {
+		return el
+	}
+
+	var err error
+	if reaperIdGlobal, reaperConnGlobal, err = reaperStart(); err != nil {
+		ErrorCh <- fmt.Errorf("primordial.Reaper().error: %v", err)
+	}
+
+	return el
+}
+
+// reaperStart
+//
+// Builds the image of the reaper, when necessary, starts the container and connects to it
+func reaperStart() (id string, conn net.Conn, err error) {
+	// the reaper doesn't have the labels of the session
+	var dockerSys = new(builder.DockerSystem)
+	if err = dockerSys.Init(); err != nil {
+		return
+	}
+
+	var imageName = reaper.ImageName()
+	if _, err = dockerSys.ImageFindIdByName(imageName); err != nil {
+		if err = reaperImageBuild(dockerSys, imageName); err != nil {
+			return
+		}
+	}
+
+	var session = standalone.SessionId()
+	var config = &container.Config{
+		Image: imageName,
+		Cmd: []string{
+			"-label", standalone.KLabelProject + "=" + standalone.KProject,
+			"-label", standalone.KLabelSession + "=" + session,
+		},
+		Labels:       map[string]string{kLabelReaper: session},
+		ExposedPorts: nat.PortSet{reaper.KPort: struct{}{}},
+	}
+
+	dockerSys.SetHostConfig(container.HostConfig{AutoRemove: true})
+
+	id, _, err = dockerSys.ContainerCreateWithConfig(
+		config,
+		"chaos_reaper_"+session,
+		builder.KRestartPolicyNo,
+		nat.PortMap{reaper.KPort: []nat.PortBinding{{HostIP: "127.0.0.1"}}},
+		[]mount.Mount{{Type: mount.TypeBind, Source: kReaperSocket, Target: kReaperSocket}},
+		nil,
+	)
+	if err != nil {
+		return
+	}
+
+	if err = dockerSys.ContainerStart(id); err != nil {
+		return
+	}
+
+	var inspect types.ContainerJSON
+	if inspect, err = dockerSys.ContainerInspect(id); err != nil {
+		return
+	}
+
+	if inspect.NetworkSettings == nil || len(inspect.NetworkSettings.Ports[reaper.KPort]) == 0 {
+		err = fmt.Errorf("port %v of the reaper not published", reaper.KPort)
+		return
+	}
+
+	conn, err = reaperConnect(net.JoinHostPort("127.0.0.1", inspect.NetworkSettings.Ports[reaper.KPort][0].HostPort), kReaperTimeout)
+	return
+}
+
+// reaperStop
+//
+// Removes the reaper without removing the Docker elements of the session, e.g. kept by KeepOnFailure()
+func reaperStop() (err error) {
+	if reaperIdGlobal == "" {
+		return
+	}
+
+	var dockerSys = new(builder.DockerSystem)
+	if err = dockerSys.Init(); err != nil {
+		return
+	}
+
+	// the reaper is killed, so it doesn't see the connection dropping
+	if err = dockerSys.ContainerRemove(reaperIdGlobal, false, false, true); err != nil {
+		return
+	}
+
+	if reaperConnGlobal != nil {
+		_ = reaperConnGlobal.Close()
+	}
+	reaperIdGlobal, reaperConnGlobal = "", nil
+	return
+}
+
+// reaperImageBuild
+//
+// Builds the image of the reaper from the local sources
+func reaperImageBuild(dockerSys *builder.DockerSystem, imageName string) (err error) {
+	var dir string
+	if dir, err = os.MkdirTemp("", "chaos-reaper"); err != nil {
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	if err = reaper.Build(dir); err != nil {
+		return
+	}
+
+	_, err = dockerSys.ImageBuildFromFolder(dir, imageName, nil, types.ImageBuildOptions{}, nil)
+	return
+}
+
+// reaperConnect
+//
+// Connects to the reaper and waits for the line ACK, retrying until the reaper is listening
+func reaperConnect(address string, timeout time.Duration) (conn net.Conn, err error) {
+	var deadline = time.Now().Add(timeout)
+	for {
+		if conn, err = net.DialTimeout("tcp", address, time.Second); err == nil {
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+			var line string
+			if line, err = bufio.NewReader(conn).ReadString('\n'); err == nil && line == "ACK\n" {
+				_ = conn.SetReadDeadline(time.Time{})
+				return
+			}
+
+			// docker accepts the connection on the published port before the reaper is listening
+			_ = conn.Close()
+			if err == nil {
+				err = fmt.Errorf("unexpected answer of the reaper: %q", line)
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+
+		time.Sleep(200 * time.Millisecond)
+	}
+}
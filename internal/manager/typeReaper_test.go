@@ -0,0 +1,39 @@
+package manager
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReaperConnect(t *testing.T) {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	go func() {
+		// the first connection is closed without answer, like the published port before the reaper is listening
+		var conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+
+		if conn, err = listener.Accept(); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("ACK\n"))
+	}()
+
+	var conn net.Conn
+	if conn, err = reaperConnect(listener.Addr().String(), 5*time.Second); err != nil {
+		t.Fatalf("reaperConnect().error: %v", err)
+	}
+	_ = conn.Close()
+
+	if _, err = reaperConnect("127.0.0.1:1", 300*time.Millisecond); err == nil {
+		t.Errorf("a closed port must fail")
+	}
+}
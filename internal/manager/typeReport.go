@@ -0,0 +1,321 @@
+package manager
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// kReportFailsMax is the maximum number of fail flags kept per copy in report.html
+const kReportFailsMax = 100
+
+// kReportFailContext is the number of log lines before and after the fail flag shown in report.html
+const kReportFailContext = 3
+
+// Data of report.html, written by the cleanup of Test()
+var reportGlobal = new(reportRegistry)
+
+// reportCopy
+//
+// Data of one copy shown in report.html
+type reportCopy struct {
+	labels metricsLabels
+
+	// Name of the container of the copy
+	name string
+
+	// Returns the standard output of the copy with timestamps, e.g. the output of ContainerLogs()
+	logs func() ([]byte, error)
+
+	// Start of the container and end of WaitForFlag()
+	started time.Time
+	ready   time.Time
+
+	samples   []reportSample
+	chaos     []ChaosEvent
+	fails     []reportFail
+	forensics []reportForensics
+}
+
+// copyName
+//
+// Container name of the copy, e.g. delete_db_0
+func (el *reportCopy) copyName() (name string) {
+	if el.name == "" {
+		return el.labels.container + "_" + strconv.Itoa(el.labels.iCopy)
+	}
+
+	return el.name
+}
+
+// reportSample
+//
+// One sample of the statistics
+type reportSample struct {
+	time       time.Time
+	cpuPercent float64
+	workingSet uint64
+	limit      uint64
+}
+
+// reportFail
+//
+// Fail flag found in the standard output, with the lines around it
+type reportFail struct {
+	time    time.Time
+	line    string
+	context []string
+}
+
+// reportForensics
+//
+// Forensics action executed in the copy after the fail flag
+type reportForensics struct {
+	time time.Time
+
+	// One of commit, copy, exec or goroutines
+	action string
+
+	// Image, path or command of the action
+	detail string
+
+	// File saved by the action, empty for commit
+	file string
+
+	err error
+}
+
+// reportVulnerability
+//
+// Summary of the vulnerability scanner of one image
+type reportVulnerability struct {
+	reportName string
+	imageName  string
+	threshold  string
+
+	// Number of vulnerabilities found, where key is the severity
+	severity map[string]int
+	allowed  int
+	above    []string
+
+	// Path of the markdown report
+	file string
+}
+
+// reportRegistry
+//
+// Data of all copies, written by the threads of the containers and read by the cleanup of Test()
+type reportRegistry struct {
+	mutex           sync.Mutex
+	copies          map[string]*reportCopy
+	vulnerabilities []reportVulnerability
+}
+
+// get
+//
+// Returns the data of the copy, created on the first call
+func (el *reportRegistry) get(labels metricsLabels) (data *reportCopy) {
+	if el.copies == nil {
+		el.copies = make(map[string]*reportCopy)
+	}
+
+	var found bool
+	if data, found = el.copies[labels.key()]; !found {
+		data = &reportCopy{labels: labels}
+		el.copies[labels.key()] = data
+	}
+
+	return
+}
+
+// register
+//
+// Defines the container name of the copy and how to read its standard output
+func (el *reportRegistry) register(labels metricsLabels, name string, logs func() ([]byte, error)) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	var data = el.get(labels)
+	data.name = name
+	data.logs = logs
+}
+
+// started
+//
+// Saves the start time of the copy
+func (el *reportRegistry) started(labels metricsLabels, started time.Time) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	el.get(labels).started = started
+}
+
+// ready
+//
+// Saves the time the copy was ready, after WaitForFlag()
+func (el *reportRegistry) ready(labels metricsLabels, ready time.Time) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	el.get(labels).ready = ready
+}
+
+// sample
+//
+// Saves one sample of the statistics of the copy
+func (el *reportRegistry) sample(labels metricsLabels, sample reportSample) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	var data = el.get(labels)
+	data.samples = append(data.samples, sample)
+}
+
+// chaosEvent
+//
+// Saves one chaos action executed in the copy
+func (el *reportRegistry) chaosEvent(labels metricsLabels, event ChaosEvent) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	var data = el.get(labels)
+	data.chaos = append(data.chaos, event)
+}
+
+// fail
+//
+// Saves one fail flag found in the standard output of the copy
+func (el *reportRegistry) fail(labels metricsLabels, fail reportFail) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	var data = el.get(labels)
+	if len(data.fails) < kReportFailsMax {
+		data.fails = append(data.fails, fail)
+	}
+}
+
+// forensics
+//
+// Saves one forensics action executed in the copy
+func (el *reportRegistry) forensics(labels metricsLabels, forensics reportForensics) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	var data = el.get(labels)
+	data.forensics = append(data.forensics, forensics)
+}
+
+// vulnerability
+//
+// Saves the summary of the vulnerability scanner of one image
+func (el *reportRegistry) vulnerability(summary reportVulnerability) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	el.vulnerabilities = append(el.vulnerabilities, summary)
+}
+
+// vulnerabilitySummary
+//
+// Counts the vulnerabilities found by severity
+func vulnerabilitySummary(reportName, imageName, file string, found, allowed []Vulnerability, threshold string) (summary reportVulnerability) {
+	summary = reportVulnerability{
+		reportName: reportName,
+		imageName:  imageName,
+		threshold:  threshold,
+		severity:   make(map[string]int),
+		allowed:    len(allowed),
+		above:      vulnerabilityAboveThreshold(found, threshold),
+		file:       file,
+	}
+
+	for _, vulnerability := range found {
+		summary.severity[vulnerability.Severity] += 1
+	}
+
+	return
+}
+
+// snapshot
+//
+// Returns a copy of the data of all copies, in the order of the copies
+func (el *reportRegistry) snapshot() (copies []*reportCopy) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	var keys = make([]string, 0, len(el.copies))
+	for key := range el.copies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	copies = make([]*reportCopy, 0, len(keys))
+	for _, key := range keys {
+		var data = *el.copies[key]
+		data.samples = append([]reportSample(nil), data.samples...)
+		data.chaos = append([]ChaosEvent(nil), data.chaos...)
+		data.fails = append([]reportFail(nil), data.fails...)
+		data.forensics = append([]reportForensics(nil), data.forensics...)
+		copies = append(copies, &data)
+	}
+
+	return
+}
+
+// write
+//
+// Writes report.html in the folder and empties the registry for the next test
+func (el *reportRegistry) write(path, title string, failed bool) (err error) {
+	var copies = el.snapshot()
+
+	el.mutex.Lock()
+	var vulnerabilities = el.vulnerabilities
+	el.copies = nil
+	el.vulnerabilities = nil
+	el.mutex.Unlock()
+
+	var page = reportPageMake(path, title, failed, copies, vulnerabilities)
+
+	var buffer bytes.Buffer
+	if err = template.Must(template.New("report").Parse(kReportTemplate)).Execute(&buffer, page); err != nil {
+		return
+	}
+
+	return os.WriteFile(filepath.Join(path, "report.html"), buffer.Bytes(), fs.ModePerm)
+}
+
+// logsContext
+//
+// Returns the lines around the last line equal to the line, the line found by logsSearchAndReplaceIntoText()
+func logsContext(lineList [][]byte, line []byte) (context []string) {
+	for k := len(lineList) - 1; k >= 0; k -= 1 {
+		if !bytes.Equal(lineList[k], line) {
+			continue
+		}
+
+		var start = k - kReportFailContext
+		if start < 0 {
+			start = 0
+		}
+
+		var end = k + kReportFailContext + 1
+		if end > len(lineList) {
+			end = len(lineList)
+		}
+
+		for _, contextLine := range lineList[start:end] {
+			context = append(context, string(contextLine))
+		}
+
+		return
+	}
+
+	return
+}
@@ -0,0 +1,434 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/helmutkemper/chaos/internal/builder"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"html"
+	"html/template"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// kReportLogLines is the maximum number of log lines per copy shown in report.html, the last lines are kept
+const kReportLogLines = 5000
+
+// Format of the times shown in report.html
+const kReportTimeFormat = "2006-01-02 15:04:05.000"
+
+// Ansi escape sequences of the standard output. Only colors are converted by builder.TerminalToHtml()
+var reportAnsiRegexp = regexp.MustCompile("\x1b\\[([0-9;]*)([A-Za-z])")
+
+// reportPage
+//
+// Data of the template of report.html
+type reportPage struct {
+	Title           string
+	Passed          bool
+	Generated       string
+	Timeline        []reportTimelineRow
+	Copies          []reportCopyView
+	Vulnerabilities []reportVulnerabilityView
+}
+
+// reportTimelineRow
+//
+// One chaos action of the timeline of all containers
+type reportTimelineRow struct {
+	time      time.Time
+	Time      string
+	Container string
+	Action    string
+}
+
+// reportCopyView
+//
+// Data of one copy in the template
+type reportCopyView struct {
+	Name        string
+	Image       string
+	Started     string
+	Ready       string
+	ReadyIn     string
+	CpuChart    template.HTML
+	MemoryChart template.HTML
+	Logs        template.HTML
+	LogsError   string
+	Fails       []reportFailView
+	Forensics   []reportForensicsView
+}
+
+// reportFailView
+//
+// Fail flag in the template
+type reportFailView struct {
+	Time    string
+	Line    string
+	Context string
+}
+
+// reportForensicsView
+//
+// Forensics action in the template
+type reportForensicsView struct {
+	Time   string
+	Action string
+	Detail string
+	File   string
+	Error  string
+}
+
+// reportVulnerabilityView
+//
+// Summary of the vulnerability scanner in the template
+type reportVulnerabilityView struct {
+	Name      string
+	Image     string
+	Threshold string
+	Critical  int
+	High      int
+	Medium    int
+	Low       int
+	Unknown   int
+	Allowed   int
+	Above     string
+	File      string
+}
+
+// reportPageMake
+//
+// Converts the data of the registry into the data of the template
+func reportPageMake(path, title string, failed bool, copies []*reportCopy, vulnerabilities []reportVulnerability) (page reportPage) {
+	page.Title = title
+	page.Passed = !failed
+	page.Generated = time.Now().Format(kReportTimeFormat)
+
+	for _, data := range copies {
+		var name = data.copyName()
+
+		for _, event := range data.chaos {
+			page.Timeline = append(page.Timeline, reportTimelineRow{
+				time:      event.Time,
+				Time:      event.Time.Format(kReportTimeFormat),
+				Container: name,
+				Action:    event.Action,
+			})
+		}
+
+		var view = reportCopyView{
+			Name:  name,
+			Image: data.labels.image,
+		}
+
+		if !data.started.IsZero() {
+			view.Started = data.started.Format(kReportTimeFormat)
+		}
+
+		if !data.ready.IsZero() {
+			view.Ready = data.ready.Format(kReportTimeFormat)
+			view.ReadyIn = data.ready.Sub(data.started).Round(time.Millisecond).String()
+		}
+
+		view.CpuChart = reportChart(data.samples, data.chaos, "cpu %", func(sample reportSample) float64 {
+			return sample.cpuPercent
+		})
+		view.MemoryChart = reportChart(data.samples, data.chaos, "working set MiB", func(sample reportSample) float64 {
+			return float64(sample.workingSet) / (1024 * 1024)
+		})
+
+		if data.logs != nil {
+			if logs, err := data.logs(); err != nil {
+				view.LogsError = err.Error()
+			} else {
+				view.Logs = reportLogs(logs, data.chaos)
+			}
+		}
+
+		for _, fail := range data.fails {
+			view.Fails = append(view.Fails, reportFailView{
+				Time:    fail.time.Format(kReportTimeFormat),
+				Line:    monitor.Redact(fail.line),
+				Context: monitor.Redact(strings.Join(fail.context, "\n")),
+			})
+		}
+
+		for _, forensics := range data.forensics {
+			var forensicsView = reportForensicsView{
+				Time:   forensics.time.Format(kReportTimeFormat),
+				Action: forensics.action,
+				Detail: monitor.Redact(forensics.detail),
+			}
+
+			if forensics.file != "" {
+				forensicsView.File = reportLink(path, forensics.file)
+			}
+
+			if forensics.err != nil {
+				forensicsView.Error = monitor.Redact(forensics.err.Error())
+			}
+
+			view.Forensics = append(view.Forensics, forensicsView)
+		}
+
+		page.Copies = append(page.Copies, view)
+	}
+
+	sort.SliceStable(page.Timeline, func(i, j int) bool {
+		return page.Timeline[i].time.Before(page.Timeline[j].time)
+	})
+
+	for _, summary := range vulnerabilities {
+		// the markdown report can be saved in another folder
+		var file = reportLink(path, summary.file)
+
+		page.Vulnerabilities = append(page.Vulnerabilities, reportVulnerabilityView{
+			Name:      summary.reportName,
+			Image:     summary.imageName,
+			Threshold: summary.threshold,
+			Critical:  summary.severity[KSeverityCritical],
+			High:      summary.severity[KSeverityHigh],
+			Medium:    summary.severity[KSeverityMedium],
+			Low:       summary.severity[KSeverityLow],
+			Unknown:   summary.severity[KSeverityUnknown],
+			Allowed:   summary.allowed,
+			Above:     strings.Join(summary.above, ", "),
+			File:      file,
+		})
+	}
+
+	return
+}
+
+// reportLink
+//
+// Returns the path of the file relative to report.html, saved in the folder
+func reportLink(path, file string) (link string) {
+	var pathAbs, errPath = filepath.Abs(path)
+	var fileAbs, errFile = filepath.Abs(file)
+	if errPath != nil || errFile != nil {
+		return file
+	}
+
+	var relative, err = filepath.Rel(pathAbs, fileAbs)
+	if err != nil {
+		return file
+	}
+
+	return filepath.ToSlash(relative)
+}
+
+// reportChart
+//
+// Draws the values of the samples as a svg line, with the chaos actions as vertical red lines
+func reportChart(samples []reportSample, events []ChaosEvent, unit string, value func(reportSample) float64) (chart template.HTML) {
+	if len(samples) < 2 {
+		return ""
+	}
+
+	const width, height, margin = 640.0, 160.0, 30.0
+
+	var start = samples[0].time
+	var end = samples[len(samples)-1].time
+	var duration = end.Sub(start).Seconds()
+	if duration <= 0 {
+		return ""
+	}
+
+	var max = 0.0
+	for _, sample := range samples {
+		if v := value(sample); v > max {
+			max = v
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+	max *= 1.1
+
+	var x = func(t time.Time) float64 {
+		return margin + t.Sub(start).Seconds()/duration*(width-2*margin)
+	}
+	var y = func(v float64) float64 {
+		return height - margin - v/max*(height-2*margin)
+	}
+
+	var buffer bytes.Buffer
+	_, _ = fmt.Fprintf(&buffer, `<svg class="chart" viewBox="0 0 %v %v" width="%v" height="%v">`, width, height, width, height)
+	_, _ = fmt.Fprintf(&buffer, `<rect x="%v" y="%v" width="%v" height="%v" class="frame"/>`, margin, margin, width-2*margin, height-2*margin)
+
+	for _, event := range events {
+		if event.Time.Before(start) || event.Time.After(end) {
+			continue
+		}
+
+		var position = x(event.Time)
+		_, _ = fmt.Fprintf(&buffer, `<line x1="%.1f" y1="%v" x2="%.1f" y2="%v" class="chaos"><title>%v %v</title></line>`,
+			position, margin, position, height-margin, event.Time.Format(kReportTimeFormat), html.EscapeString(event.Action))
+	}
+
+	var points = make([]string, 0, len(samples))
+	for _, sample := range samples {
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x(sample.time), y(value(sample))))
+	}
+	_, _ = fmt.Fprintf(&buffer, `<polyline points="%v" class="value"/>`, strings.Join(points, " "))
+
+	_, _ = fmt.Fprintf(&buffer, `<text x="%v" y="%v">%v %.2f</text>`, margin, margin-8, html.EscapeString(unit), max/1.1)
+	_, _ = fmt.Fprintf(&buffer, `<text x="%v" y="%v">%v</text>`, margin, height-8, start.Format(kReportTimeFormat))
+	_, _ = fmt.Fprintf(&buffer, `<text x="%v" y="%v" text-anchor="end">%v</text>`, width-margin, height-8, end.Format(kReportTimeFormat))
+	buffer.WriteString(`</svg>`)
+
+	return template.HTML(buffer.String())
+}
+
+// reportLogs
+//
+// Converts the standard output with timestamps into html, with the colors of the terminal and the chaos actions
+// between the lines, in the order of time
+func reportLogs(logs []byte, events []ChaosEvent) (logsHtml template.HTML) {
+	var lines = logLines(logs)
+	var skipped = 0
+	if len(lines) > kReportLogLines {
+		skipped = len(lines) - kReportLogLines
+		lines = lines[skipped:]
+	}
+
+	events = append([]ChaosEvent(nil), events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	var out strings.Builder
+	if skipped != 0 {
+		_, _ = fmt.Fprintf(&out, "<span class=\"skipped\">%v earlier lines in the log file</span>\n", skipped)
+	}
+
+	var chaos = func(event ChaosEvent) {
+		_, _ = fmt.Fprintf(&out, "<span class=\"chaos\">%v chaos: %v</span>\n", event.Time.Format(kReportTimeFormat), html.EscapeString(event.Action))
+	}
+
+	for _, line := range lines {
+		if !line.time.IsZero() {
+			for len(events) != 0 && !events[0].Time.After(line.time) {
+				chaos(events[0])
+				events = events[1:]
+			}
+
+			_, _ = fmt.Fprintf(&out, "<span class=\"time\">%v</span> ", line.time.Local().Format(kReportTimeFormat))
+		}
+
+		out.WriteString(reportTerminalToHtml(monitor.Redact(line.text)))
+		out.WriteString("\n")
+	}
+
+	for _, event := range events {
+		chaos(event)
+	}
+
+	return template.HTML(out.String())
+}
+
+// reportTerminalToHtml
+//
+// Escapes one line of the standard output and converts the colors of the terminal with builder.TerminalToHtml(), which
+// expects the escape sequences as text, e.g. \u001b[31m
+func reportTerminalToHtml(line string) (lineHtml string) {
+	line = strings.Map(func(r rune) rune {
+		if r < 32 && r != '\t' && r != '\x1b' {
+			return -1
+		}
+		return r
+	}, line)
+
+	line = html.EscapeString(line)
+
+	// builder.TerminalToHtml() converts any \u0000 text, so the backslash of the log is kept as an html entity
+	line = strings.ReplaceAll(line, `\`, "&#92;")
+
+	line = reportAnsiRegexp.ReplaceAllStringFunc(line, func(sequence string) string {
+		var match = reportAnsiRegexp.FindStringSubmatch(sequence)
+		if match[2] != "m" || strings.Contains(match[1], ";") {
+			return ""
+		}
+
+		if match[1] == "" {
+			match[1] = "0"
+		}
+		return `\u001b[` + match[1] + "m"
+	})
+
+	// closes the colors at the end of the line, so the chaos actions between the lines are not colored
+	return builder.TerminalToHtml(line + builder.KAnsiReset)
+}
+
+const kReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 20px; color: #222; }
+h1 .pass { color: #2e7d32; }
+h1 .fail { color: #c62828; }
+table { border-collapse: collapse; margin-bottom: 20px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; font-size: 13px; }
+th { background: #f0f0f0; }
+pre { background: #1e1e1e; color: #ddd; padding: 8px; overflow-x: auto; font-size: 12px; }
+pre .time { color: #888; }
+pre .chaos { display: inline-block; width: 100%; background: #5c1a1a; color: #fff; }
+pre .skipped { color: #ffb74d; }
+svg.chart { display: block; margin: 8px 0; }
+svg.chart .frame { fill: #fafafa; stroke: #ccc; }
+svg.chart .value { fill: none; stroke: #1565c0; stroke-width: 1.5; }
+svg.chart line.chaos { stroke: #c62828; stroke-dasharray: 4 2; }
+svg.chart text { font-size: 11px; fill: #555; }
+.error { color: #c62828; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}: {{if .Passed}}<span class="pass">pass</span>{{else}}<span class="fail">fail</span>{{end}}</h1>
+<p>Generated at {{.Generated}}</p>
+
+<h2>Readiness</h2>
+<table>
+<tr><th>container</th><th>image</th><th>started</th><th>ready</th><th>ready in</th></tr>
+{{range .Copies}}<tr><td>{{.Name}}</td><td>{{.Image}}</td><td>{{.Started}}</td><td>{{.Ready}}</td><td>{{.ReadyIn}}</td></tr>
+{{end}}</table>
+
+<h2>Chaos timeline</h2>
+{{if .Timeline}}<table>
+<tr><th>time</th><th>container</th><th>action</th></tr>
+{{range .Timeline}}<tr><td>{{.Time}}</td><td>{{.Container}}</td><td>{{.Action}}</td></tr>
+{{end}}</table>{{else}}<p>No chaos actions.</p>{{end}}
+
+{{if .Vulnerabilities}}<h2>Vulnerabilities</h2>
+<table>
+<tr><th>report</th><th>image</th><th>critical</th><th>high</th><th>medium</th><th>low</th><th>unknown</th><th>allowed</th><th>threshold</th><th>above threshold</th></tr>
+{{range .Vulnerabilities}}<tr><td><a href="{{.File}}">{{.Name}}</a></td><td>{{.Image}}</td><td>{{.Critical}}</td><td>{{.High}}</td><td>{{.Medium}}</td><td>{{.Low}}</td><td>{{.Unknown}}</td><td>{{.Allowed}}</td><td>{{.Threshold}}</td><td>{{.Above}}</td></tr>
+{{end}}</table>{{end}}
+
+{{range .Copies}}<h2>{{.Name}}</h2>
+{{if .Fails}}<h3>Fail flags</h3>
+{{range .Fails}}<p class="error">{{.Time}}: {{.Line}}</p>
+<pre>{{.Context}}</pre>
+{{end}}{{end}}
+{{if .Forensics}}<h3>Forensics</h3>
+<table>
+<tr><th>time</th><th>action</th><th>detail</th><th>file</th><th>error</th></tr>
+{{range .Forensics}}<tr><td>{{.Time}}</td><td>{{.Action}}</td><td>{{.Detail}}</td><td>{{if .File}}<a href="{{.File}}">{{.File}}</a>{{end}}</td><td class="error">{{.Error}}</td></tr>
+{{end}}</table>{{end}}
+{{if or .CpuChart .MemoryChart}}<h3>Statistics</h3>
+{{.CpuChart}}
+{{.MemoryChart}}{{end}}
+<details>
+<summary>Standard output</summary>
+{{if .LogsError}}<p class="error">{{.LogsError}}</p>{{end}}
+<pre>{{.Logs}}</pre>
+</details>
+{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,149 @@
+package manager
+
+import (
+	"bytes"
+	"github.com/docker/docker/pkg/stdcopy"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportTerminalToHtml(t *testing.T) {
+	var line = reportTerminalToHtml("\x1b[31m<b>error</b>\x1b[0m \\u0041 \x1b[2K\x1b[38;5;1mdone")
+
+	if !strings.Contains(line, "<span style='color: rgb(255,0,0);'>&lt;b&gt;error&lt;/b&gt;</span>") {
+		t.Errorf("the color must be converted and the text escaped: %v", line)
+	}
+
+	if !strings.Contains(line, "&#92;u0041") {
+		t.Errorf("the backslash of the log must be kept: %v", line)
+	}
+
+	if strings.Contains(line, "\x1b") || strings.Contains(line, "u001b") {
+		t.Errorf("the escape sequences must be removed: %v", line)
+	}
+}
+
+func TestReportLogs(t *testing.T) {
+	var start = time.Date(2022, 11, 20, 13, 15, 0, 0, time.UTC)
+
+	var buffer bytes.Buffer
+	var stdout = stdcopy.NewStdWriter(&buffer, stdcopy.Stdout)
+	var stderr = stdcopy.NewStdWriter(&buffer, stdcopy.Stderr)
+	_, _ = stdout.Write([]byte(start.Format(time.RFC3339Nano) + " first\n"))
+	_, _ = stderr.Write([]byte(start.Add(10*time.Second).Format(time.RFC3339Nano) + " second\n"))
+
+	var events = []ChaosEvent{
+		{Time: start.Add(20 * time.Second), Action: "unpause()"},
+		{Time: start.Add(5 * time.Second), Action: "pause()"},
+	}
+
+	var logs = string(reportLogs(buffer.Bytes(), events))
+
+	var order = []string{" first", "chaos: pause()", " second", "chaos: unpause()"}
+	var position = 0
+	for _, text := range order {
+		var index = strings.Index(logs[position:], text)
+		if index == -1 {
+			t.Fatalf("%q not found in order: %v", text, logs)
+		}
+		position += index
+	}
+
+	if strings.Contains(logs, "\x01") || strings.Contains(logs, "\x02") {
+		t.Errorf("the headers of the stream must be removed: %q", logs)
+	}
+}
+
+func TestReportChart(t *testing.T) {
+	var start = time.Date(2022, 11, 20, 13, 15, 0, 0, time.UTC)
+	var samples = []reportSample{
+		{time: start, cpuPercent: 10},
+		{time: start.Add(10 * time.Second), cpuPercent: 50},
+	}
+
+	if chart := reportChart(samples[:1], nil, "cpu %", func(sample reportSample) float64 { return sample.cpuPercent }); chart != "" {
+		t.Errorf("one sample must not have chart: %v", chart)
+	}
+
+	var events = []ChaosEvent{{Time: start.Add(5 * time.Second), Action: "pause()"}, {Time: start.Add(time.Minute), Action: "unpause()"}}
+	var chart = string(reportChart(samples, events, "cpu %", func(sample reportSample) float64 { return sample.cpuPercent }))
+
+	if !strings.Contains(chart, "<polyline points=\"30.0,") {
+		t.Errorf("the chart must have the samples: %v", chart)
+	}
+
+	if strings.Count(chart, "class=\"chaos\"") != 1 {
+		t.Errorf("only the chaos actions inside the samples must be drawn: %v", chart)
+	}
+}
+
+func TestLogsContext(t *testing.T) {
+	var lineList = [][]byte{[]byte("a"), []byte("b"), []byte("panic: x"), []byte("c"), []byte("d"), []byte("e"), []byte("f")}
+
+	var context = logsContext(lineList, []byte("panic: x"))
+	if strings.Join(context, ",") != "a,b,panic: x,c,d,e" {
+		t.Errorf("unexpected context: %v", context)
+	}
+
+	if context = logsContext(lineList, []byte("not found")); len(context) != 0 {
+		t.Errorf("unexpected context: %v", context)
+	}
+}
+
+func TestReportRegistry_write(t *testing.T) {
+	var start = time.Now()
+	var labels = metricsLabels{container: "delete_db", iCopy: 0, image: "mongo:6.0.6"}
+	var dir = filepath.Join(t.TempDir(), "end")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var registry = new(reportRegistry)
+	registry.register(labels, "delete_db_0", func() ([]byte, error) {
+		return []byte(start.UTC().Format(time.RFC3339Nano) + " \x1b[32mwaiting for connections\x1b[0m\n"), nil
+	})
+	registry.started(labels, start)
+	registry.ready(labels, start.Add(1500*time.Millisecond))
+	registry.sample(labels, reportSample{time: start, cpuPercent: 1, workingSet: 1024 * 1024})
+	registry.sample(labels, reportSample{time: start.Add(10 * time.Second), cpuPercent: 2, workingSet: 2 * 1024 * 1024})
+	registry.chaosEvent(labels, ChaosEvent{Time: start.Add(5 * time.Second), Container: "delete_db_0", Action: "pause()"})
+	registry.fail(labels, reportFail{time: start, line: "panic: <nil>", context: []string{"before", "panic: <nil>"}})
+	registry.vulnerability(vulnerabilitySummary("mongo", "mongo:6.0.6", filepath.Join(dir, "..", "reports", "report.mongo.md"), []Vulnerability{{Id: "CVE-1", Severity: KSeverityHigh}}, nil, KSeverityHigh))
+
+	if err := registry.write(dir, "TestMongo", true); err != nil {
+		t.Fatal(err)
+	}
+
+	var data, err = os.ReadFile(filepath.Join(dir, "report.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report = string(data)
+	for _, text := range []string{
+		"TestMongo: <span class=\"fail\">fail</span>",
+		"<td>delete_db_0</td><td>mongo:6.0.6</td>",
+		"<td>1.5s</td>",
+		"<td>pause()</td>",
+		"<svg class=\"chart\"",
+		"<span style='color: rgb(0,255,0);'>waiting for connections</span>",
+		"panic: &lt;nil&gt;",
+		"href=\"../reports/report.mongo.md\"",
+		"<td>CVE-1</td>",
+	} {
+		if !strings.Contains(report, text) {
+			t.Errorf("%q not found in the report", text)
+		}
+	}
+
+	if strings.Contains(report, "http://") || strings.Contains(report, "https://") || strings.Contains(report, "<script") {
+		t.Errorf("the report must not have external assets")
+	}
+
+	if len(registry.copies) != 0 {
+		t.Errorf("the registry must be empty after the report")
+	}
+}
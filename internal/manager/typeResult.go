@@ -0,0 +1,288 @@
+package manager
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Files of the result, written in the folder defined by Test()
+const (
+	kResultJson  = "result.json"
+	kResultJUnit = "junit.xml"
+)
+
+// Result
+//
+// English:
+//
+//	Outcome of the test returned by Primordial.MonitorResult(), also saved as result.json and junit.xml in the folder
+//	defined by Test().
+//
+// Português:
+//
+//	Resultado do teste retornado por Primordial.MonitorResult(), também salvo como result.json e junit.xml na pasta
+//	definida por Test().
+type Result struct {
+	// Name of the test, defined by Test()
+	Name string `json:"name"`
+
+	// One of pass, fail or error
+	Status string `json:"status"`
+	Pass   bool   `json:"pass"`
+
+	// Fail flag line or error message, redacted
+	Reason string `json:"reason,omitempty"`
+
+	// Container and copy of the fail flag. Empty container and copy -1 when unknown, e.g. on error
+	Container string `json:"container,omitempty"`
+	Copy      int    `json:"copy"`
+
+	// Chaos actions executed in all containers, in the order of time
+	ChaosActions []ChaosEvent `json:"chaosActions"`
+
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// Duration of the test, in seconds
+	Duration float64 `json:"duration"`
+
+	// Files saved in the folder defined by Test(). Completed by the cleanup of Test(), after the logs and report.html
+	Artifacts []string `json:"artifacts"`
+
+	// Copies of all containers, one test case per copy in junit.xml
+	copies []resultCopy
+}
+
+// resultCopy
+//
+// Chaos actions of one copy
+type resultCopy struct {
+	labels metricsLabels
+	name   string
+	chaos  []ChaosEvent
+	failed bool
+}
+
+// junitTestSuites
+//
+// Root element of junit.xml
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// result
+//
+// Returns the copies of all containers and the copy of the first fail flag, nil when there is no fail flag
+func (el *reportRegistry) result() (copies []resultCopy, failed *metricsLabels) {
+	var first time.Time
+	for _, data := range el.snapshot() {
+		copies = append(copies, resultCopy{labels: data.labels, name: data.copyName(), chaos: data.chaos})
+
+		if len(data.fails) != 0 && (failed == nil || data.fails[0].time.Before(first)) {
+			var labels = data.labels
+			failed = &labels
+			first = data.fails[0].time
+		}
+	}
+
+	return
+}
+
+// resultMake
+//
+// Makes the result of the test with the chaos actions of all copies
+func resultMake(name, status, reason string, start, end time.Time) (result Result) {
+	result = Result{
+		Name:         name,
+		Status:       status,
+		Pass:         status == monitor.KStatusPass,
+		Reason:       reason,
+		Copy:         -1,
+		ChaosActions: make([]ChaosEvent, 0),
+		Start:        start,
+		End:          end,
+		Duration:     end.Sub(start).Seconds(),
+		Artifacts:    make([]string, 0),
+	}
+
+	var copies, failed = reportGlobal.result()
+	for k := range copies {
+		if status == monitor.KStatusFail && failed != nil && copies[k].labels == *failed {
+			copies[k].failed = true
+			result.Container = failed.container
+			result.Copy = failed.iCopy
+		}
+
+		result.ChaosActions = append(result.ChaosActions, copies[k].chaos...)
+	}
+	result.copies = copies
+
+	sort.SliceStable(result.ChaosActions, func(i, j int) bool {
+		return result.ChaosActions[i].Time.Before(result.ChaosActions[j].Time)
+	})
+
+	return
+}
+
+// Subfolders of the folder of Test() written by the test, visited by resultArtifacts()
+var resultFolders = map[string]bool{
+	"coverage":  true,
+	"forensics": true,
+}
+
+// resultArtifacts
+//
+// Returns the files written in the folder since the start of the test, except the files of the result. Only the
+// subfolders written by the test, e.g. forensics, are visited, so the folder can be the folder of the source code
+func resultArtifacts(path string, since time.Time) (artifacts []string) {
+	// file systems with modification time in seconds
+	since = since.Truncate(time.Second)
+
+	artifacts = make([]string, 0)
+	_ = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if entry.IsDir() {
+			var relative, errRel = filepath.Rel(path, file)
+			if errRel != nil {
+				return fs.SkipDir
+			}
+
+			if relative != "." && !resultFolders[strings.Split(filepath.ToSlash(relative), "/")[0]] {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if name := entry.Name(); name == kResultJson || name == kResultJUnit {
+			return nil
+		}
+
+		if info, err := entry.Info(); err != nil || info.ModTime().Before(since) {
+			return nil
+		}
+
+		artifacts = append(artifacts, file)
+		return nil
+	})
+
+	return
+}
+
+// write
+//
+// Saves result.json and junit.xml in the folder
+func (el Result) write(path string) (err error) {
+	var data []byte
+	if data, err = json.MarshalIndent(el, "", "  "); err != nil {
+		return
+	}
+
+	if err = os.WriteFile(filepath.Join(path, kResultJson), data, fs.ModePerm); err != nil {
+		return
+	}
+
+	if data, err = xml.MarshalIndent(el.junit(), "", "  "); err != nil {
+		return
+	}
+
+	return os.WriteFile(filepath.Join(path, kResultJUnit), append([]byte(xml.Header), data...), fs.ModePerm)
+}
+
+// junit
+//
+// Converts the result into one test suite, with one test case for the test and one per copy
+func (el Result) junit() (suites junitTestSuites) {
+	var seconds = fmt.Sprintf("%.3f", el.Duration)
+
+	var suite = junitTestSuite{
+		Name:      "chaos",
+		Time:      seconds,
+		Timestamp: el.Start.Format("2006-01-02T15:04:05"),
+	}
+
+	for _, artifact := range el.Artifacts {
+		suite.Properties = append(suite.Properties, junitProperty{Name: "artifact", Value: artifact})
+	}
+
+	var failure = &junitFailure{Message: el.Reason, Type: el.Status, Text: el.Reason}
+
+	var test = junitTestCase{Name: el.Name, ClassName: "chaos", Time: seconds}
+	switch el.Status {
+	case monitor.KStatusFail:
+		test.Failure = failure
+		suite.Failures += 1
+	case monitor.KStatusError:
+		test.Error = failure
+		suite.Errors += 1
+	}
+	test.SystemOut = resultChaosText(el.ChaosActions)
+	suite.Cases = append(suite.Cases, test)
+
+	for _, data := range el.copies {
+		var testCopy = junitTestCase{Name: data.name, ClassName: el.Name, Time: seconds, SystemOut: resultChaosText(data.chaos)}
+		if data.failed {
+			testCopy.Failure = failure
+			suite.Failures += 1
+		}
+		suite.Cases = append(suite.Cases, testCopy)
+	}
+
+	suite.Tests = len(suite.Cases)
+	suites.Suites = append(suites.Suites, suite)
+	return
+}
+
+// resultChaosText
+//
+// One line per chaos action, with time, container and action
+func resultChaosText(events []ChaosEvent) (text string) {
+	var lines = make([]string, 0, len(events))
+	for _, event := range events {
+		lines = append(lines, fmt.Sprintf("%v %v %v", event.Time.Format(time.RFC3339Nano), event.Container, event.Action))
+	}
+
+	return strings.Join(lines, "\n")
+}
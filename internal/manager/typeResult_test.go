@@ -0,0 +1,113 @@
+package manager
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResultMake(t *testing.T) {
+	var registry = reportGlobal
+	defer func() { reportGlobal = registry }()
+	reportGlobal = new(reportRegistry)
+
+	var start = time.Now()
+	var db0 = metricsLabels{container: "delete_db", iCopy: 0}
+	var db1 = metricsLabels{container: "delete_db", iCopy: 1}
+	reportGlobal.register(db0, "delete_db_0", nil)
+	reportGlobal.register(db1, "delete_db_1", nil)
+	reportGlobal.chaosEvent(db1, ChaosEvent{Time: start.Add(2 * time.Second), Container: "delete_db_1", Action: "unpause()"})
+	reportGlobal.chaosEvent(db0, ChaosEvent{Time: start.Add(time.Second), Container: "delete_db_0", Action: "pause()"})
+	reportGlobal.fail(db1, reportFail{time: start.Add(3 * time.Second), line: "panic: x"})
+	reportGlobal.fail(db0, reportFail{time: start.Add(4 * time.Second), line: "panic: y"})
+
+	var result = resultMake("TestDb", monitor.KStatusFail, "panic: x", start, start.Add(90*time.Second))
+	if result.Pass || result.Container != "delete_db" || result.Copy != 1 || result.Duration != 90 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if len(result.ChaosActions) != 2 || result.ChaosActions[0].Action != "pause()" {
+		t.Errorf("the chaos actions must be in the order of time: %v", result.ChaosActions)
+	}
+
+	var suites = result.junit()
+	var suite = suites.Suites[0]
+	if suite.Tests != 3 || suite.Failures != 2 || suite.Errors != 0 {
+		t.Errorf("unexpected suite: %+v", suite)
+	}
+
+	if suite.Cases[1].Failure != nil || suite.Cases[2].Failure == nil || suite.Cases[2].Name != "delete_db_1" {
+		t.Errorf("only the copy of the fail flag must fail: %+v", suite.Cases)
+	}
+
+	result = resultMake("TestDb", monitor.KStatusError, "container[0].Start().error", start, start)
+	suite = result.junit().Suites[0]
+	if result.Container != "" || result.Copy != -1 || suite.Errors != 1 || suite.Failures != 0 {
+		t.Errorf("the error must not have container: %+v, %+v", result, suite)
+	}
+}
+
+func TestResult_write(t *testing.T) {
+	var dir = t.TempDir()
+	var start = time.Now()
+
+	// files of the folder written before the test, and folders not written by the test, are not artifacts
+	var old = filepath.Join(dir, "main.go")
+	if err := os.WriteFile(old, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(old, start.Add(-time.Hour), start.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "internal"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "internal", "code.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "report.html"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "forensics", "delete_db.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "forensics", "delete_db.0", "goroutines.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var result = Result{Name: "TestDb", Status: monitor.KStatusPass, Pass: true, Copy: -1}
+	result.Artifacts = resultArtifacts(dir, start)
+	if err := result.write(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// the files of the result are not artifacts
+	var expected = []string{filepath.Join(dir, "forensics", "delete_db.0", "goroutines.txt"), filepath.Join(dir, "report.html")}
+	if artifacts := resultArtifacts(dir, start); len(artifacts) != 2 || artifacts[0] != expected[0] || artifacts[1] != expected[1] {
+		t.Errorf("unexpected artifacts: %v", artifacts)
+	}
+
+	var data, err = os.ReadFile(filepath.Join(dir, kResultJson))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Result
+	if err = json.Unmarshal(data, &decoded); err != nil || decoded.Status != "pass" || len(decoded.Artifacts) != 2 {
+		t.Errorf("unexpected json: %s, %v", data, err)
+	}
+
+	if data, err = os.ReadFile(filepath.Join(dir, kResultJUnit)); err != nil {
+		t.Fatal(err)
+	}
+
+	var suites junitTestSuites
+	if err = xml.Unmarshal(data, &suites); err != nil || len(suites.Suites) != 1 || suites.Suites[0].Tests != 1 || suites.Suites[0].Failures != 0 {
+		t.Errorf("unexpected junit: %s, %v", data, err)
+	}
+}
@@ -0,0 +1,584 @@
+package manager
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity levels of a vulnerability, from the lowest to the highest
+const (
+	KSeverityUnknown  = "UNKNOWN"
+	KSeverityLow      = "LOW"
+	KSeverityMedium   = "MEDIUM"
+	KSeverityHigh     = "HIGH"
+	KSeverityCritical = "CRITICAL"
+)
+
+// SBOM formats
+const (
+	KSbomSpdx      = "spdx-json"
+	KSbomCycloneDx = "cyclonedx-json"
+)
+
+// Folders, inside the scanner container, shared with the host computer
+const (
+	// Image under test, exported with `docker save`
+	kScannerArchive = "/scan/image.tar"
+
+	// Project folder, when the image was built from a folder or a git repository
+	kScannerProject = "/project"
+
+	// Folder where the scanner writes report.json or sbom.json
+	kScannerReport = "/report"
+
+	// Vulnerability database mirror, used offline
+	kScannerDb = "/db"
+
+	// Maximum time of a scan
+	kScannerTimeout = 10 * time.Minute
+)
+
+// Vulnerability
+//
+// Vulnerability found by a scanner, in a format common to all scanners
+type Vulnerability struct {
+	// Vulnerability id. e.g. CVE-2022-41717, GHSA-xrjj-mj9h-534m, GO-2022-1144
+	Id string
+
+	// Other ids of the same vulnerability
+	Aliases []string
+
+	// Affected package
+	Package string
+
+	// Installed version
+	Version string
+
+	// Version with the fix, when it exists
+	Fixed string
+
+	// One of KSeverityUnknown, KSeverityLow, KSeverityMedium, KSeverityHigh or KSeverityCritical
+	Severity string
+
+	// Short description
+	Summary string
+
+	// List of URLs with details
+	References []string
+}
+
+// ScannerCommand
+//
+// Container used to run a scanner.
+//
+//	Notes:
+//	  * The image must exist on the local computer, so the test can run offline;
+//	  * The image under test is mounted in /scan/image.tar, the project folder, when it exists, in /project, the
+//	    vulnerability database mirror in /db and the scanner must write the output in /report/report.json, for
+//	    vulnerabilities, or /report/sbom.json, for SBOM.
+type ScannerCommand struct {
+	// Local image of the scanner. e.g. anchore/grype:v0.65.0
+	Image string
+
+	// Scanner arguments, used as container CMD
+	Cmd []string
+
+	// Environment variables
+	Env []string
+
+	// Folder, inside the host computer, with the vulnerability database mirror, mounted in /db
+	DbPath string
+}
+
+// Scanner
+//
+// Interface of vulnerability scanners, used by VulnerabilityScannerEngine()
+type Scanner interface {
+	// ScanCommand returns the container used to scan the image
+	ScanCommand() (command ScannerCommand)
+
+	// ScanParse converts the content of /report/report.json into a list of vulnerabilities
+	ScanParse(report []byte) (vulnerabilities []Vulnerability, err error)
+}
+
+// SbomMaker
+//
+// Interface of SBOM generators, used by Sbom()
+type SbomMaker interface {
+	// SbomCommand returns the container used to write the SBOM of the image in /report/sbom.json
+	SbomCommand(format string) (command ScannerCommand, err error)
+}
+
+// ScannerOsv
+//
+// Pre-built osv-scanner image, https://github.com/google/osv-scanner. Scans the lockfiles of the project folder,
+// so, it does not scan images created by ContainerFromImage().
+type ScannerOsv struct {
+	// Local image. e.g. ghcr.io/google/osv-scanner:v1.3.6
+	Image string
+
+	// Folder with the local database, downloaded by `osv-scanner --experimental-local-db`. Empty to use the online
+	// database
+	DbPath string
+}
+
+func (el ScannerOsv) ScanCommand() (command ScannerCommand) {
+	command = ScannerCommand{
+		Image:  el.Image,
+		Cmd:    []string{"--format=json", "--output=" + kScannerReport + "/report.json", "--recursive"},
+		DbPath: el.DbPath,
+	}
+
+	if el.DbPath != "" {
+		command.Cmd = append(command.Cmd, "--experimental-offline", "--experimental-local-db")
+		command.Env = []string{"OSV_SCANNER_LOCAL_DB_CACHE_DIRECTORY=" + kScannerDb}
+	}
+
+	command.Cmd = append(command.Cmd, kScannerProject)
+	return
+}
+
+func (el ScannerOsv) ScanParse(report []byte) (vulnerabilities []Vulnerability, err error) {
+	var reportSt reportData
+	err = json.Unmarshal(report, &reportSt)
+	if err != nil {
+		err = fmt.Errorf("scannerOsv.ScanParse().Unmarshal().error: %v", err)
+		return
+	}
+
+	vulnerabilities = osvVulnerabilities(reportSt)
+	return
+}
+
+// osvVulnerabilities
+//
+// Converts the osv-scanner report into a list of vulnerabilities
+func osvVulnerabilities(reportSt reportData) (vulnerabilities []Vulnerability) {
+	vulnerabilities = make([]Vulnerability, 0)
+	for _, result := range reportSt.Results {
+		for _, pkg := range result.Packages {
+
+			// the highest CVSS score of each group of aliases
+			var scores = make(map[string]string)
+			for _, group := range pkg.Groups {
+				for _, id := range group.Ids {
+					scores[id] = group.MaxSeverity
+				}
+			}
+
+			for _, vulnerability := range pkg.Vulnerabilities {
+				var severity = severityNormalize(vulnerability.DatabaseSpecific.Severity)
+				if severity == KSeverityUnknown {
+					severity = severityFromScore(scores[vulnerability.Id])
+				}
+
+				var fixed string
+				var references = make([]string, 0)
+				for _, affected := range vulnerability.Affected {
+					for _, ranges := range affected.Ranges {
+						for _, event := range ranges.Events {
+							if event.Fixed != "" {
+								fixed = event.Fixed
+							}
+						}
+					}
+				}
+
+				for _, reference := range vulnerability.References {
+					references = append(references, reference.Url)
+				}
+
+				vulnerabilities = append(vulnerabilities, Vulnerability{
+					Id:         vulnerability.Id,
+					Aliases:    vulnerability.Aliases,
+					Package:    pkg.Package.Name,
+					Version:    pkg.Package.Version,
+					Fixed:      fixed,
+					Severity:   severity,
+					Summary:    vulnerability.Summary,
+					References: references,
+				})
+			}
+		}
+	}
+
+	return
+}
+
+// ScannerGrype
+//
+// Pre-built Grype image, https://github.com/anchore/grype, or any image with the same command line
+type ScannerGrype struct {
+	// Local image. e.g. anchore/grype:v0.65.0
+	Image string
+
+	// Folder with the database, the content of `grype db status` location. Empty to update the database online
+	DbPath string
+}
+
+func (el ScannerGrype) ScanCommand() (command ScannerCommand) {
+	command = ScannerCommand{
+		Image:  el.Image,
+		Cmd:    []string{"docker-archive:" + kScannerArchive, "--output", "json", "--file", kScannerReport + "/report.json"},
+		Env:    []string{"GRYPE_CHECK_FOR_APP_UPDATE=false"},
+		DbPath: el.DbPath,
+	}
+
+	if el.DbPath != "" {
+		command.Env = append(command.Env, "GRYPE_DB_CACHE_DIR="+kScannerDb, "GRYPE_DB_AUTO_UPDATE=false", "GRYPE_DB_VALIDATE_AGE=false")
+	}
+
+	return
+}
+
+func (el ScannerGrype) ScanParse(report []byte) (vulnerabilities []Vulnerability, err error) {
+	var reportSt struct {
+		Matches []struct {
+			Vulnerability struct {
+				Id          string   `json:"id"`
+				Severity    string   `json:"severity"`
+				Description string   `json:"description"`
+				Urls        []string `json:"urls"`
+				Fix         struct {
+					Versions []string `json:"versions"`
+				} `json:"fix"`
+			} `json:"vulnerability"`
+			RelatedVulnerabilities []struct {
+				Id string `json:"id"`
+			} `json:"relatedVulnerabilities"`
+			Artifact struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"artifact"`
+		} `json:"matches"`
+	}
+
+	err = json.Unmarshal(report, &reportSt)
+	if err != nil {
+		err = fmt.Errorf("scannerGrype.ScanParse().Unmarshal().error: %v", err)
+		return
+	}
+
+	vulnerabilities = make([]Vulnerability, 0)
+	for _, match := range reportSt.Matches {
+		var aliases = make([]string, 0)
+		for _, related := range match.RelatedVulnerabilities {
+			aliases = append(aliases, related.Id)
+		}
+
+		vulnerabilities = append(vulnerabilities, Vulnerability{
+			Id:         match.Vulnerability.Id,
+			Aliases:    aliases,
+			Package:    match.Artifact.Name,
+			Version:    match.Artifact.Version,
+			Fixed:      strings.Join(match.Vulnerability.Fix.Versions, ", "),
+			Severity:   severityNormalize(match.Vulnerability.Severity),
+			Summary:    match.Vulnerability.Description,
+			References: match.Vulnerability.Urls,
+		})
+	}
+
+	return
+}
+
+// ScannerTrivy
+//
+// Pre-built Trivy image, https://github.com/aquasecurity/trivy, or any image with the same command line. Also makes
+// SBOM
+type ScannerTrivy struct {
+	// Local image. e.g. aquasec/trivy:0.44.0
+	Image string
+
+	// Trivy cache folder, containing the db folder. Empty to update the database online
+	DbPath string
+}
+
+func (el ScannerTrivy) command(cmd ...string) (command ScannerCommand) {
+	command = ScannerCommand{
+		Image:  el.Image,
+		Cmd:    append([]string{"image", "--input", kScannerArchive}, cmd...),
+		DbPath: el.DbPath,
+	}
+
+	if el.DbPath != "" {
+		command.Cmd = append(command.Cmd, "--cache-dir", kScannerDb, "--skip-db-update", "--offline-scan")
+	}
+
+	return
+}
+
+func (el ScannerTrivy) ScanCommand() (command ScannerCommand) {
+	return el.command("--format", "json", "--output", kScannerReport+"/report.json")
+}
+
+func (el ScannerTrivy) ScanParse(report []byte) (vulnerabilities []Vulnerability, err error) {
+	var reportSt struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string   `json:"VulnerabilityID"`
+				PkgName          string   `json:"PkgName"`
+				InstalledVersion string   `json:"InstalledVersion"`
+				FixedVersion     string   `json:"FixedVersion"`
+				Severity         string   `json:"Severity"`
+				Title            string   `json:"Title"`
+				References       []string `json:"References"`
+			} `json:"Vulnerabilities"`
+		} `json:"Results"`
+	}
+
+	err = json.Unmarshal(report, &reportSt)
+	if err != nil {
+		err = fmt.Errorf("scannerTrivy.ScanParse().Unmarshal().error: %v", err)
+		return
+	}
+
+	vulnerabilities = make([]Vulnerability, 0)
+	for _, result := range reportSt.Results {
+		for _, vulnerability := range result.Vulnerabilities {
+			vulnerabilities = append(vulnerabilities, Vulnerability{
+				Id:         vulnerability.VulnerabilityID,
+				Package:    vulnerability.PkgName,
+				Version:    vulnerability.InstalledVersion,
+				Fixed:      vulnerability.FixedVersion,
+				Severity:   severityNormalize(vulnerability.Severity),
+				Summary:    vulnerability.Title,
+				References: vulnerability.References,
+			})
+		}
+	}
+
+	return
+}
+
+func (el ScannerTrivy) SbomCommand(format string) (command ScannerCommand, err error) {
+	switch format {
+	case KSbomSpdx:
+		return el.command("--format", "spdx-json", "--output", kScannerReport+"/sbom.json"), nil
+	case KSbomCycloneDx:
+		return el.command("--format", "cyclonedx", "--output", kScannerReport+"/sbom.json"), nil
+	}
+
+	err = fmt.Errorf("scannerTrivy.SbomCommand().error: format %v not supported", format)
+	return
+}
+
+// SbomSyft
+//
+// Pre-built Syft image, https://github.com/anchore/syft, or any image with the same command line
+type SbomSyft struct {
+	// Local image. e.g. anchore/syft:v0.85.0
+	Image string
+}
+
+func (el SbomSyft) SbomCommand(format string) (command ScannerCommand, err error) {
+	if format != KSbomSpdx && format != KSbomCycloneDx {
+		err = fmt.Errorf("sbomSyft.SbomCommand().error: format %v not supported", format)
+		return
+	}
+
+	command = ScannerCommand{
+		Image: el.Image,
+		Cmd:   []string{"docker-archive:" + kScannerArchive, "--output", format + "=" + kScannerReport + "/sbom.json"},
+		Env:   []string{"SYFT_CHECK_FOR_APP_UPDATE=false"},
+	}
+	return
+}
+
+// severityNormalize
+//
+// Converts the severity names used by the scanners into KSeverityUnknown, KSeverityLow, KSeverityMedium,
+// KSeverityHigh or KSeverityCritical
+func severityNormalize(severity string) (normalized string) {
+	switch strings.ToUpper(strings.TrimSpace(severity)) {
+	case "CRITICAL":
+		return KSeverityCritical
+	case "HIGH", "IMPORTANT":
+		return KSeverityHigh
+	case "MEDIUM", "MODERATE":
+		return KSeverityMedium
+	case "LOW", "NEGLIGIBLE", "MINIMAL":
+		return KSeverityLow
+	}
+
+	return KSeverityUnknown
+}
+
+// severityFromScore
+//
+// Converts the CVSS score into severity, following the CVSS v3 ranges
+func severityFromScore(score string) (severity string) {
+	var value, err = strconv.ParseFloat(score, 64)
+	if err != nil {
+		return KSeverityUnknown
+	}
+
+	switch {
+	case value >= 9.0:
+		return KSeverityCritical
+	case value >= 7.0:
+		return KSeverityHigh
+	case value >= 4.0:
+		return KSeverityMedium
+	case value > 0.0:
+		return KSeverityLow
+	}
+
+	return KSeverityUnknown
+}
+
+// severityRank
+//
+// Returns the position of the severity, where unknown is zero and critical is four
+func severityRank(severity string) (rank int) {
+	switch severityNormalize(severity) {
+	case KSeverityCritical:
+		return 4
+	case KSeverityHigh:
+		return 3
+	case KSeverityMedium:
+		return 2
+	case KSeverityLow:
+		return 1
+	}
+
+	return 0
+}
+
+// vulnerabilityAllowListRead
+//
+// Reads the allow-list file, one vulnerability id per line, where the text after # is a comment.
+//
+//	Example:
+//	  # accepted until the next release of golang.org/x/net
+//	  CVE-2022-41717
+//	  GHSA-xrjj-mj9h-534m # not used by the project
+func vulnerabilityAllowListRead(path string) (allowList []string, err error) {
+	var file *os.File
+	file, err = os.Open(path)
+	if err != nil {
+		err = fmt.Errorf("vulnerabilityAllowListRead().Open().error: %v", err)
+		return
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	allowList = make([]string, 0)
+	var scanner = bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line = scanner.Text()
+		if comment := strings.Index(line, "#"); comment != -1 {
+			line = line[:comment]
+		}
+
+		line = strings.TrimSpace(line)
+		if line != "" {
+			allowList = append(allowList, line)
+		}
+	}
+
+	err = scanner.Err()
+	return
+}
+
+// vulnerabilityFilter
+//
+// Separates the vulnerabilities accepted by the allow-list, by id or alias
+func vulnerabilityFilter(vulnerabilities []Vulnerability, allowList []string) (found, allowed []Vulnerability) {
+	var allow = make(map[string]bool)
+	for _, id := range allowList {
+		allow[strings.ToUpper(id)] = true
+	}
+
+	found = make([]Vulnerability, 0)
+	allowed = make([]Vulnerability, 0)
+
+	for _, vulnerability := range vulnerabilities {
+		var accepted = allow[strings.ToUpper(vulnerability.Id)]
+		for _, alias := range vulnerability.Aliases {
+			accepted = accepted || allow[strings.ToUpper(alias)]
+		}
+
+		if accepted {
+			allowed = append(allowed, vulnerability)
+		} else {
+			found = append(found, vulnerability)
+		}
+	}
+
+	return
+}
+
+// vulnerabilityAboveThreshold
+//
+// Returns the ids of the vulnerabilities with severity equal to or greater than the threshold. An empty threshold
+// never fails
+func vulnerabilityAboveThreshold(vulnerabilities []Vulnerability, threshold string) (ids []string) {
+	ids = make([]string, 0)
+	if threshold == "" {
+		return
+	}
+
+	var limit = severityRank(threshold)
+	for _, vulnerability := range vulnerabilities {
+		if severityRank(vulnerability.Severity) >= limit {
+			ids = append(ids, vulnerability.Id)
+		}
+	}
+
+	sort.Strings(ids)
+	return
+}
+
+// vulnerabilityMarkdown
+//
+// Makes the vulnerability report, in markdown, common to all scanners
+func vulnerabilityMarkdown(imageName string, found, allowed []Vulnerability, threshold string) (reportText string) {
+	var table = func(list []Vulnerability) (text string) {
+		sort.SliceStable(list, func(i, j int) bool {
+			return severityRank(list[i].Severity) > severityRank(list[j].Severity)
+		})
+
+		text += fmt.Sprintf("| Severity | Id | Package | Version | Fixed | Summary |\n")
+		text += fmt.Sprintf("|----------|----|---------|---------|-------|---------|\n")
+		for _, vulnerability := range list {
+			var id = vulnerability.Id
+			if len(vulnerability.References) != 0 {
+				id = fmt.Sprintf("[%v](%v)", vulnerability.Id, vulnerability.References[0])
+			}
+
+			var summary = strings.ReplaceAll(vulnerability.Summary, "\n", " ")
+			summary = strings.ReplaceAll(summary, "|", "\\|")
+			text += fmt.Sprintf("| %v | %v | %v | %v | %v | %v |\n", vulnerability.Severity, id, vulnerability.Package, vulnerability.Version, vulnerability.Fixed, summary)
+		}
+
+		return text + "\n"
+	}
+
+	reportText = "# Vulnerability Report\n\n"
+	reportText += fmt.Sprintf("Image: %v\n\n", imageName)
+	if threshold != "" {
+		reportText += fmt.Sprintf("Threshold: %v\n\n", severityNormalize(threshold))
+	}
+
+	if len(found) == 0 {
+		reportText += fmt.Sprintf("No known vulnerabilities found\n\n")
+	} else {
+		reportText += fmt.Sprintf("## Vulnerabilities\n\n")
+		reportText += table(found)
+	}
+
+	if len(allowed) != 0 {
+		reportText += fmt.Sprintf("## Accepted by the allow-list\n\n")
+		reportText += table(allowed)
+	}
+
+	return
+}
@@ -0,0 +1,106 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScannerParse(t *testing.T) {
+	var tests = []struct {
+		name     string
+		scanner  Scanner
+		report   string
+		expected []Vulnerability
+	}{
+		{
+			name:    "osv",
+			scanner: ScannerOsv{},
+			report: `{"results":[{"packages":[{"package":{"name":"golang.org/x/net","version":"0.2.0","ecosystem":"Go"},
+				"vulnerabilities":[{"id":"GO-2022-1144","aliases":["CVE-2022-41717"],"summary":"memory growth",
+				"affected":[{"ranges":[{"events":[{"introduced":"0"},{"fixed":"0.4.0"}]}]}],
+				"references":[{"type":"REPORT","url":"https://go.dev/issue/56350"}]},
+				{"id":"GHSA-xrjj-mj9h-534m","database_specific":{"severity":"MODERATE"}}],
+				"groups":[{"ids":["GO-2022-1144","CVE-2022-41717"],"max_severity":"7.5"}]}]}]}`,
+			expected: []Vulnerability{
+				{Id: "GO-2022-1144", Aliases: []string{"CVE-2022-41717"}, Package: "golang.org/x/net", Version: "0.2.0", Fixed: "0.4.0", Severity: KSeverityHigh, Summary: "memory growth", References: []string{"https://go.dev/issue/56350"}},
+				{Id: "GHSA-xrjj-mj9h-534m", Package: "golang.org/x/net", Version: "0.2.0", Severity: KSeverityMedium, References: []string{}},
+			},
+		},
+		{
+			name:    "grype",
+			scanner: ScannerGrype{},
+			report: `{"matches":[{"vulnerability":{"id":"CVE-2023-0286","severity":"High","description":"type confusion",
+				"urls":["https://nvd.nist.gov/vuln/detail/CVE-2023-0286"],"fix":{"versions":["3.0.8-r0"]}},
+				"relatedVulnerabilities":[{"id":"GHSA-0000"}],"artifact":{"name":"libcrypto3","version":"3.0.7-r0"}}]}`,
+			expected: []Vulnerability{
+				{Id: "CVE-2023-0286", Aliases: []string{"GHSA-0000"}, Package: "libcrypto3", Version: "3.0.7-r0", Fixed: "3.0.8-r0", Severity: KSeverityHigh, Summary: "type confusion", References: []string{"https://nvd.nist.gov/vuln/detail/CVE-2023-0286"}},
+			},
+		},
+		{
+			name:    "trivy",
+			scanner: ScannerTrivy{},
+			report: `{"Results":[{"Vulnerabilities":[{"VulnerabilityID":"CVE-2023-0464","PkgName":"libssl3",
+				"InstalledVersion":"3.0.7-r0","FixedVersion":"3.0.8-r1","Severity":"CRITICAL","Title":"excessive resource use"}]}]}`,
+			expected: []Vulnerability{
+				{Id: "CVE-2023-0464", Package: "libssl3", Version: "3.0.7-r0", Fixed: "3.0.8-r1", Severity: KSeverityCritical, Summary: "excessive resource use"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var vulnerabilities, err = test.scanner.ScanParse([]byte(test.report))
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		if !reflect.DeepEqual(vulnerabilities, test.expected) {
+			t.Errorf("%v:\n got %+v\nwant %+v", test.name, vulnerabilities, test.expected)
+		}
+	}
+}
+
+func TestVulnerabilityAllowListAndThreshold(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "vulnerability.allow")
+	var err = os.WriteFile(path, []byte("# accepted\n\ncve-2022-41717 # alias of GO-2022-1144\nCVE-2023-0464\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var allowList []string
+	allowList, err = vulnerabilityAllowListRead(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(allowList, []string{"cve-2022-41717", "CVE-2023-0464"}) {
+		t.Fatalf("allow-list: %v", allowList)
+	}
+
+	var vulnerabilities = []Vulnerability{
+		{Id: "GO-2022-1144", Aliases: []string{"CVE-2022-41717"}, Severity: KSeverityHigh},
+		{Id: "CVE-2023-0464", Severity: KSeverityCritical},
+		{Id: "CVE-2023-0286", Severity: KSeverityHigh},
+		{Id: "CVE-2023-0001", Severity: KSeverityMedium},
+		{Id: "CVE-2023-0002", Severity: KSeverityUnknown},
+	}
+
+	var found, allowed = vulnerabilityFilter(vulnerabilities, allowList)
+	if len(found) != 3 || len(allowed) != 2 {
+		t.Fatalf("found %v, allowed %v", found, allowed)
+	}
+
+	var thresholds = map[string][]string{
+		"":                {},
+		KSeverityCritical: {},
+		KSeverityHigh:     {"CVE-2023-0286"},
+		"medium":          {"CVE-2023-0001", "CVE-2023-0286"},
+	}
+
+	for threshold, expected := range thresholds {
+		if ids := vulnerabilityAboveThreshold(found, threshold); !reflect.DeepEqual(ids, expected) {
+			t.Errorf("threshold %q: got %v, want %v", threshold, ids, expected)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package manager
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// kStatsIntervalDefault is the sampling interval of SaveStatistics()
+const kStatsIntervalDefault = 10 * time.Second
+
+// chaosStateCopy
+//
+// Chaos window in progress in one copy
+type chaosStateCopy struct {
+	// Type of the chaos, e.g. stop, pause and throttle
+	kind string
+
+	// Last action executed, e.g. pause()
+	action string
+}
+
+// chaosStateList
+//
+// Chaos window in progress in each copy, written by the chaos thread and read by the statistics thread
+type chaosStateList struct {
+	mutex sync.Mutex
+	state map[int]chaosStateCopy
+}
+
+func (el *chaosStateList) set(iCopy int, kind, action string) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	if el.state == nil {
+		el.state = make(map[int]chaosStateCopy)
+	}
+
+	if kind == "" {
+		delete(el.state, iCopy)
+		return
+	}
+
+	el.state[iCopy] = chaosStateCopy{kind: kind, action: action}
+}
+
+func (el *chaosStateList) get(iCopy int) (state chaosStateCopy) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	return el.state[iCopy]
+}
+
+// StatsInterval
+//
+// English:
+//
+//	Defines the sampling interval of the statistics saved by SaveStatistics().
+//
+//	 Input:
+//	   interval: sampling interval (Default: 10s)
+//
+// Português:
+//
+//	Define o intervalo de amostragem das estatísticas salvas por SaveStatistics().
+//
+//	 Entrada:
+//	   interval: intervalo de amostragem (Padrão: 10s)
+func (el *ContainerFromImage) StatsInterval(interval time.Duration) (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.statsInterval = interval
+	return el
+}
+
+// StatsWindowsColumns
+//
+// English:
+//
+//	Adds the columns only filled by Windows containers to the statistics saved by SaveStatistics().
+//
+// Português:
+//
+//	Adiciona as colunas preenchidas apenas por containers Windows às estatísticas salvas por SaveStatistics().
+func (el *ContainerFromImage) StatsWindowsColumns() (ref *ContainerFromImage) {
+	if monitor.Err {
+		return el
+	}
+
+	el.statsWindows = true
+	return el
+}
+
+// statsHeader
+//
+// Columns of the statistics, after the columns of state and chaos
+func statsHeader(interfaces []string, windows bool) (header []string) {
+	header = []string{
+		"read",
+
+		"pids - current",
+		"pids - limit",
+
+		"cpu - online",
+		"cpu - percent",
+		"cpu - usage in user mode",
+		"cpu - usage in kernel mode",
+		"cpu - total usage",
+		"cpu - throttled time",
+		"cpu - throttled periods",
+		"cpu - periods",
+
+		"memory - limit",
+		"memory - usage",
+		"memory - max usage",
+		"memory - working set",
+		"memory - fail cnt",
+
+		"blkio - read bytes",
+		"blkio - write bytes",
+		"blkio - read iops",
+		"blkio - write iops",
+	}
+
+	for _, name := range interfaces {
+		header = append(header,
+			"network "+name+" - rx bytes",
+			"network "+name+" - rx errors",
+			"network "+name+" - rx dropped",
+			"network "+name+" - tx bytes",
+			"network "+name+" - tx errors",
+			"network "+name+" - tx dropped",
+		)
+	}
+
+	if windows {
+		header = append(header,
+			"num of process (windows)",
+			"storage - read count (windows)",
+			"storage - write count (windows)",
+			"memory - commit (windows)",
+			"memory - commit peak (windows)",
+			"memory - private working set (windows)",
+		)
+	}
+
+	return
+}
+
+// statsLine
+//
+// Values of the statistics, in the order of statsHeader(). The derived values are calculated from the previous sample
+// and, except the cpu percent, are empty in the first sample
+func statsLine(current, previous *types.StatsJSON, interfaces []string, windows bool) (line []string) {
+	var readBytes, writeBytes = blkioTotal(current.BlkioStats.IoServiceBytesRecursive)
+
+	var readIops, writeIops string
+	if previous != nil {
+
+		// cgroup v2 doesn't count the operations, only the bytes
+		if len(current.BlkioStats.IoServicedRecursive) != 0 {
+			var seconds = current.Read.Sub(previous.Read).Seconds()
+			var readOps, writeOps = blkioTotal(current.BlkioStats.IoServicedRecursive)
+			var readOpsPrevious, writeOpsPrevious = blkioTotal(previous.BlkioStats.IoServicedRecursive)
+			if seconds > 0 {
+				readIops = strconv.FormatFloat(float64(readOps-readOpsPrevious)/seconds, 'f', 2, 64)
+				writeIops = strconv.FormatFloat(float64(writeOps-writeOpsPrevious)/seconds, 'f', 2, 64)
+			}
+		}
+	}
+
+	line = []string{
+		current.Read.Format(time.RFC3339Nano),
+
+		strconv.FormatUint(current.PidsStats.Current, 10),
+		strconv.FormatUint(current.PidsStats.Limit, 10),
+
+		strconv.FormatUint(uint64(current.CPUStats.OnlineCPUs), 10),
+		strconv.FormatFloat(statsCpuPercent(current.CPUStats, statsPreviousCpu(current, previous)), 'f', 2, 64),
+		strconv.FormatUint(current.CPUStats.CPUUsage.UsageInUsermode, 10),
+		strconv.FormatUint(current.CPUStats.CPUUsage.UsageInKernelmode, 10),
+		strconv.FormatUint(current.CPUStats.CPUUsage.TotalUsage, 10),
+		strconv.FormatUint(current.CPUStats.ThrottlingData.ThrottledTime, 10),
+		strconv.FormatUint(current.CPUStats.ThrottlingData.ThrottledPeriods, 10),
+		strconv.FormatUint(current.CPUStats.ThrottlingData.Periods, 10),
+
+		strconv.FormatUint(current.MemoryStats.Limit, 10),
+		strconv.FormatUint(current.MemoryStats.Usage, 10),
+		strconv.FormatUint(current.MemoryStats.MaxUsage, 10),
+		strconv.FormatUint(statsWorkingSet(current.MemoryStats), 10),
+		strconv.FormatUint(current.MemoryStats.Failcnt, 10),
+
+		strconv.FormatUint(readBytes, 10),
+		strconv.FormatUint(writeBytes, 10),
+		readIops,
+		writeIops,
+	}
+
+	for _, name := range interfaces {
+		var network, found = current.Networks[name]
+		if !found {
+			// e.g. the copy is disconnected from the network by the partition chaos
+			line = append(line, "", "", "", "", "", "")
+			continue
+		}
+
+		line = append(line,
+			strconv.FormatUint(network.RxBytes, 10),
+			strconv.FormatUint(network.RxErrors, 10),
+			strconv.FormatUint(network.RxDropped, 10),
+			strconv.FormatUint(network.TxBytes, 10),
+			strconv.FormatUint(network.TxErrors, 10),
+			strconv.FormatUint(network.TxDropped, 10),
+		)
+	}
+
+	if windows {
+		line = append(line,
+			strconv.FormatUint(uint64(current.NumProcs), 10),
+			strconv.FormatUint(current.StorageStats.ReadCountNormalized, 10),
+			strconv.FormatUint(current.StorageStats.WriteCountNormalized, 10),
+			strconv.FormatUint(current.MemoryStats.Commit, 10),
+			strconv.FormatUint(current.MemoryStats.CommitPeak, 10),
+			strconv.FormatUint(current.MemoryStats.PrivateWorkingSet, 10),
+		)
+	}
+
+	return
+}
+
+// statsCpuPercent
+//
+// Cpu usage between the two samples, where 100% is one cpu
+func statsCpuPercent(current, previous types.CPUStats) (percent float64) {
+	var cpuDelta = float64(current.CPUUsage.TotalUsage) - float64(previous.CPUUsage.TotalUsage)
+	var systemDelta = float64(current.SystemUsage) - float64(previous.SystemUsage)
+
+	var online = float64(current.OnlineCPUs)
+	if online == 0 {
+		online = float64(len(current.CPUUsage.PercpuUsage))
+	}
+
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+
+	return cpuDelta / systemDelta * online * 100
+}
+
+// statsPreviousCpu
+//
+// Cpu values of the previous sample. The first sample uses the previous read of the docker daemon
+func statsPreviousCpu(current, previous *types.StatsJSON) (cpu types.CPUStats) {
+	if previous == nil {
+		return current.PreCPUStats
+	}
+
+	return previous.CPUStats
+}
+
+// statsWorkingSet
+//
+// Memory in use without the inactive page cache, the value used by the kernel before OOM
+func statsWorkingSet(memory types.MemoryStats) (workingSet uint64) {
+	// cgroup v1 uses total_inactive_file and cgroup v2 uses inactive_file
+	var inactive, found = memory.Stats["total_inactive_file"]
+	if !found {
+		inactive = memory.Stats["inactive_file"]
+	}
+
+	if inactive > memory.Usage {
+		return 0
+	}
+
+	return memory.Usage - inactive
+}
+
+// blkioTotal
+//
+// Sum of the read and write values of all devices
+func blkioTotal(list []types.BlkioStatEntry) (read, write uint64) {
+	for _, entry := range list {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			read += entry.Value
+		case "write":
+			write += entry.Value
+		}
+	}
+
+	return
+}
+
+// statsThread
+//
+// Inspects the container and saves container statistics information to a CSV file on each sampling interval
+func (el *ContainerFromImage) statsThread() {
+	if el.csvPath == "" && metricsGlobal == nil {
+		return
+	}
+
+	var interval = el.statsInterval
+	if interval <= 0 {
+		interval = kStatsIntervalDefault
+	}
+
+	var err error
+	el.manager.TickerStats = time.NewTicker(interval)
+	go func() {
+		// without SaveStatistics(), the samples are only exported by Primordial.Metrics()
+		var file = make([]*os.File, el.copies)
+		for i := 0; i != el.copies && el.csvPath != ""; i += 1 {
+			var filePath = filepath.Join(el.csvPath, fmt.Sprintf("stats.%v.csv", el.copyReportName(i)))
+			_ = os.Remove(filePath)
+			file[i], err = os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, fs.ModePerm)
+			if err != nil {
+				monitor.Err = true
+				ErrorCh <- fmt.Errorf("container[%v].statsThread().OpenFile().error: %v", i, err)
+				return
+			}
+		}
+
+		defer func() {
+			for i := 0; i != el.copies; i += 1 {
+				if file[i] != nil {
+					_ = file[i].Close()
+				}
+			}
+		}()
+
+		// the network interfaces are known on the first sample of each copy, when the header is written
+		var interfaces = make([][]string, el.copies)
+		var previous = make([]*types.StatsJSON, el.copies)
+
+		for {
+			select {
+			case <-el.manager.TickerStats.C:
+				for i := 0; i != el.copies; i += 1 {
+					var stats types.StatsJSON
+					var id = el.copyId(i)
+					stats, err = el.manager.DockerSys[i].ContainerStatisticsOneShotJSON(id)
+					if err != nil {
+						// the copy is being created again by containerRecreate(), e.g. the read-only window of the disk
+						// fault, so the sample is skipped
+						if el.copyRecreated(i, id) {
+							continue
+						}
+
+						monitor.Err = true
+						ErrorCh <- fmt.Errorf("container[%v].statsThread().ContainerStatisticsOneShotJSON().error: %v", i, err)
+						continue
+					}
+
+					var line []string
+					if interfaces[i] == nil {
+						interfaces[i] = make([]string, 0, len(stats.Networks))
+						for name := range stats.Networks {
+							interfaces[i] = append(interfaces[i], name)
+						}
+						sort.Strings(interfaces[i])
+
+						line = append(el.statsStateHeader(), statsHeader(interfaces[i], el.statsWindows)...)
+						if err = el.statsWrite(file[i], line); err != nil {
+							monitor.Err = true
+							ErrorCh <- fmt.Errorf("container[%v].statsThread().WriteAll(0).error: %v", i, err)
+							return
+						}
+					}
+
+					line = append(el.statsState(i), statsLine(&stats, previous[i], interfaces[i], el.statsWindows)...)
+					var cpuPercent = statsCpuPercent(stats.CPUStats, statsPreviousCpu(&stats, previous[i]))
+					metricsGlobal.stats(el.metricsLabels(i), stats, cpuPercent)
+					reportGlobal.sample(el.metricsLabels(i), reportSample{
+						time:       stats.Read,
+						cpuPercent: cpuPercent,
+						workingSet: statsWorkingSet(stats.MemoryStats),
+						limit:      stats.MemoryStats.Limit,
+					})
+					previous[i] = &stats
+
+					if err = el.statsWrite(file[i], line); err != nil {
+						monitor.Err = true
+						ErrorCh <- fmt.Errorf("container[%v].statsThread().WriteAll(1).error: %v", i, err)
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// statsStateHeader
+//
+// Columns of state and chaos of the statistics
+func (el *ContainerFromImage) statsStateHeader() (header []string) {
+	return []string{
+		"time",
+
+		"state - running",
+		"state - dead",
+		"state - OOMKilled",
+		"state - paused",
+		"state - restarting",
+		"state - error",
+		"state - status",
+		"state - exitCode",
+		"state - health check",
+
+		"chaos - action",
+		"chaos - paused",
+		"chaos - stopped",
+	}
+}
+
+// statsState
+//
+// Values of state and chaos of the copy, in the order of statsStateHeader()
+func (el *ContainerFromImage) statsState(iCopy int) (line []string) {
+	line = make([]string, 10, 13)
+	line[0] = time.Now().Format(time.RFC3339)
+
+	var inspect, err = el.manager.DockerSys[iCopy].ContainerInspect(el.copyId(iCopy))
+	if err == nil && inspect.State != nil {
+		line[1] = strconv.FormatBool(inspect.State.Running)
+		line[2] = strconv.FormatBool(inspect.State.Dead)
+		line[3] = strconv.FormatBool(inspect.State.OOMKilled)
+		line[4] = strconv.FormatBool(inspect.State.Paused)
+		line[5] = strconv.FormatBool(inspect.State.Restarting)
+		line[6] = inspect.State.Error
+		line[7] = inspect.State.Status
+
+		if inspect.State.ExitCode != 0 {
+			line[8] = strconv.FormatInt(int64(inspect.State.ExitCode), 10)
+		}
+
+		if inspect.State.Health != nil {
+			line[9] = inspect.State.Health.Status
+		}
+	}
+
+	var chaos = el.chaosState.get(iCopy)
+	return append(line, chaos.action, strconv.FormatBool(chaos.kind == "pause"), strconv.FormatBool(chaos.kind == "stop"))
+}
+
+// statsWrite
+//
+// Writes one line in the statistics file
+func (el *ContainerFromImage) statsWrite(file *os.File, line []string) (err error) {
+	if file == nil {
+		return
+	}
+
+	var writer = csv.NewWriter(file)
+	return writer.WriteAll([][]string{line})
+}
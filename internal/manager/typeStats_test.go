@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"github.com/docker/docker/api/types"
+	"testing"
+	"time"
+)
+
+func TestStatsCpuPercent(t *testing.T) {
+	var previous = types.CPUStats{SystemUsage: 1000, OnlineCPUs: 4}
+	previous.CPUUsage.TotalUsage = 100
+
+	var current = types.CPUStats{SystemUsage: 2000, OnlineCPUs: 4}
+	current.CPUUsage.TotalUsage = 350
+
+	if percent := statsCpuPercent(current, previous); percent != 100 {
+		t.Errorf("one cpu in use must be 100%%: %v", percent)
+	}
+
+	if percent := statsCpuPercent(previous, previous); percent != 0 {
+		t.Errorf("without delta, the percent must be zero: %v", percent)
+	}
+}
+
+func TestStatsWorkingSet(t *testing.T) {
+	var memory = types.MemoryStats{Usage: 1000, Stats: map[string]uint64{"inactive_file": 300}}
+	if workingSet := statsWorkingSet(memory); workingSet != 700 {
+		t.Errorf("cgroup v2: %v", workingSet)
+	}
+
+	memory.Stats = map[string]uint64{"total_inactive_file": 400, "inactive_file": 300}
+	if workingSet := statsWorkingSet(memory); workingSet != 600 {
+		t.Errorf("cgroup v1: %v", workingSet)
+	}
+
+	memory.Stats = map[string]uint64{"inactive_file": 2000}
+	if workingSet := statsWorkingSet(memory); workingSet != 0 {
+		t.Errorf("the working set must not underflow: %v", workingSet)
+	}
+}
+
+func TestStatsLine(t *testing.T) {
+	var start = time.Date(2022, 11, 20, 13, 15, 0, 0, time.UTC)
+
+	var previous = types.StatsJSON{Networks: map[string]types.NetworkStats{"eth0": {RxBytes: 10}}}
+	previous.Read = start
+	previous.BlkioStats.IoServicedRecursive = []types.BlkioStatEntry{{Op: "Read", Value: 10}, {Op: "Write", Value: 20}}
+
+	var current = types.StatsJSON{Networks: map[string]types.NetworkStats{"eth0": {RxBytes: 20, TxDropped: 3}}}
+	current.Read = start.Add(10 * time.Second)
+	current.BlkioStats.IoServiceBytesRecursive = []types.BlkioStatEntry{{Op: "read", Value: 4096}, {Op: "Read", Value: 4096}, {Op: "write", Value: 512}}
+	current.BlkioStats.IoServicedRecursive = []types.BlkioStatEntry{{Op: "Read", Value: 110}, {Op: "Write", Value: 40}}
+
+	var interfaces = []string{"eth0", "eth1"}
+	for _, windows := range []bool{false, true} {
+		var header = statsHeader(interfaces, windows)
+		var line = statsLine(&current, &previous, interfaces, windows)
+		if len(header) != len(line) {
+			t.Fatalf("windows %v: the header has %v columns and the line has %v", windows, len(header), len(line))
+		}
+	}
+
+	var header = statsHeader(interfaces, false)
+	var line = statsLine(&current, &previous, interfaces, false)
+	var column = make(map[string]string)
+	for k := range header {
+		column[header[k]] = line[k]
+	}
+
+	var expected = map[string]string{
+		"read":                      "2022-11-20T13:15:10Z",
+		"blkio - read bytes":        "8192",
+		"blkio - write bytes":       "512",
+		"blkio - read iops":         "10.00",
+		"blkio - write iops":        "2.00",
+		"network eth0 - rx bytes":   "20",
+		"network eth0 - tx dropped": "3",
+		"network eth1 - rx bytes":   "",
+		"network eth1 - tx dropped": "",
+		"memory - working set":      "0",
+		"cpu - percent":             "0.00",
+		"pids - current":            "0",
+	}
+	for name, value := range expected {
+		if column[name] != value {
+			t.Errorf("%v: expected %q, got %q", name, value, column[name])
+		}
+	}
+
+	// the first sample has no iops
+	line = statsLine(&current, nil, interfaces, false)
+	for k := range header {
+		if header[k] == "blkio - read iops" && line[k] != "" {
+			t.Errorf("the first sample must not have iops: %v", line[k])
+		}
+	}
+}
+
+func TestChaosStateList(t *testing.T) {
+	var state chaosStateList
+	if chaos := state.get(0); chaos.kind != "" {
+		t.Errorf("empty state: %v", chaos)
+	}
+
+	state.set(0, "pause", "pause()")
+	if chaos := state.get(0); chaos.kind != "pause" || chaos.action != "pause()" {
+		t.Errorf("unexpected state: %v", chaos)
+	}
+
+	state.set(0, "", "unpause()")
+	if chaos := state.get(0); chaos.kind != "" || chaos.action != "" {
+		t.Errorf("the end of the window must clear the state: %v", chaos)
+	}
+}
@@ -0,0 +1,390 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/helmutkemper/chaos/internal/monitor"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of the entries of the timeline
+const (
+	KTimelineLog   = "log"
+	KTimelineChaos = "chaos"
+	KTimelineGap   = "gap"
+)
+
+// kTimelineGapDefault is the minimum time without logs shown as a gap in timeline.log
+const kTimelineGapDefault = 30 * time.Second
+
+// Minimum time without logs shown as a gap in timeline.log, defined by Primordial.TimelineGap()
+var timelineGapGlobal = kTimelineGapDefault
+
+// TimelineEntry
+//
+// English:
+//
+//	One log line, chaos action or gap of the timeline
+//
+// Português:
+//
+//	Uma linha de log, ação de caos ou intervalo sem log da linha do tempo
+type TimelineEntry struct {
+	Time time.Time `json:"time"`
+
+	// Container name, e.g. delete_db, and index of the copy
+	Container string `json:"container"`
+	Copy      int    `json:"copy"`
+
+	// Container name of the copy, e.g. delete_db_0
+	Name string `json:"name"`
+
+	// One of KTimelineLog, KTimelineChaos or KTimelineGap
+	Kind string `json:"kind"`
+
+	// Log line without the colors of the terminal, chaos action or description of the gap
+	Text string `json:"text"`
+
+	// Time without logs of the gap
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// timelineCopy
+//
+// Copy of the timeline, used by the gap detector
+type timelineCopy struct {
+	container string
+	iCopy     int
+	name      string
+	started   time.Time
+}
+
+// Timeline
+//
+// English:
+//
+//	Log lines of all containers and chaos actions, in the order of time, returned by Primordial.Timeline()
+//
+// Português:
+//
+//	Linhas de log de todos os containers e ações de caos, na ordem do tempo, retornadas por Primordial.Timeline()
+type Timeline struct {
+	entries []TimelineEntry
+	copies  []timelineCopy
+
+	// Time the timeline was made, the end of the last gap
+	end time.Time
+}
+
+// logLine
+//
+// One line of the standard output of a container
+type logLine struct {
+	// Timestamp of docker, zero when the line has no timestamp
+	time time.Time
+	text string
+}
+
+// logLines
+//
+// Splits the standard output with timestamps of ContainerLogs() into lines
+func logLines(logs []byte) (lines []logLine) {
+	// the standard output of a container without tty has a header of 8 bytes per block
+	var buffer bytes.Buffer
+	if _, err := stdcopy.StdCopy(&buffer, &buffer, bytes.NewReader(logs)); err == nil {
+		logs = buffer.Bytes()
+	}
+
+	var text = strings.TrimRight(string(logs), "\n")
+	if text == "" {
+		return
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, logLineParse(line))
+	}
+
+	return
+}
+
+// logLineParse
+//
+// Separates the timestamp of docker from the text of one line
+func logLineParse(line string) (parsed logLine) {
+	line = strings.TrimRight(line, "\r")
+
+	parsed = logLine{text: line}
+	if space := strings.IndexByte(line, ' '); space != -1 {
+		if lineTime, err := time.Parse(time.RFC3339Nano, line[:space]); err == nil {
+			parsed = logLine{time: lineTime, text: line[space+1:]}
+		}
+	}
+
+	return
+}
+
+// timelineMake
+//
+// Merges the log lines and the chaos actions of the copies. The log lines without timestamp receive the time of the
+// previous line
+func timelineMake(copies []*reportCopy, end time.Time) (timeline *Timeline) {
+	timeline = &Timeline{end: end}
+
+	for _, data := range copies {
+		var name = data.copyName()
+		timeline.copies = append(timeline.copies, timelineCopy{
+			container: data.labels.container,
+			iCopy:     data.labels.iCopy,
+			name:      name,
+			started:   data.started,
+		})
+
+		var entry = TimelineEntry{Container: data.labels.container, Copy: data.labels.iCopy, Name: name}
+
+		if data.logs != nil {
+			if logs, err := data.logs(); err == nil {
+				var previous time.Time
+				for _, line := range logLines(logs) {
+					if !line.time.IsZero() {
+						previous = line.time
+					}
+
+					entry.Time = previous
+					entry.Kind = KTimelineLog
+					entry.Text = timelineText(line.text)
+					timeline.entries = append(timeline.entries, entry)
+				}
+			}
+		}
+
+		for _, event := range data.chaos {
+			entry.Time = event.Time
+			entry.Kind = KTimelineChaos
+			entry.Text = event.Action
+			timeline.entries = append(timeline.entries, entry)
+		}
+	}
+
+	timeline.sort()
+	return
+}
+
+// timelineText
+//
+// Removes the escape sequences of the terminal and the secrets of the log line
+func timelineText(line string) (text string) {
+	text = reportAnsiRegexp.ReplaceAllString(line, "")
+	text = strings.Map(func(r rune) rune {
+		if r < 32 && r != '\t' {
+			return -1
+		}
+		return r
+	}, text)
+
+	return monitor.Redact(text)
+}
+
+// sort
+//
+// Orders the entries by time, keeping the order of the lines of each copy
+func (el *Timeline) sort() {
+	sort.SliceStable(el.entries, func(i, j int) bool {
+		return el.entries[i].Time.Before(el.entries[j].Time)
+	})
+}
+
+// Entries
+//
+// English:
+//
+//	Returns the entries of the timeline, in the order of time
+//
+// Português:
+//
+//	Retorna as entradas da linha do tempo, na ordem do tempo
+func (el *Timeline) Entries() (entries []TimelineEntry) {
+	return append([]TimelineEntry(nil), el.entries...)
+}
+
+// Filter
+//
+// English:
+//
+//	Returns a new timeline with the entries of the container, of the copy and matching the regular expression.
+//
+//	 Input:
+//	   container: container name, with or without the prefix `delete_`, or "" for all containers
+//	   iCopy: index of the copy, or -1 for all copies
+//	   pattern: regular expression of the text of the entries, or "" for all entries
+//
+// Português:
+//
+//	Retorna uma nova linha do tempo com as entradas do container, da cópia e que casam com a expressão regular.
+//
+//	 Entrada:
+//	   container: nome do container, com ou sem o prefixo `delete_`, ou "" para todos os containers
+//	   iCopy: índice da cópia, ou -1 para todas as cópias
+//	   pattern: expressão regular do texto das entradas, ou "" para todas as entradas
+func (el *Timeline) Filter(container string, iCopy int, pattern string) (timeline *Timeline, err error) {
+	var expression *regexp.Regexp
+	if pattern != "" {
+		if expression, err = regexp.Compile(pattern); err != nil {
+			return
+		}
+	}
+
+	var match = func(entryContainer string, entryCopy int) bool {
+		if container != "" && entryContainer != container && entryContainer != "delete_"+container {
+			return false
+		}
+
+		return iCopy < 0 || entryCopy == iCopy
+	}
+
+	timeline = &Timeline{end: el.end}
+	for _, data := range el.copies {
+		if match(data.container, data.iCopy) {
+			timeline.copies = append(timeline.copies, data)
+		}
+	}
+
+	for _, entry := range el.entries {
+		if !match(entry.Container, entry.Copy) {
+			continue
+		}
+
+		if expression != nil && !expression.MatchString(entry.Text) {
+			continue
+		}
+
+		timeline.entries = append(timeline.entries, entry)
+	}
+
+	return
+}
+
+// Gaps
+//
+// English:
+//
+//	Returns the periods in which a copy logged nothing, from the start of the copy, or its first log line, to the
+//	moment the timeline was made.
+//
+//	 Input:
+//	   minimum: minimum duration of the gap
+//
+//	 Notes:
+//	   * After Filter() with a regular expression, the gaps are the periods without matching lines.
+//
+// Português:
+//
+//	Retorna os períodos nos quais uma cópia não gerou log, do início da cópia, ou da sua primeira linha de log, até o
+//	momento em que a linha do tempo foi feita.
+//
+//	 Entrada:
+//	   minimum: duração mínima do intervalo
+//
+//	 Notas:
+//	   * Depois de Filter() com uma expressão regular, os intervalos são os períodos sem linhas que casam.
+func (el *Timeline) Gaps(minimum time.Duration) (gaps []TimelineEntry) {
+	for _, data := range el.copies {
+		var previous = data.started
+		var gap = func(end time.Time) {
+			if !previous.IsZero() && end.Sub(previous) >= minimum {
+				gaps = append(gaps, TimelineEntry{
+					Time:      previous,
+					Container: data.container,
+					Copy:      data.iCopy,
+					Name:      data.name,
+					Kind:      KTimelineGap,
+					Text:      fmt.Sprintf("no log for %v, until %v", end.Sub(previous).Round(time.Millisecond), end.Format(kReportTimeFormat)),
+					Duration:  end.Sub(previous),
+				})
+			}
+		}
+
+		for _, entry := range el.entries {
+			if entry.Kind != KTimelineLog || entry.Name != data.name || entry.Time.IsZero() {
+				continue
+			}
+
+			gap(entry.Time)
+			previous = entry.Time
+		}
+
+		gap(el.end)
+	}
+
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return gaps[i].Time.Before(gaps[j].Time)
+	})
+
+	return
+}
+
+// WithGaps
+//
+// English:
+//
+//	Returns a new timeline with the gaps of Gaps() between the entries
+//
+// Português:
+//
+//	Retorna uma nova linha do tempo com os intervalos de Gaps() entre as entradas
+func (el *Timeline) WithGaps(minimum time.Duration) (timeline *Timeline) {
+	timeline = &Timeline{end: el.end, copies: el.copies}
+	timeline.entries = append(el.Entries(), el.Gaps(minimum)...)
+	timeline.sort()
+	return
+}
+
+// String
+//
+// English:
+//
+//	Returns the timeline as text, one entry per line, with the log lines on the left and the chaos actions and gaps
+//	on the right
+//
+// Português:
+//
+//	Retorna a linha do tempo como texto, uma entrada por linha, com as linhas de log à esquerda e as ações de caos e
+//	os intervalos à direita
+func (el *Timeline) String() (text string) {
+	var width = 0
+	for _, entry := range el.entries {
+		if len(entry.Name) > width {
+			width = len(entry.Name)
+		}
+	}
+
+	var builder strings.Builder
+	for _, entry := range el.entries {
+		var when = "-"
+		if !entry.Time.IsZero() {
+			when = entry.Time.Local().Format(kReportTimeFormat)
+		}
+
+		switch entry.Kind {
+		case KTimelineLog:
+			_, _ = fmt.Fprintf(&builder, "%v  %-*v  %v\n", when, width, entry.Name, entry.Text)
+		default:
+			_, _ = fmt.Fprintf(&builder, "%v  %-*v  | %v: %v\n", when, width, entry.Name, entry.Kind, entry.Text)
+		}
+	}
+
+	return builder.String()
+}
+
+// write
+//
+// Saves the timeline, with the gaps, as timeline.log in the folder
+func (el *Timeline) write(path string, minimum time.Duration) (err error) {
+	return os.WriteFile(filepath.Join(path, "timeline.log"), []byte(el.WithGaps(minimum).String()), fs.ModePerm)
+}
@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogLines(t *testing.T) {
+	var start = time.Date(2022, 11, 20, 13, 15, 0, 0, time.UTC)
+	var lines = logLines([]byte(start.Format(time.RFC3339Nano) + " first line\r\nwithout time\n\n"))
+
+	// the empty lines of the end are removed
+	if len(lines) != 2 || !lines[0].time.Equal(start) || lines[0].text != "first line" {
+		t.Fatalf("unexpected lines: %+v", lines)
+	}
+
+	if !lines[1].time.IsZero() || lines[1].text != "without time" {
+		t.Errorf("unexpected lines: %+v", lines)
+	}
+
+	if lines = logLines(nil); len(lines) != 0 {
+		t.Errorf("empty logs must not have lines: %+v", lines)
+	}
+}
+
+func timelineTestCopies(start time.Time) []*reportCopy {
+	var line = func(offset time.Duration, text string) string {
+		return start.Add(offset).Format(time.RFC3339Nano) + " " + text + "\n"
+	}
+
+	return []*reportCopy{
+		{
+			labels:  metricsLabels{container: "delete_db", iCopy: 0},
+			name:    "delete_db_0",
+			started: start,
+			logs: func() ([]byte, error) {
+				return []byte(line(time.Second, "\x1b[32mwaiting\x1b[0m") + "continuation\n" + line(50*time.Second, "ready")), nil
+			},
+			chaos: []ChaosEvent{{Time: start.Add(10 * time.Second), Container: "delete_db_0", Action: "pause()"}},
+		},
+		{
+			labels:  metricsLabels{container: "delete_api", iCopy: 0},
+			name:    "delete_api_0",
+			started: start,
+			logs: func() ([]byte, error) {
+				return []byte(line(2*time.Second, "connecting") + line(20*time.Second, "error: timeout")), nil
+			},
+		},
+	}
+}
+
+func TestTimelineMake(t *testing.T) {
+	var start = time.Date(2022, 11, 20, 13, 15, 0, 0, time.UTC)
+	var timeline = timelineMake(timelineTestCopies(start), start.Add(60*time.Second))
+
+	var texts = make([]string, 0)
+	for _, entry := range timeline.Entries() {
+		texts = append(texts, entry.Name+":"+entry.Text)
+	}
+
+	var expected = "delete_db_0:waiting,delete_db_0:continuation,delete_api_0:connecting,delete_db_0:pause(),delete_api_0:error: timeout,delete_db_0:ready"
+	if strings.Join(texts, ",") != expected {
+		t.Errorf("unexpected order: %v", texts)
+	}
+
+	filtered, err := timeline.Filter("db", -1, "")
+	if err != nil || len(filtered.Entries()) != 4 {
+		t.Errorf("the filter by container must accept the name without prefix: %+v, %v", filtered, err)
+	}
+
+	if filtered, err = timeline.Filter("", 0, "^error"); err != nil || len(filtered.Entries()) != 1 || filtered.Entries()[0].Name != "delete_api_0" {
+		t.Errorf("unexpected filter by regular expression: %+v, %v", filtered, err)
+	}
+
+	if filtered, err = timeline.Filter("", 1, ""); err != nil || len(filtered.Entries()) != 0 {
+		t.Errorf("unexpected filter by copy: %+v, %v", filtered, err)
+	}
+
+	if _, err = timeline.Filter("", -1, "("); err == nil {
+		t.Errorf("an invalid regular expression must return an error")
+	}
+}
+
+func TestTimeline_Gaps(t *testing.T) {
+	var start = time.Date(2022, 11, 20, 13, 15, 0, 0, time.UTC)
+	var timeline = timelineMake(timelineTestCopies(start), start.Add(60*time.Second))
+
+	var gaps = timeline.Gaps(30 * time.Second)
+	if len(gaps) != 2 {
+		t.Fatalf("unexpected gaps: %+v", gaps)
+	}
+
+	if gaps[0].Name != "delete_db_0" || !gaps[0].Time.Equal(start.Add(time.Second)) || gaps[0].Duration != 49*time.Second {
+		t.Errorf("unexpected gap between log lines: %+v", gaps[0])
+	}
+
+	if gaps[1].Name != "delete_api_0" || !gaps[1].Time.Equal(start.Add(20*time.Second)) || gaps[1].Duration != 40*time.Second {
+		t.Errorf("unexpected gap until the end: %+v", gaps[1])
+	}
+
+	var text = timeline.WithGaps(30 * time.Second).String()
+	if !strings.Contains(text, "| gap: no log for 49s") || !strings.Contains(text, "| chaos: pause()") {
+		t.Errorf("unexpected text: %v", text)
+	}
+
+	var dir = t.TempDir()
+	if err := timeline.write(dir, 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "timeline.log")); err != nil || string(data) != text {
+		t.Errorf("unexpected timeline.log: %s, %v", data, err)
+	}
+}
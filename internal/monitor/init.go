@@ -9,8 +9,10 @@ var ErrorChList = make([]<-chan error, 0)
 var FailChList = make([]<-chan string, 0)
 var DoneChList = make([]<-chan struct{}, 0)
 var EndFunc = make([]func(), 0)
+var CleanupFunc = make([]func(), 0)
 var ChaosFunc = make([]func(), 0)
 var IpAddress = make(map[string]string)
+var IpV6Address = make(map[string]string)
 
 var Err bool
 
@@ -24,6 +26,14 @@ func GetIpAddress(container string) (ip string) {
 	return IpAddress[container]
 }
 
+func AddIpV6Address(container, ip string) {
+	IpV6Address[container] = ip
+}
+
+func GetIpV6Address(container string) (ip string) {
+	return IpV6Address[container]
+}
+
 func AddChaosFunc(f ...func()) {
 	ChaosFunc = append(ChaosFunc, f...)
 }
@@ -39,7 +49,41 @@ func EndAll() {
 	}
 }
 
+// AddCleanupFunc
+//
+// Adds a function to be called by the test cleanup, before the containers are removed
+func AddCleanupFunc(f func()) {
+	CleanupFunc = append(CleanupFunc, f)
+}
+
+// CleanupAll
+//
+// Runs all cleanup functions, in order of addition, and empties the list
+func CleanupAll() {
+	for k := range CleanupFunc {
+		CleanupFunc[k]()
+	}
+
+	CleanupFunc = make([]func(), 0)
+}
+
+// Status of the test returned by MonitorStatus()
+const (
+	KStatusPass  = "pass"
+	KStatusFail  = "fail"
+	KStatusError = "error"
+)
+
 func Monitor() (pass bool) {
+	var status, _ = MonitorStatus()
+	return status == KStatusPass
+}
+
+// MonitorStatus
+//
+// Same as Monitor(), but returns the status of the test, KStatusPass, KStatusFail or KStatusError, and the reason of
+// the fail or error, redacted
+func MonitorStatus() (status, reason string) {
 	if !Err {
 		for k := range ChaosFunc {
 			if ChaosFunc[k] != nil {
@@ -57,16 +101,17 @@ func Monitor() (pass bool) {
 	for {
 		select {
 		case err := <-eventError:
-			log.Printf("test error: %v", err)
-			return
+			reason = Redact(err.Error())
+			log.Printf("test error: %v", reason)
+			return KStatusError, reason
 		case fail := <-eventFail:
-			log.Printf("test fail: %v", fail)
-			return
+			reason = Redact(fail)
+			log.Printf("test fail: %v", reason)
+			return KStatusFail, reason
 		case <-eventDone:
 			counterEndFunc -= 1
 			if counterEndFunc <= 0 {
-				pass = true
-				return
+				return KStatusPass, ""
 			}
 		}
 	}
@@ -0,0 +1,294 @@
+// Command chaos-reaper
+//
+// English:
+//
+//	Sidecar started by Primordial.Reaper(). It holds the socket connections of the test processes and, when the last
+//	connection drops, e.g. go test was killed by timeout, Ctrl-C or out of memory, removes all containers, networks,
+//	volumes and images with the labels, through the docker socket, and ends.
+//
+//	  chaos-reaper -label chaos.project=github.com/helmutkemper/chaos -label chaos.session=20221120T131500-3f2a9c1d
+//
+// Português:
+//
+//	Sidecar iniciado por Primordial.Reaper(). Ele mantém as conexões de socket dos processos de teste e, quando a
+//	última conexão cai, ex. go test foi morto por timeout, Ctrl-C ou falta de memória, remove todos os containers,
+//	redes, volumes e imagens com os rótulos, através do socket do docker, e termina.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// kAck is the line sent to each connection, so the test process knows the reaper is ready
+const kAck = "ACK\n"
+
+// kReapRounds is the number of attempts to remove the docker elements, e.g. a network in use by a container which is
+// still being removed
+const kReapRounds = 3
+
+// labels is the list of -label flags
+type labels []string
+
+func (el *labels) String() string {
+	return strings.Join(*el, ",")
+}
+
+func (el *labels) Set(value string) error {
+	*el = append(*el, value)
+	return nil
+}
+
+func main() {
+	var labelList labels
+	flag.Var(&labelList, "label", "label key=value of the docker elements removed, repeat for more labels")
+	var listen = flag.String("listen", ":8080", "address of the connections of the test processes")
+	var socket = flag.String("socket", "/var/run/docker.sock", "socket of the docker daemon")
+	var connectTimeout = flag.Duration("connect", time.Minute, "time waiting for the first connection")
+	var grace = flag.Duration("grace", 10*time.Second, "time waiting for a new connection after the last one drops")
+	flag.Parse()
+
+	if len(labelList) == 0 {
+		log.Fatalf("chaos-reaper: at least one -label is required")
+	}
+
+	var listener, err = net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("chaos-reaper: listen: %v", err)
+	}
+
+	var keeper = &connections{connect: *connectTimeout, grace: *grace}
+	keeper.wait(listener)
+
+	var client = dockerClient(*socket)
+	for round := 0; round != kReapRounds; round += 1 {
+		var left int
+		if left, err = reap(client, labelList); err == nil && left == 0 {
+			break
+		}
+
+		log.Printf("chaos-reaper: %v elements left: %v", left, err)
+		time.Sleep(time.Second)
+	}
+}
+
+// connections
+//
+// Counts the connections of the test processes
+type connections struct {
+	connect time.Duration
+	grace   time.Duration
+
+	mutex  sync.Mutex
+	active int
+
+	// Receives one value each time the number of connections changes
+	changed chan struct{}
+}
+
+// wait
+//
+// Returns when there is no connection for the grace time, or no connection at all for the connect time
+func (el *connections) wait(listener net.Listener) {
+	el.changed = make(chan struct{}, 1)
+	go el.accept(listener)
+	defer func() { _ = listener.Close() }()
+
+	var timer = time.NewTimer(el.connect)
+	for {
+		select {
+		case <-timer.C:
+			el.mutex.Lock()
+			var active = el.active
+			el.mutex.Unlock()
+
+			if active == 0 {
+				return
+			}
+
+		case <-el.changed:
+			el.mutex.Lock()
+			var active = el.active
+			el.mutex.Unlock()
+
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			if active == 0 {
+				timer.Reset(el.grace)
+			}
+		}
+	}
+}
+
+// accept
+//
+// Holds each connection until it drops
+func (el *connections) accept(listener net.Listener) {
+	for {
+		var conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+
+		el.add(1)
+		go func() {
+			defer el.add(-1)
+			defer func() { _ = conn.Close() }()
+
+			if _, err := conn.Write([]byte(kAck)); err != nil {
+				return
+			}
+
+			// the test process never sends data, the read ends when the connection drops
+			_, _ = io.Copy(io.Discard, bufio.NewReader(conn))
+		}()
+	}
+}
+
+func (el *connections) add(delta int) {
+	el.mutex.Lock()
+	el.active += delta
+	el.mutex.Unlock()
+
+	select {
+	case el.changed <- struct{}{}:
+	default:
+	}
+}
+
+// dockerClient
+//
+// Http client of the docker api over the unix socket
+func dockerClient(socket string) (client *http.Client) {
+	return &http.Client{
+		Timeout: time.Minute,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return new(net.Dialer).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+// element
+//
+// Docker element found by the labels
+type element struct {
+	kind string
+	id   string
+}
+
+// reap
+//
+// Removes the containers, networks, volumes and images with the labels, in this order, and returns the number of
+// elements which couldn't be removed
+func reap(client *http.Client, labelList []string) (left int, err error) {
+	var filter []byte
+	if filter, err = json.Marshal(map[string][]string{"label": labelList}); err != nil {
+		return
+	}
+
+	var query = "?filters=" + url.QueryEscape(string(filter))
+
+	var elements []element
+	var containers []struct{ Id string }
+	if err = dockerGet(client, "/containers/json"+query+"&all=1", &containers); err != nil {
+		return
+	}
+	for _, data := range containers {
+		elements = append(elements, element{kind: "containers", id: data.Id + "?force=1&v=1"})
+	}
+
+	var networks []struct{ Id string }
+	if err = dockerGet(client, "/networks"+query, &networks); err != nil {
+		return
+	}
+	for _, data := range networks {
+		elements = append(elements, element{kind: "networks", id: data.Id})
+	}
+
+	var volumes struct{ Volumes []struct{ Name string } }
+	if err = dockerGet(client, "/volumes"+query, &volumes); err != nil {
+		return
+	}
+	for _, data := range volumes.Volumes {
+		elements = append(elements, element{kind: "volumes", id: url.PathEscape(data.Name)})
+	}
+
+	var images []struct{ Id string }
+	if err = dockerGet(client, "/images/json"+query, &images); err != nil {
+		return
+	}
+	for _, data := range images {
+		elements = append(elements, element{kind: "images", id: data.Id + "?force=1"})
+	}
+
+	for _, data := range elements {
+		if e := dockerDelete(client, "/"+data.kind+"/"+data.id); e != nil {
+			left += 1
+			err = e
+			continue
+		}
+
+		log.Printf("chaos-reaper: remove %v %v", data.kind, data.id)
+	}
+
+	return
+}
+
+// dockerGet
+//
+// Decodes the json answer of the docker api
+func dockerGet(client *http.Client, path string, value interface{}) (err error) {
+	var response *http.Response
+	if response, err = client.Get("http://docker" + path); err != nil {
+		return
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		var body, _ = io.ReadAll(response.Body)
+		return fmt.Errorf("GET %v: %v: %s", path, response.Status, body)
+	}
+
+	return json.NewDecoder(response.Body).Decode(value)
+}
+
+// dockerDelete
+//
+// Removes one docker element, where not found is not an error
+func dockerDelete(client *http.Client, path string) (err error) {
+	var request *http.Request
+	if request, err = http.NewRequest(http.MethodDelete, "http://docker"+path, nil); err != nil {
+		return
+	}
+
+	var response *http.Response
+	if response, err = client.Do(request); err != nil {
+		return
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode >= 300 && response.StatusCode != http.StatusNotFound {
+		var body, _ = io.ReadAll(response.Body)
+		return fmt.Errorf("DELETE %v: %v: %s", path, response.Status, body)
+	}
+
+	return
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConnections_wait(t *testing.T) {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var done = make(chan time.Time)
+	var keeper = &connections{connect: 5 * time.Second, grace: 200 * time.Millisecond}
+	go func() {
+		keeper.wait(listener)
+		done <- time.Now()
+	}()
+
+	var conn net.Conn
+	if conn, err = net.Dial("tcp", listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+
+	var line string
+	if line, err = bufio.NewReader(conn).ReadString('\n'); err != nil || line != kAck {
+		t.Fatalf("unexpected ack: %q, %v", line, err)
+	}
+
+	// the reaper must wait while the connection is alive
+	select {
+	case <-done:
+		t.Fatalf("the reaper must not end with an active connection")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	var closed = time.Now()
+	_ = conn.Close()
+
+	select {
+	case end := <-done:
+		if end.Sub(closed) < 200*time.Millisecond {
+			t.Errorf("the reaper must wait the grace time: %v", end.Sub(closed))
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("the reaper must end after the grace time")
+	}
+}
+
+func TestConnections_waitWithoutConnection(t *testing.T) {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var start = time.Now()
+	var keeper = &connections{connect: 100 * time.Millisecond, grace: time.Hour}
+	keeper.wait(listener)
+
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("the reaper must end after the connect time")
+	}
+}
+
+func TestReap(t *testing.T) {
+	var mutex sync.Mutex
+	var deleted []string
+	var filters []string
+
+	var server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if r.Method == http.MethodDelete {
+			deleted = append(deleted, r.URL.Path)
+			if r.URL.Path == "/networks/n1" {
+				w.WriteHeader(http.StatusConflict)
+			}
+			return
+		}
+
+		filters = append(filters, r.URL.Query().Get("filters"))
+		switch r.URL.Path {
+		case "/containers/json":
+			_, _ = w.Write([]byte(`[{"Id":"c1"}]`))
+		case "/networks":
+			_, _ = w.Write([]byte(`[{"Id":"n1"}]`))
+		case "/volumes":
+			_, _ = w.Write([]byte(`{"Volumes":[{"Name":"v1"}]}`))
+		case "/images/json":
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+
+	var socket = filepath.Join(t.TempDir(), "docker.sock")
+	var listener, err = net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	var left int
+	left, err = reap(dockerClient(socket), []string{"chaos.session=a"})
+	if left != 1 || err == nil {
+		t.Errorf("the network in use must be left: %v, %v", left, err)
+	}
+
+	if strings.Join(deleted, ",") != "/containers/c1,/networks/n1,/volumes/v1" {
+		t.Errorf("unexpected order of removal: %v", deleted)
+	}
+
+	if len(filters) != 4 || filters[0] != `{"label":["chaos.session=a"]}` {
+		t.Errorf("unexpected filters: %v", filters)
+	}
+}
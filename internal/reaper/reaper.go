@@ -0,0 +1,100 @@
+// Package reaper
+//
+// English:
+//
+//	Builds the image of the chaos-reaper sidecar from the local sources, started by Primordial.Reaper().
+//
+// Português:
+//
+//	Constrói a imagem do sidecar chaos-reaper a partir dos fontes locais, iniciado por Primordial.Reaper().
+package reaper
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// KBinaryName is the name of the binary inside the image
+const KBinaryName = "chaos-reaper"
+
+// KPort is the port of the connections of the test processes, inside the container
+const KPort = "8080/tcp"
+
+//go:embed cmd/main.go
+var source []byte
+
+// ImageName
+//
+// English:
+//
+//	Returns the name of the image, with a tag made from the sources, so a new version is built when the sources change
+//
+// Português:
+//
+//	Retorna o nome da imagem, com uma tag feita a partir dos fontes, para que uma nova versão seja construída quando os
+//	fontes mudam
+func ImageName() (name string) {
+	var sum = sha256.Sum256(source)
+	return KBinaryName + ":" + hex.EncodeToString(sum[:6])
+}
+
+// Build
+//
+// English:
+//
+//	Compiles the reaper, with the go compiler of the host computer, for linux and the architecture of the host, and
+//	writes the Dockerfile of the image in the folder.
+//
+//	 Input:
+//	   dir: folder of the binary and of the Dockerfile, the context of the image build
+//
+// Português:
+//
+//	Compila o reaper, com o compilador go do computador hospedeiro, para linux e a arquitetura do hospedeiro, e escreve
+//	o Dockerfile da imagem na pasta.
+//
+//	 Entrada:
+//	   dir: pasta do binário e do Dockerfile, o contexto da construção da imagem
+func Build(dir string) (err error) {
+	var goBinary string
+	if goBinary, err = exec.LookPath("go"); err != nil {
+		return
+	}
+
+	var src = filepath.Join(dir, "src")
+	if err = os.MkdirAll(src, 0755); err != nil {
+		return
+	}
+
+	if err = os.WriteFile(filepath.Join(src, "go.mod"), []byte("module chaos-reaper\n\ngo 1.19\n"), 0644); err != nil {
+		return
+	}
+
+	if err = os.WriteFile(filepath.Join(src, "main.go"), source, 0644); err != nil {
+		return
+	}
+
+	var cmd = exec.Command(goBinary, "build", "-trimpath", "-ldflags=-s -w", "-o", filepath.Join(dir, KBinaryName), ".")
+	cmd.Dir = src
+	// the reaper is a module of its own, so the workspace and flags of the user's project don't apply
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=", "CGO_ENABLED=0", "GOOS=linux", "GOARCH="+runtime.GOARCH)
+
+	var output []byte
+	if output, err = cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("go build: %v: %s", err, output)
+	}
+
+	// the sources are not part of the image
+	if err = os.RemoveAll(src); err != nil {
+		return
+	}
+
+	var dockerfile = fmt.Sprintf("FROM scratch\nCOPY %v /%v\nEXPOSE %v\nENTRYPOINT [\"/%v\"]\n", KBinaryName, KBinaryName, KPort, KBinaryName)
+	return os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644)
+}
@@ -0,0 +1,34 @@
+package reaper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	var dir = t.TempDir()
+	if err := Build(dir); err != nil {
+		t.Fatalf("Build().error: %v", err)
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, KBinaryName)); err != nil || info.Size() == 0 {
+		t.Fatalf("binary not found: %v", err)
+	}
+
+	var dockerfile, err = os.ReadFile(filepath.Join(dir, "Dockerfile"))
+	if err != nil || !strings.Contains(string(dockerfile), "ENTRYPOINT [\"/chaos-reaper\"]") {
+		t.Errorf("unexpected Dockerfile: %s, %v", dockerfile, err)
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, "src")); err == nil {
+		t.Errorf("the sources must not be in the context of the image")
+	}
+}
+
+func TestImageName(t *testing.T) {
+	if name := ImageName(); !strings.HasPrefix(name, KBinaryName+":") || len(name) != len(KBinaryName)+13 {
+		t.Errorf("unexpected image name: %v", name)
+	}
+}
@@ -1,80 +1,214 @@
 package standalone
 
 import (
-	"github.com/docker/docker/api/types"
 	"github.com/helmutkemper/chaos/internal/builder"
-	iotmakerdocker "github.com/helmutkemper/iotmaker.docker/v1.0.1"
-	"regexp"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // GarbageCollector
 //
 // English:
 //
-//	Removes docker elements created during unit tests, such as networks, containers, images and volumes
-//
-// with the term delete in the name.
-//
-//	Eg: network_to_delete_after_test
+//	Removes the docker elements created by this test process, such as networks, containers, images and volumes with
+//	the label KLabelSession of SessionId().
 //
 //	 Input:
-//	   names: Terms contained in the name of docker elements indicated for removal.
+//	   names: Terms contained in the name of docker elements of this project, of any session, indicated for removal.
 //	     Eg: nats, removes network, container image, and volume elements that contain the term "nats"
 //	     in the name. [optional]
 //
-// Português:
+//	 Notes:
+//	   * Only docker elements with the label KLabelProject are removed, the name is never enough.
 //
-//	Remove elementos docker criados dutente os testtes unitários, como por exemplo, redes, contêineres,
-//	imagens e volumes com o termo delete no nome.
+// Português:
 //
-//	ex.: network_to_delete_after_test
+//	Remove os elementos docker criados por este processo de teste, como por exemplo, redes, contêineres, imagens e
+//	volumes com o rótulo KLabelSession de SessionId().
 //
 //	 Entrada:
-//	   names: Termos contidos no nome dos elementos docker indicados para remoção.
+//	   names: Termos contidos no nome dos elementos docker deste projeto, de qualquer sessão, indicados para remoção.
 //	     Ex.: nats, remove os elementos de rede, imagem container e volumes que contenham o termo
 //	     "nats" no nome. [opcional]
+//
+//	 Notas:
+//	   * Apenas elementos docker com o rótulo KLabelProject são removidos, o nome nunca é suficiente.
 func GarbageCollector(names ...string) {
-	var err error
+	_, _ = garbageCollect(false, func(list []builder.Resource) []builder.Resource {
+		return garbageSelect(list, sessionId, names...)
+	})
+}
+
+// GarbageCollectorDryRun
+//
+// English:
+//
+//	Returns the docker elements which GarbageCollector() would remove, without removing them
+//
+// Português:
+//
+//	Retorna os elementos docker que GarbageCollector() removeria, sem removê-los
+func GarbageCollectorDryRun(names ...string) (resources []builder.Resource, err error) {
+	return garbageCollect(true, func(list []builder.Resource) []builder.Resource {
+		return garbageSelect(list, sessionId, names...)
+	})
+}
+
+// GarbageCollectorSession
+//
+// English:
+//
+//	Removes the docker elements of one session, e.g. left by a test process which was killed.
+//
+//	 Input:
+//	   session: id of the session, or "" for all sessions of this project
+//	   dryRun: only returns the docker elements, without removing them
+//
+// Português:
+//
+//	Remove os elementos docker de uma sessão, ex. deixados por um processo de teste que foi morto.
+//
+//	 Entrada:
+//	   session: id da sessão, ou "" para todas as sessões deste projeto
+//	   dryRun: apenas retorna os elementos docker, sem removê-los
+func GarbageCollectorSession(session string, dryRun bool) (resources []builder.Resource, err error) {
+	return garbageCollect(dryRun, func(list []builder.Resource) []builder.Resource {
+		return garbageSelect(list, session)
+	})
+}
+
+// GarbageCollectorDead
+//
+// English:
+//
+//	Removes the docker elements of the dead sessions, left by test processes which were killed before the cleanup,
+//	without touching the sessions of test processes still running, e.g. other packages of go test ./...
+//
+//	 Input:
+//	   maxAge: age of the test process after which the session is dead, even when the process id is in use
+//
+//	 Notes:
+//	   * A session is dead when its test process, on this host, is no longer running, or when it is older than maxAge;
+//	   * The sessions of other hosts, e.g. sharing a remote docker daemon, are only removed by the age or by the reaper;
+//	   * The session of this test process and the sessions with the label KLabelKeep, kept by KeepOnFailure(), are
+//	     never removed, use GarbageCollectorSession().
+//
+// Português:
+//
+//	Remove os elementos docker das sessões mortas, deixados por processos de teste mortos antes da limpeza, sem tocar
+//	nas sessões de processos de teste ainda em execução, ex. outros pacotes de go test ./...
+//
+//	 Entrada:
+//	   maxAge: idade do processo de teste após a qual a sessão está morta, mesmo quando o id do processo está em uso
+//
+//	 Notas:
+//	   * Uma sessão está morta quando o seu processo de teste, neste hospedeiro, não está mais em execução, ou quando é
+//	     mais antiga que maxAge;
+//	   * As sessões de outros hospedeiros, ex. compartilhando um docker daemon remoto, só são removidas pela idade ou
+//	     pelo reaper;
+//	   * A sessão deste processo de teste e as sessões com o rótulo KLabelKeep, mantidas por KeepOnFailure(), nunca
+//	     são removidas, use GarbageCollectorSession().
+func GarbageCollectorDead(maxAge time.Duration) (resources []builder.Resource, err error) {
+	return garbageCollect(false, func(list []builder.Resource) []builder.Resource {
+		return garbageSelectDead(list, time.Now(), maxAge, processAlive)
+	})
+}
 
-	// garbage collector delete all containers, images, volumes and networks whose name contains the term
-	// "delete"
+// garbageCollect
+//
+// Lists the docker elements of the project and removes the elements chosen by the selector
+func garbageCollect(dryRun bool, selector func(list []builder.Resource) []builder.Resource) (resources []builder.Resource, err error) {
 	var garbageCollector = builder.DockerSystem{}
-	err = garbageCollector.Init()
-	if err != nil {
+	if err = garbageCollector.Init(); err != nil {
 		return
 	}
 
-	var re = regexp.MustCompile("\\w+_\\w+")
-	var list []builder.NameAndId
-	list, _ = garbageCollector.ContainerFindIdByNameContains("_")
+	var list []builder.Resource
+	if list, err = garbageCollector.ResourceListByLabel(KLabelProject + "=" + KProject); err != nil {
+		return
+	}
 
-	var dockerSys iotmakerdocker.DockerSystem
-	err = dockerSys.Init()
-	if err != nil {
+	resources = selector(list)
+	if dryRun {
 		return
 	}
 
-	var inspect types.ContainerJSON
-	for _, container := range list {
-		if re.Match([]byte(container.Name)) == true {
-			inspect, err = dockerSys.ContainerInspect(container.ID)
-			if err != nil {
-				return
+	for _, resource := range resources {
+		if e := garbageCollector.ResourceRemove(resource); e != nil {
+			if err == nil {
+				err = e
 			}
+			continue
+		}
+
+		log.Printf("remove: %v %v", resource.Kind, resource.Name)
+	}
 
-			if inspect.State != nil && inspect.State.ExitCode != 0 {
-				_ = dockerSys.ContainerRemove(container.ID, true, false, false)
+	return
+}
+
+// garbageSelect
+//
+// Selects the docker elements of the session, all sessions when session is "", or with one of the terms in the name
+func garbageSelect(list []builder.Resource, session string, names ...string) (resources []builder.Resource) {
+	resources = make([]builder.Resource, 0)
+	for _, resource := range list {
+		var selected = session == "" || resource.Labels[KLabelSession] == session
+		for _, name := range names {
+			if name != "" && strings.Contains(resource.Name, name) {
+				selected = true
 			}
 		}
+
+		if selected {
+			resources = append(resources, resource)
+		}
 	}
 
-	// set the term "delete" to garbage collector
-	err = garbageCollector.RemoveAllByNameContains("delete")
-	if err != nil {
-		return
+	return
+}
+
+// garbageSelectDead
+//
+// Selects the docker elements of the sessions without any owner alive, except the session of this test process and
+// the sessions kept
+func garbageSelectDead(list []builder.Resource, now time.Time, maxAge time.Duration, alive func(pid int) bool) (resources []builder.Resource) {
+	var sessions = make(map[string]bool)
+	for _, resource := range list {
+		var session = resource.Labels[KLabelSession]
+		if session == "" || session == sessionId {
+			continue
+		}
+
+		sessions[session] = sessions[session] || resource.Labels[KLabelKeep] == "true" ||
+			garbageOwnerAlive(resource.Labels, now, maxAge, alive)
+	}
+
+	resources = make([]builder.Resource, 0)
+	for _, resource := range list {
+		if live, found := sessions[resource.Labels[KLabelSession]]; found && !live {
+			resources = append(resources, resource)
+		}
+	}
+
+	return
+}
+
+// garbageOwnerAlive
+//
+// Returns false when the test process of the labels is older than maxAge or is no longer running on this host
+func garbageOwnerAlive(labels map[string]string, now time.Time, maxAge time.Duration, alive func(pid int) bool) (live bool) {
+	if start, err := time.Parse(time.RFC3339, labels[KLabelStart]); err == nil && now.Sub(start) > maxAge {
+		return false
 	}
 
-	for _, nameContains := range names {
-		_ = garbageCollector.RemoveAllByNameContains(nameContains)
+	// the process id of other hosts can't be verified
+	var pid, err = strconv.Atoi(labels[KLabelPid])
+	if err != nil || labels[KLabelHost] != sessionHost {
+		return true
 	}
+
+	return alive(pid)
 }
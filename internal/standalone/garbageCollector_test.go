@@ -0,0 +1,105 @@
+package standalone
+
+import (
+	"github.com/helmutkemper/chaos/internal/builder"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGarbageSelect(t *testing.T) {
+	var list = []builder.Resource{
+		{Kind: builder.KResourceContainer, Name: "delete_db_0", Labels: map[string]string{KLabelSession: "a"}},
+		{Kind: builder.KResourceContainer, Name: "delete_db_1", Labels: map[string]string{KLabelSession: "b"}},
+		{Kind: builder.KResourceNetwork, Name: "nats_network", Labels: map[string]string{KLabelSession: "b"}},
+		{Kind: builder.KResourceVolume, Name: "volume", Labels: map[string]string{}},
+	}
+
+	var names = func(resources []builder.Resource) (text string) {
+		for _, resource := range resources {
+			text += resource.Name + ","
+		}
+		return
+	}
+
+	if selected := names(garbageSelect(list, "a")); selected != "delete_db_0," {
+		t.Errorf("only the session must be selected: %v", selected)
+	}
+
+	if selected := names(garbageSelect(list, "a", "nats")); selected != "delete_db_0,nats_network," {
+		t.Errorf("the terms must select other sessions: %v", selected)
+	}
+
+	if selected := names(garbageSelect(list, "")); selected != "delete_db_0,delete_db_1,nats_network,volume," {
+		t.Errorf("all sessions must be selected: %v", selected)
+	}
+
+	if selected := names(garbageSelect(list, "c", "")); selected != "" {
+		t.Errorf("an empty term must not select: %v", selected)
+	}
+}
+
+func TestSessionLabels(t *testing.T) {
+	var labels = SessionLabels()
+	if labels[KLabelProject] != KProject || labels[KLabelSession] != SessionId() || SessionId() == "" {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+
+	if labels[KLabelPid] != strconv.Itoa(os.Getpid()) || labels[KLabelHost] != sessionHost || labels[KLabelStart] == "" {
+		t.Errorf("unexpected owner labels: %v", labels)
+	}
+}
+
+func TestGarbageSelectDead(t *testing.T) {
+	var now = time.Date(2022, 11, 20, 13, 15, 0, 0, time.UTC)
+	var owner = func(session string, pid int, host string, start time.Time) map[string]string {
+		return map[string]string{KLabelSession: session, KLabelPid: strconv.Itoa(pid), KLabelHost: host, KLabelStart: start.Format(time.RFC3339)}
+	}
+
+	var list = []builder.Resource{
+		{Kind: builder.KResourceContainer, Name: "own", Labels: owner(sessionId, 1, sessionHost, now)},
+		{Kind: builder.KResourceContainer, Name: "running", Labels: owner("a", 1, sessionHost, now)},
+		{Kind: builder.KResourceContainer, Name: "killed_0", Labels: owner("b", 2, sessionHost, now)},
+		{Kind: builder.KResourceNetwork, Name: "killed_network", Labels: owner("b", 2, sessionHost, now)},
+		{Kind: builder.KResourceContainer, Name: "shared_0", Labels: owner("c", 2, sessionHost, now)},
+		{Kind: builder.KResourceContainer, Name: "shared_1", Labels: owner("c", 1, sessionHost, now)},
+		{Kind: builder.KResourceContainer, Name: "remote", Labels: owner("d", 2, "other-host", now)},
+		{Kind: builder.KResourceContainer, Name: "old", Labels: owner("e", 1, sessionHost, now.Add(-2*time.Hour))},
+		{Kind: builder.KResourceContainer, Name: "old_remote", Labels: owner("f", 1, "other-host", now.Add(-2*time.Hour))},
+		{Kind: builder.KResourceVolume, Name: "unknown", Labels: map[string]string{KLabelSession: "g"}},
+		{Kind: builder.KResourceVolume, Name: "no_session", Labels: map[string]string{}},
+		{Kind: builder.KResourceContainer, Name: "kept_0", Labels: owner("h", 2, sessionHost, now.Add(-2*time.Hour))},
+		{Kind: builder.KResourceVolume, Name: "chaos-keep-h", Labels: map[string]string{KLabelSession: "h", KLabelKeep: "true"}},
+	}
+
+	// only the process 1 is running
+	var alive = func(pid int) bool {
+		return pid == 1
+	}
+
+	var text string
+	for _, resource := range garbageSelectDead(list, now, time.Hour, alive) {
+		text += resource.Name + ","
+	}
+
+	if text != "killed_0,killed_network,old,old_remote," {
+		t.Errorf("only the dead sessions, not kept, must be selected: %v", text)
+	}
+}
+
+func TestProcessAlive(t *testing.T) {
+	if !processAlive(os.Getpid()) {
+		t.Errorf("the test process must be alive")
+	}
+
+	var command = exec.Command("true")
+	if err := command.Run(); err != nil {
+		t.Skipf("true: %v", err)
+	}
+
+	if processAlive(command.Process.Pid) {
+		t.Errorf("the process %v must be dead", command.Process.Pid)
+	}
+}
@@ -0,0 +1,112 @@
+package standalone
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Labels of ownership added to every container, network, volume and image created by the manager
+const (
+	// KLabelProject has the value KProject in every docker element of this project
+	KLabelProject = "chaos.project"
+
+	// KLabelSession has the id of the test process which created the docker element
+	KLabelSession = "chaos.session"
+
+	// KLabelHost and KLabelPid have the host name and the process id of the test process, used to find dead sessions
+	KLabelHost = "chaos.session.host"
+	KLabelPid  = "chaos.session.pid"
+
+	// KLabelStart has the start time of the test process, in RFC3339
+	KLabelStart = "chaos.session.start"
+
+	// KLabelKeep marks a session kept by KeepOnFailure(), never removed by GarbageCollectorDead()
+	KLabelKeep = "chaos.keep"
+
+	KProject = "github.com/helmutkemper/chaos"
+)
+
+// KSessionMaxAge is the age after which a session is dead, removed at the start of the next test by
+// GarbageCollectorDead()
+const KSessionMaxAge = 24 * time.Hour
+
+// KSessionEnv defines the session id, e.g. to share one session between test processes
+const KSessionEnv = "CHAOS_SESSION"
+
+var sessionId = sessionMake()
+
+// Owner of the session, the test process
+var (
+	sessionHost, _ = os.Hostname()
+	sessionPid     = os.Getpid()
+	sessionStart   = time.Now().UTC()
+)
+
+// sessionMake
+//
+// Returns the session id of the environment variable, or a new id with the start time and random bytes
+func sessionMake() (id string) {
+	if id = os.Getenv(KSessionEnv); id != "" {
+		return
+	}
+
+	var random = make([]byte, 4)
+	_, _ = rand.Read(random)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(random)
+}
+
+// SessionId
+//
+// English:
+//
+//	Returns the id of the session, the label KLabelSession of the docker elements created by this test process
+//
+// Português:
+//
+//	Retorna o id da sessão, o rótulo KLabelSession dos elementos docker criados por este processo de teste
+func SessionId() (id string) {
+	return sessionId
+}
+
+// SessionLabels
+//
+// English:
+//
+//	Returns the labels of ownership of the docker elements created by this test process
+//
+// Português:
+//
+//	Retorna os rótulos de propriedade dos elementos docker criados por este processo de teste
+func SessionLabels() (labels map[string]string) {
+	return map[string]string{
+		KLabelProject: KProject,
+		KLabelSession: sessionId,
+		KLabelHost:    sessionHost,
+		KLabelPid:     strconv.Itoa(sessionPid),
+		KLabelStart:   sessionStart.Format(time.RFC3339),
+	}
+}
+
+// processAlive
+//
+// Returns true when the process of this host is running
+func processAlive(pid int) (alive bool) {
+	var process, err = os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// on windows, FindProcess() fails when the process doesn't exist and Signal() only supports kill
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, os.ErrPermission)
+}
@@ -0,0 +1,691 @@
+// Command chaos-stress
+//
+// English:
+//
+//	Stressor copied into the containers by the resource-pressure and disk-fault chaos. It burns cpu, fills the
+//	memory, fills the disk or keeps the disk busy during a time window and then releases the resources and ends. It
+//	also truncates or corrupts a file and ends immediately.
+//
+//	  chaos-stress -duration 30s -cpu 2
+//	  chaos-stress -duration 30s -memory 268435456
+//	  chaos-stress -duration 30s -disk 1073741824 -path /tmp/chaos-stress
+//	  chaos-stress -duration 30s -disk -1 -path /data/chaos-stress (until the disk is full)
+//	  chaos-stress -duration 30s -io /data/chaos-stress
+//	  chaos-stress -truncate /data/file.db -size 100
+//	  chaos-stress -corrupt /data/file.db -bytes 16
+//	  chaos-stress -duration 30s -dns nxdomain -names db,cache
+//	  chaos-stress -dns-wait 10s (reports the error of the start of the dns stand-in)
+//	  chaos-stress -dns-restore 10s (restores /etc/resolv.conf if the dns stand-in was killed)
+//
+// Português:
+//
+//	Estressor copiado para os containers pelo caos de pressão de recursos e de falha de disco. Ele queima cpu, enche
+//	a memória, enche o disco ou mantém o disco ocupado durante uma janela de tempo e depois libera os recursos e
+//	termina. Ele também trunca ou corrompe um arquivo e termina imediatamente.
+//
+//	O modo dns responde a resolução de nomes do container durante a janela, como nxdomain, servfail, timeout ou
+//	endereço errado, e encaminha os demais nomes para o resolvedor do docker.
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// kPageSize is the step used to touch the memory, so the kernel really allocates it
+const kPageSize = 4096
+
+const (
+	// kDnsListen is the address of the dns stand-in, written in resolv.conf during the window
+	kDnsListen = "127.0.0.1"
+
+	// kDnsHeaderSize is the size of the header of a dns message
+	kDnsHeaderSize = 12
+
+	// kDnsTimeout is the time waiting for the answer of the docker resolver
+	kDnsTimeout = 5 * time.Second
+
+	// kDnsBackup is the copy of the original resolver configuration, removed after the restore
+	kDnsBackup = "/chaos-stress.resolv.conf"
+
+	// kDnsStatus is the status of the stand-in, ready or the error of the start, read by -dns-wait
+	kDnsStatus = "/chaos-stress.dns"
+)
+
+// dns response codes and record types used by the stand-in
+const (
+	kDnsRCodeServFail = 2
+	kDnsRCodeNxDomain = 3
+	kDnsTypeA         = 1
+	kDnsTypeAAAA      = 28
+)
+
+func main() {
+	var duration = flag.Duration("duration", 30*time.Second, "time window of the stress")
+	var cpu = flag.Int("cpu", 0, "number of cpus to burn")
+	var memory = flag.Int64("memory", 0, "bytes of memory to fill")
+	var disk = flag.Int64("disk", 0, "bytes of disk to fill")
+	var path = flag.String("path", "/tmp/chaos-stress", "folder of the file used to fill the disk")
+	var ioPath = flag.String("io", "", "folder of the file written and synchronized during the window")
+	var truncate = flag.String("truncate", "", "file to truncate")
+	var size = flag.Int64("size", 0, "size of the truncated file")
+	var corrupt = flag.String("corrupt", "", "file to corrupt")
+	var corruptBytes = flag.Int("bytes", 1, "number of random bytes changed by the corruption")
+	var dnsMode = flag.String("dns", "", "dns failure: nxdomain, servfail, timeout or wrong")
+	var dnsAddress = flag.String("address", "", "address answered by the dns failure wrong")
+	var dnsNames = flag.String("names", "", "comma separated names affected by the dns failure, empty for all names")
+	var dnsUpstream = flag.String("upstream", "127.0.0.11:53", "resolver of the names not affected by the dns failure")
+	var resolvConf = flag.String("resolv", "/etc/resolv.conf", "resolver configuration changed during the dns failure")
+	var dnsWaitTimeout = flag.Duration("dns-wait", 0, "waits for the start of the dns stand-in and reports its error")
+	var dnsRestoreTimeout = flag.Duration("dns-restore", 0, "waits for the end of the dns stand-in and restores the resolver configuration")
+	flag.Parse()
+
+	if *dnsWaitTimeout > 0 {
+		if err := dnsWait(kDnsStatus, *dnsWaitTimeout); err != nil {
+			log.Fatalf("chaos-stress: dns: %v", err)
+		}
+		return
+	}
+
+	if *dnsRestoreTimeout > 0 {
+		if err := dnsRestore(*resolvConf, kDnsBackup, kDnsStatus, *dnsRestoreTimeout); err != nil {
+			log.Fatalf("chaos-stress: dns: restore: %v", err)
+		}
+		return
+	}
+
+	if *truncate != "" {
+		if err := os.Truncate(*truncate, *size); err != nil {
+			log.Fatalf("chaos-stress: truncate: %v", err)
+		}
+		return
+	}
+
+	if *corrupt != "" {
+		if err := change(*corrupt, *corruptBytes); err != nil {
+			log.Fatalf("chaos-stress: corrupt: %v", err)
+		}
+		return
+	}
+
+	var end = time.Now().Add(*duration)
+
+	if *dnsMode != "" {
+		var standIn = dnsStandIn{
+			mode:     *dnsMode,
+			address:  net.ParseIP(*dnsAddress),
+			upstream: *dnsUpstream,
+			listen:   net.JoinHostPort(kDnsListen, "53"),
+			backup:   kDnsBackup,
+			status:   kDnsStatus,
+		}
+		if *dnsNames != "" {
+			standIn.names = strings.Split(strings.ToLower(*dnsNames), ",")
+		}
+
+		if err := standIn.serve(*resolvConf, end); err != nil {
+			log.Fatalf("chaos-stress: dns: %v", err)
+		}
+		return
+	}
+
+	if *cpu > 0 {
+		runtime.GOMAXPROCS(*cpu + 1)
+		for i := 0; i != *cpu; i += 1 {
+			go burn(end)
+		}
+	}
+
+	var hold []byte
+	if *memory > 0 {
+		hold = fill(*memory)
+	}
+
+	if *disk != 0 {
+		// a negative size fills the disk until it is full (ENOSPC)
+		if *disk < 0 {
+			*disk = math.MaxInt64
+		}
+
+		var file, err = write(*path, *disk)
+		if file != "" {
+			defer remove(file)
+		}
+		if err != nil {
+			log.Printf("chaos-stress: disk: %v", err)
+		}
+	}
+
+	if *ioPath != "" {
+		defer remove(filepath.Join(*ioPath, "io"))
+		if err := busy(*ioPath, end); err != nil {
+			log.Printf("chaos-stress: io: %v", err)
+		}
+	}
+
+	time.Sleep(time.Until(end))
+	runtime.KeepAlive(hold)
+}
+
+// remove deletes the file written by the stressor and its folder, only when the folder is empty, so the files of the
+// container are never removed
+func remove(file string) {
+	_ = os.Remove(file)
+	_ = os.Remove(filepath.Dir(file))
+}
+
+// busy writes and synchronizes a small file until the end of the window, so the other writes of the disk wait
+func busy(dir string, end time.Time) (err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+
+	var file *os.File
+	if file, err = os.Create(filepath.Join(dir, "io")); err != nil {
+		return
+	}
+	defer file.Close()
+
+	var block = make([]byte, 64*1024)
+	for time.Now().Before(end) {
+		for i := 0; i != 64; i += 1 {
+			if _, err = file.WriteAt(block, int64(i*len(block))); err != nil {
+				return
+			}
+
+			if err = file.Sync(); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// change writes random bytes in random positions of the file
+func change(path string, count int) (err error) {
+	var file *os.File
+	if file, err = os.OpenFile(path, os.O_RDWR, 0); err != nil {
+		return
+	}
+	defer file.Close()
+
+	var info os.FileInfo
+	if info, err = file.Stat(); err != nil || info.Size() == 0 {
+		return
+	}
+
+	var random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i != count; i += 1 {
+		if _, err = file.WriteAt([]byte{byte(random.Intn(256))}, random.Int63n(info.Size())); err != nil {
+			return
+		}
+	}
+
+	return file.Sync()
+}
+
+// burn keeps one cpu busy until the end of the window
+func burn(end time.Time) {
+	var x uint64
+	for time.Now().Before(end) {
+		for i := 0; i != 1000000; i += 1 {
+			x += uint64(i) * x
+		}
+	}
+}
+
+// fill allocates the memory and writes one byte per page
+func fill(size int64) (data []byte) {
+	data = make([]byte, size)
+	for i := int64(0); i < size; i += kPageSize {
+		data[i] = 1
+	}
+
+	return
+}
+
+// write fills the disk until size, or until the disk is full
+func write(dir string, size int64) (path string, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+
+	path = filepath.Join(dir, "fill")
+
+	var file *os.File
+	if file, err = os.Create(path); err != nil {
+		return
+	}
+	defer file.Close()
+
+	var block = make([]byte, 1024*1024)
+	for written := int64(0); written < size; written += int64(len(block)) {
+		if size-written < int64(len(block)) {
+			block = block[:size-written]
+		}
+
+		if _, err = file.Write(block); err != nil {
+			return
+		}
+	}
+
+	err = file.Sync()
+	return
+}
+
+// dnsStandIn answers the name resolution of the container during the dns failure
+type dnsStandIn struct {
+	mode     string
+	address  net.IP
+	names    []string
+	upstream string
+
+	// address of the udp and tcp listeners, kDnsListen:53 inside the container
+	listen string
+
+	// copy of the original resolver configuration and status read by -dns-wait, see kDnsBackup and kDnsStatus
+	backup string
+	status string
+}
+
+// serve points the resolver of the container to the stand-in until the end of the window and then restores it. The
+// original configuration is saved in the backup first, so -dns-restore restores it even if the stand-in is killed
+func (el dnsStandIn) serve(resolvConf string, end time.Time) (err error) {
+	_ = os.Remove(el.status)
+
+	// the exec is detached, so the error is written in the status file read by -dns-wait
+	defer func() {
+		if err != nil {
+			_ = os.WriteFile(el.status, []byte("error: "+err.Error()), 0644)
+		}
+	}()
+
+	if el.mode == "wrong" && el.address == nil {
+		return fmt.Errorf("the dns failure wrong requires -address")
+	}
+
+	var original []byte
+	if original, err = el.original(resolvConf); err != nil {
+		return
+	}
+
+	var conn net.PacketConn
+	if conn, err = net.ListenPacket("udp", el.listen); err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// the resolver falls back to tcp when the answer is truncated, or when the option use-vc is defined
+	var listener net.Listener
+	if listener, err = net.Listen("tcp", el.listen); err != nil {
+		return
+	}
+	defer listener.Close()
+
+	if err = os.WriteFile(el.backup, original, 0644); err != nil {
+		return
+	}
+
+	var once sync.Once
+	var restore = func() {
+		once.Do(func() {
+			if err := restoreResolv(resolvConf, el.backup); err != nil {
+				log.Printf("chaos-stress: dns: restore %v: %v", resolvConf, err)
+			}
+			_ = os.Remove(el.status)
+		})
+	}
+	defer restore()
+
+	// the container stops the stand-in with a signal, e.g. docker stop
+	var signals = make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		if _, ok := <-signals; ok {
+			restore()
+			os.Exit(1)
+		}
+	}()
+
+	// the file is a bind mount made by docker, so it is written in place and never replaced
+	if err = os.WriteFile(resolvConf, resolvStandIn(original), 0644); err != nil {
+		return
+	}
+
+	if err = os.WriteFile(el.status, []byte("ready"), 0644); err != nil {
+		return
+	}
+
+	go el.serveTcp(listener, end)
+
+	var buffer = make([]byte, 65535)
+	for {
+		_ = conn.SetReadDeadline(end)
+
+		var size int
+		var from net.Addr
+		if size, from, err = conn.ReadFrom(buffer); err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				return nil
+			}
+			return
+		}
+
+		var query = make([]byte, size)
+		copy(query, buffer[:size])
+		go el.answer(conn, from, query)
+	}
+}
+
+// original returns the original resolver configuration. The backup of a stand-in killed before the restore is the
+// original, because the configuration still points to the stand-in
+func (el dnsStandIn) original(resolvConf string) (original []byte, err error) {
+	if original, err = os.ReadFile(el.backup); err == nil {
+		return
+	}
+
+	return os.ReadFile(resolvConf)
+}
+
+// restoreResolv writes the backup in the resolver configuration and removes the backup. Without backup, the
+// configuration was already restored
+func restoreResolv(resolvConf, backup string) (err error) {
+	var original []byte
+	if original, err = os.ReadFile(backup); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return
+	}
+
+	if err = os.WriteFile(resolvConf, original, 0644); err != nil {
+		return
+	}
+
+	return os.Remove(backup)
+}
+
+// dnsRestore waits for the end of the stand-in and restores the resolver configuration, if the stand-in was killed
+// before the restore
+func dnsRestore(resolvConf, backup, status string, timeout time.Duration) (err error) {
+	var end = time.Now().Add(timeout)
+	for time.Now().Before(end) {
+		if _, err = os.Stat(backup); os.IsNotExist(err) {
+			_ = os.Remove(status)
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err = restoreResolv(resolvConf, backup); err != nil {
+		return
+	}
+
+	_ = os.Remove(status)
+	return
+}
+
+// dnsWait waits for the status of the stand-in and returns its error
+func dnsWait(status string, timeout time.Duration) (err error) {
+	var end = time.Now().Add(timeout)
+	for time.Now().Before(end) {
+		var data []byte
+		if data, err = os.ReadFile(status); err == nil {
+			if text := string(data); text != "ready" {
+				return fmt.Errorf("%v", strings.TrimPrefix(text, "error: "))
+			}
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("the dns stand-in didn't start in %v", timeout)
+}
+
+// serveTcp answers the queries of the tcp connections until the listener is closed
+func (el dnsStandIn) serveTcp(listener net.Listener, end time.Time) {
+	for {
+		var conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go el.answerTcp(conn, end)
+	}
+}
+
+// answerTcp replies to the queries of one tcp connection, each message has a prefix of two bytes with its size
+func (el dnsStandIn) answerTcp(conn net.Conn, end time.Time) {
+	defer conn.Close()
+
+	// a panic would end the stand-in before the restore of the resolver configuration
+	defer func() {
+		_ = recover()
+	}()
+
+	_ = conn.SetDeadline(end)
+	for {
+		var query, err = dnsReadTcp(conn)
+		if err != nil {
+			return
+		}
+
+		var response = el.response(query, "tcp")
+		if response == nil {
+			continue
+		}
+
+		if _, err = conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(response)))); err != nil {
+			return
+		}
+
+		if _, err = conn.Write(response); err != nil {
+			return
+		}
+	}
+}
+
+// dnsReadTcp reads one message of a tcp connection
+func dnsReadTcp(conn io.Reader) (message []byte, err error) {
+	var size = make([]byte, 2)
+	if _, err = io.ReadFull(conn, size); err != nil {
+		return
+	}
+
+	message = make([]byte, binary.BigEndian.Uint16(size))
+	_, err = io.ReadFull(conn, message)
+	return
+}
+
+// answer replies to one udp query
+func (el dnsStandIn) answer(conn net.PacketConn, from net.Addr, query []byte) {
+	// a panic would end the stand-in before the restore of the resolver configuration
+	defer func() {
+		_ = recover()
+	}()
+
+	if response := el.response(query, "udp"); response != nil {
+		_, _ = conn.WriteTo(response, from)
+	}
+}
+
+// response returns the failure when the name is affected, or the answer of the upstream. Nil for no answer
+func (el dnsStandIn) response(query []byte, network string) (response []byte) {
+	var name, qType, end, err = dnsQuestion(query)
+	if err != nil {
+		return
+	}
+
+	if !el.affected(name) {
+		if response, err = el.forward(query, network); err != nil {
+			response = dnsResponse(query, end, kDnsRCodeServFail, nil, qType)
+		}
+		return
+	}
+
+	switch el.mode {
+	case "nxdomain":
+		response = dnsResponse(query, end, kDnsRCodeNxDomain, nil, qType)
+	case "servfail":
+		response = dnsResponse(query, end, kDnsRCodeServFail, nil, qType)
+	case "wrong":
+		response = dnsResponse(query, end, 0, el.address, qType)
+	}
+
+	// timeout, and unknown modes, are answered with silence
+	return
+}
+
+// affected reports whether the name is affected by the failure
+func (el dnsStandIn) affected(name string) bool {
+	if len(el.names) == 0 {
+		return true
+	}
+
+	for _, affected := range el.names {
+		affected = strings.TrimSuffix(strings.TrimSpace(affected), ".")
+		if name == affected || strings.HasSuffix(name, "."+affected) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forward sends the query to the upstream resolver, by the same network of the query, and returns its answer
+func (el dnsStandIn) forward(query []byte, network string) (response []byte, err error) {
+	var conn net.Conn
+	if conn, err = net.DialTimeout(network, el.upstream, kDnsTimeout); err != nil {
+		return
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(kDnsTimeout))
+
+	if network == "tcp" {
+		if _, err = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(query))), query...)); err != nil {
+			return
+		}
+
+		return dnsReadTcp(conn)
+	}
+
+	if _, err = conn.Write(query); err != nil {
+		return
+	}
+
+	var buffer = make([]byte, 65535)
+	var size int
+	if size, err = conn.Read(buffer); err != nil {
+		return
+	}
+
+	response = buffer[:size]
+	return
+}
+
+// resolvStandIn replaces the name servers of the resolver configuration by the stand-in
+func resolvStandIn(original []byte) (changed []byte) {
+	var lines = []string{"nameserver " + kDnsListen}
+	for _, line := range strings.Split(string(original), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "nameserver") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// dnsQuestion returns the name, in lower case and without the final dot, the type and the end of the first question
+func dnsQuestion(query []byte) (name string, qType uint16, end int, err error) {
+	if len(query) < kDnsHeaderSize || binary.BigEndian.Uint16(query[4:6]) == 0 {
+		err = fmt.Errorf("message without question")
+		return
+	}
+
+	var labels []string
+	end = kDnsHeaderSize
+	for {
+		if end >= len(query) {
+			err = fmt.Errorf("malformed question")
+			return
+		}
+
+		var size = int(query[end])
+		end += 1
+		if size == 0 {
+			break
+		}
+
+		if size > 63 || end+size > len(query) {
+			err = fmt.Errorf("malformed question")
+			return
+		}
+
+		labels = append(labels, string(query[end:end+size]))
+		end += size
+	}
+
+	if end+4 > len(query) {
+		err = fmt.Errorf("malformed question")
+		return
+	}
+
+	name = strings.ToLower(strings.Join(labels, "."))
+	qType = binary.BigEndian.Uint16(query[end : end+2])
+	end += 4
+	return
+}
+
+// dnsResponse builds the response of the query with the response code and, when the address matches the type of the
+// question, one record with the address and time to live zero, so the client resolves the name again
+func dnsResponse(query []byte, end int, rCode int, address net.IP, qType uint16) (response []byte) {
+	response = make([]byte, end, end+28)
+	copy(response, query[:end])
+
+	// QR, opcode and RD of the query, RA and the response code
+	response[2] = 0x80 | query[2]&0x79
+	response[3] = 0x80 | byte(rCode)
+
+	var record []byte
+	if ip4 := address.To4(); ip4 != nil && qType == kDnsTypeA {
+		record = ip4
+	} else if address != nil && address.To4() == nil && qType == kDnsTypeAAAA {
+		record = address.To16()
+	}
+
+	binary.BigEndian.PutUint16(response[4:6], 1)
+	binary.BigEndian.PutUint16(response[6:8], 0)
+	binary.BigEndian.PutUint16(response[8:10], 0)
+	binary.BigEndian.PutUint16(response[10:12], 0)
+
+	if record != nil {
+		binary.BigEndian.PutUint16(response[6:8], 1)
+		// pointer to the name of the question, type, class IN and time to live zero
+		response = append(response, 0xc0, kDnsHeaderSize)
+		response = binary.BigEndian.AppendUint16(response, qType)
+		response = append(response, 0, 1, 0, 0, 0, 0)
+		response = binary.BigEndian.AppendUint16(response, uint16(len(record)))
+		response = append(response, record...)
+	}
+
+	return
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// query of db.example, type A, with the flag RD
+var queryDb = []byte{
+	0x12, 0x34, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x02, 'D', 'b', 0x07, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 0x00,
+	0x00, 0x01, 0x00, 0x01,
+}
+
+func TestDnsQuestion(t *testing.T) {
+	var name, qType, end, err = dnsQuestion(queryDb)
+	if err != nil {
+		t.Fatalf("dnsQuestion().error: %v", err)
+	}
+
+	if name != "db.example" || qType != kDnsTypeA || end != len(queryDb) {
+		t.Errorf("unexpected question: %v %v %v", name, qType, end)
+	}
+
+	if _, _, _, err = dnsQuestion(queryDb[:20]); err == nil {
+		t.Errorf("a truncated question must fail")
+	}
+}
+
+func TestDnsResponse(t *testing.T) {
+	var response = dnsResponse(queryDb, len(queryDb), kDnsRCodeNxDomain, nil, kDnsTypeA)
+	if response[0] != 0x12 || response[1] != 0x34 || response[2] != 0x81 || response[3]&0x0f != kDnsRCodeNxDomain {
+		t.Errorf("unexpected header: % x", response[:4])
+	}
+
+	if binary.BigEndian.Uint16(response[6:8]) != 0 {
+		t.Errorf("nxdomain must not have answers")
+	}
+
+	response = dnsResponse(queryDb, len(queryDb), 0, net.ParseIP("10.0.0.250"), kDnsTypeA)
+	if binary.BigEndian.Uint16(response[6:8]) != 1 {
+		t.Fatalf("wrong address must have one answer")
+	}
+
+	if ip := net.IP(response[len(response)-4:]); !ip.Equal(net.ParseIP("10.0.0.250")) {
+		t.Errorf("unexpected address: %v", ip)
+	}
+
+	response = dnsResponse(queryDb, len(queryDb), 0, net.ParseIP("fd00::250"), kDnsTypeA)
+	if binary.BigEndian.Uint16(response[6:8]) != 0 {
+		t.Errorf("an ipv6 address must not answer a question of type A")
+	}
+}
+
+func TestDnsStandIn_affected(t *testing.T) {
+	var standIn = dnsStandIn{names: []string{"db", "cache.example."}}
+
+	for name, expected := range map[string]bool{
+		"db":               true,
+		"db.example":       false,
+		"cache.example":    true,
+		"a.cache.example":  true,
+		"mycache.example":  false,
+		"delete_db_0.test": false,
+	} {
+		if standIn.affected(name) != expected {
+			t.Errorf("affected(%v) != %v", name, expected)
+		}
+	}
+
+	if !(dnsStandIn{}).affected("any") {
+		t.Errorf("without names, all names are affected")
+	}
+}
+
+func TestResolvStandIn(t *testing.T) {
+	var changed = resolvStandIn([]byte("search example\nnameserver 127.0.0.11\noptions ndots:0\n"))
+	if string(changed) != "nameserver 127.0.0.1\nsearch example\noptions ndots:0\n" {
+		t.Errorf("unexpected resolv.conf: %q", changed)
+	}
+}
+
+func TestWriteRemove(t *testing.T) {
+	var dir = t.TempDir()
+	var data = filepath.Join(dir, "data.db")
+	if err := os.WriteFile(data, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var file, err = write(filepath.Join(dir, "chaos-stress"), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remove(file)
+	if _, err = os.Stat(filepath.Join(dir, "chaos-stress")); !os.IsNotExist(err) {
+		t.Errorf("the folder of the stressor must be removed: %v", err)
+	}
+
+	// the folder of the container is not empty, so it is kept
+	remove(data + ".missing")
+	if _, err = os.Stat(data); err != nil {
+		t.Errorf("the files of the container must be kept: %v", err)
+	}
+}
+
+// dnsTestStandIn
+//
+// Returns a stand-in on a free port of the loopback, with the files in a temporary folder
+func dnsTestStandIn(t *testing.T) (standIn dnsStandIn, resolvConf string) {
+	var conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listen = conn.LocalAddr().String()
+	_ = conn.Close()
+
+	var dir = t.TempDir()
+	resolvConf = filepath.Join(dir, "resolv.conf")
+	if err = os.WriteFile(resolvConf, []byte("nameserver 127.0.0.11\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	standIn = dnsStandIn{
+		mode:     "nxdomain",
+		upstream: "127.0.0.1:1",
+		listen:   listen,
+		backup:   filepath.Join(dir, "resolv.conf.backup"),
+		status:   filepath.Join(dir, "status"),
+	}
+	return
+}
+
+func TestDnsStandIn_serve(t *testing.T) {
+	var standIn, resolvConf = dnsTestStandIn(t)
+
+	var done = make(chan error)
+	go func() {
+		done <- standIn.serve(resolvConf, time.Now().Add(2*time.Second))
+	}()
+
+	if err := dnsWait(standIn.status, 2*time.Second); err != nil {
+		t.Fatalf("dnsWait().error: %v", err)
+	}
+
+	if data, _ := os.ReadFile(resolvConf); !strings.HasPrefix(string(data), "nameserver 127.0.0.1\n") {
+		t.Errorf("the resolver must point to the stand-in: %q", data)
+	}
+
+	// udp
+	conn, err := net.Dial("udp", standIn.listen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(time.Second))
+	if _, err = conn.Write(queryDb); err != nil {
+		t.Fatal(err)
+	}
+	var buffer = make([]byte, 512)
+	if size, err := conn.Read(buffer); err != nil || size < 4 || buffer[3]&0x0f != kDnsRCodeNxDomain {
+		t.Errorf("unexpected udp answer: % x, %v", buffer[:size], err)
+	}
+	_ = conn.Close()
+
+	// tcp, the fallback of the resolver
+	if conn, err = net.Dial("tcp", standIn.listen); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(time.Second))
+	if _, err = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(queryDb))), queryDb...)); err != nil {
+		t.Fatal(err)
+	}
+	if response, err := dnsReadTcp(conn); err != nil || len(response) < 4 || response[3]&0x0f != kDnsRCodeNxDomain {
+		t.Errorf("unexpected tcp answer: % x, %v", response, err)
+	}
+	_ = conn.Close()
+
+	if err = <-done; err != nil {
+		t.Fatalf("serve().error: %v", err)
+	}
+
+	if data, _ := os.ReadFile(resolvConf); string(data) != "nameserver 127.0.0.11\n" {
+		t.Errorf("the resolver must be restored: %q", data)
+	}
+
+	if _, err = os.Stat(standIn.backup); !os.IsNotExist(err) {
+		t.Errorf("the backup must be removed after the restore: %v", err)
+	}
+}
+
+func TestDnsStandIn_serveError(t *testing.T) {
+	var standIn, resolvConf = dnsTestStandIn(t)
+	standIn.mode = "wrong"
+
+	if err := standIn.serve(resolvConf, time.Now().Add(time.Second)); err == nil {
+		t.Fatalf("the dns failure wrong without address must fail")
+	}
+
+	if err := dnsWait(standIn.status, time.Second); err == nil || !strings.Contains(err.Error(), "-address") {
+		t.Errorf("the error of the start must be reported by the status: %v", err)
+	}
+}
+
+func TestDnsRestore(t *testing.T) {
+	var standIn, resolvConf = dnsTestStandIn(t)
+
+	// the stand-in was killed before the restore
+	if err := os.WriteFile(standIn.backup, []byte("nameserver 127.0.0.11\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(resolvConf, []byte("nameserver 127.0.0.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dnsRestore(resolvConf, standIn.backup, standIn.status, 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(resolvConf); string(data) != "nameserver 127.0.0.11\n" {
+		t.Errorf("the resolver must be restored from the backup: %q", data)
+	}
+
+	// the backup of a killed stand-in is the original of the next window
+	if original, err := standIn.original(resolvConf); err != nil || string(original) != "nameserver 127.0.0.11\n" {
+		t.Errorf("unexpected original: %q, %v", original, err)
+	}
+}
@@ -0,0 +1,132 @@
+// Package stressor
+//
+// English:
+//
+//	Builds the chaos-stress binary, copied into the containers by the resource-pressure chaos.
+//
+// Português:
+//
+//	Compila o binário chaos-stress, copiado para os containers pelo caos de pressão de recursos.
+package stressor
+
+import (
+	"archive/tar"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// KBinaryName is the name of the binary inside the container
+const KBinaryName = "chaos-stress"
+
+//go:embed cmd/main.go
+var source []byte
+
+// Build
+//
+// English:
+//
+//	Compiles the stressor, with the go compiler of the host computer, for the platform of the container.
+//
+//	 Input:
+//	   dir: folder where the binary is written
+//	   platform: platform in the format os[/arch[/variant]]. Use "" for linux and the architecture of the host
+//
+//	 Output:
+//	   binary: path of the binary
+//
+// Português:
+//
+//	Compila o estressor, com o compilador go do computador hospedeiro, para a plataforma do container.
+//
+//	 Entrada:
+//	   dir: pasta onde o binário é escrito
+//	   platform: plataforma no formato os[/arch[/variant]]. Use "" para linux e a arquitetura do hospedeiro
+//
+//	 Saída:
+//	   binary: caminho do binário
+func Build(dir, platform string) (binary string, err error) {
+	var goBinary string
+	if goBinary, err = exec.LookPath("go"); err != nil {
+		return
+	}
+
+	var env = []string{"CGO_ENABLED=0", "GOOS=linux", "GOARCH=" + runtime.GOARCH}
+	var part = strings.Split(platform, "/")
+	if platform != "" {
+		env[1] = "GOOS=" + part[0]
+	}
+	if len(part) > 1 {
+		env[2] = "GOARCH=" + part[1]
+	}
+	if len(part) > 2 && part[1] == "arm" {
+		env = append(env, "GOARM="+strings.TrimPrefix(part[2], "v"))
+	}
+
+	var src = filepath.Join(dir, "src")
+	if err = os.MkdirAll(src, 0755); err != nil {
+		return
+	}
+
+	if err = os.WriteFile(filepath.Join(src, "go.mod"), []byte("module chaos-stress\n\ngo 1.19\n"), 0644); err != nil {
+		return
+	}
+
+	if err = os.WriteFile(filepath.Join(src, "main.go"), source, 0644); err != nil {
+		return
+	}
+
+	binary = filepath.Join(dir, KBinaryName)
+
+	var cmd = exec.Command(goBinary, "build", "-trimpath", "-ldflags=-s -w", "-o", binary, ".")
+	cmd.Dir = src
+	// the stressor is a module of its own, so the workspace and flags of the user's project don't apply
+	cmd.Env = append(append(os.Environ(), "GOWORK=off", "GOFLAGS="), env...)
+
+	var output []byte
+	if output, err = cmd.CombinedOutput(); err != nil {
+		err = fmt.Errorf("go build: %v: %s", err, output)
+	}
+
+	return
+}
+
+// Tar
+//
+// English:
+//
+//	Archives the binary in the tar format used by ContainerCopyTo()
+//
+// Português:
+//
+//	Arquiva o binário no formato tar usado por ContainerCopyTo()
+func Tar(binary string) (archive *bytes.Buffer, err error) {
+	var data []byte
+	if data, err = os.ReadFile(binary); err != nil {
+		return
+	}
+
+	archive = new(bytes.Buffer)
+	var writer = tar.NewWriter(archive)
+
+	err = writer.WriteHeader(&tar.Header{
+		Name: KBinaryName,
+		Mode: 0755,
+		Size: int64(len(data)),
+	})
+	if err != nil {
+		return
+	}
+
+	if _, err = writer.Write(data); err != nil {
+		return
+	}
+
+	err = writer.Close()
+	return
+}
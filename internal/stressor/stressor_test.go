@@ -0,0 +1,33 @@
+package stressor
+
+import (
+	"archive/tar"
+	"os"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	var binary, err = Build(t.TempDir(), "linux/arm64")
+	if err != nil {
+		t.Fatalf("Build().error: %v", err)
+	}
+
+	var info os.FileInfo
+	if info, err = os.Stat(binary); err != nil || info.Size() == 0 {
+		t.Fatalf("binary not found: %v", err)
+	}
+
+	archive, err := Tar(binary)
+	if err != nil {
+		t.Fatalf("Tar().error: %v", err)
+	}
+
+	var header *tar.Header
+	if header, err = tar.NewReader(archive).Next(); err != nil {
+		t.Fatalf("tar.Next().error: %v", err)
+	}
+
+	if header.Name != KBinaryName || header.Size != info.Size() || header.Mode != 0755 {
+		t.Errorf("unexpected header: %+v", header)
+	}
+}